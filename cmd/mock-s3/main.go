@@ -0,0 +1,157 @@
+// Command mock-s3 is a local stand-in for an S3-compatible bucket, for trying the s3 storage
+// driver without a cloud account. It keeps objects in memory and only understands path-style
+// PUT, GET, HEAD and DELETE of single objects. Requests and presigned URLs have to carry a
+// valid Signature Version 4 signature made with the configured access key and secret key,
+// and payload hashes are checked like S3 does.
+//
+//	go run ./cmd/mock-s3 -addr :9100 -bucket si-lab -access-key local -secret-key local-secret
+//
+// Then run the API with STORAGE_DRIVER=s3, S3_ENDPOINT=http://localhost:9100, S3_BUCKET=si-lab,
+// S3_ACCESS_KEY_ID=local, S3_SECRET_ACCESS_KEY=local-secret and S3_PATH_STYLE=true.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/egasa21/si-lab-api-go/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+type object struct {
+	body        []byte
+	contentType string
+	modified    time.Time
+}
+
+type bucket struct {
+	name      string
+	accessKey string
+	secretKey string
+
+	mu      sync.RWMutex
+	objects map[string]object
+}
+
+func main() {
+	addr := flag.String("addr", ":9100", "listen address")
+	name := flag.String("bucket", "si-lab", "bucket name, must match S3_BUCKET")
+	accessKey := flag.String("access-key", "local", "accepted access key id")
+	secretKey := flag.String("secret-key", "local-secret", "secret key requests have to be signed with")
+	flag.Parse()
+
+	b := &bucket{name: *name, accessKey: *accessKey, secretKey: *secretKey, objects: make(map[string]object)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /"+b.name+"/{key...}", b.put)
+	mux.HandleFunc("GET /"+b.name+"/{key...}", b.get)
+	mux.HandleFunc("HEAD /"+b.name+"/{key...}", b.get)
+	mux.HandleFunc("DELETE /"+b.name+"/{key...}", b.delete)
+
+	log.Info().Str("addr", *addr).Str("bucket", b.name).Msg("Mock S3 listening")
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		log.Fatal().Err(err).Msg("Mock S3 stopped")
+	}
+}
+
+func (b *bucket) put(w http.ResponseWriter, r *http.Request) {
+	if !b.authorized(w, r) {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s3Error(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+
+	// Like S3, the body has to match the hash it was signed with
+	if expected := r.Header.Get("X-Amz-Content-Sha256"); expected != "" && expected != "UNSIGNED-PAYLOAD" {
+		sum := sha256.Sum256(body)
+		if hex.EncodeToString(sum[:]) != expected {
+			s3Error(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed.")
+			return
+		}
+	}
+
+	b.mu.Lock()
+	b.objects[r.PathValue("key")] = object{body: body, contentType: r.Header.Get("Content-Type"), modified: time.Now()}
+	b.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (b *bucket) get(w http.ResponseWriter, r *http.Request) {
+	if !b.authorized(w, r) {
+		return
+	}
+
+	b.mu.RLock()
+	obj, found := b.objects[r.PathValue("key")]
+	b.mu.RUnlock()
+	if !found {
+		s3Error(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+
+	w.Header().Set("Content-Type", obj.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(obj.body)))
+	w.Header().Set("Last-Modified", obj.modified.UTC().Format(http.TimeFormat))
+	if disposition := r.URL.Query().Get("response-content-disposition"); disposition != "" {
+		w.Header().Set("Content-Disposition", disposition)
+	}
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(obj.body)
+	}
+}
+
+func (b *bucket) delete(w http.ResponseWriter, r *http.Request) {
+	if !b.authorized(w, r) {
+		return
+	}
+
+	b.mu.Lock()
+	delete(b.objects, r.PathValue("key"))
+	b.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorized checks the Signature Version 4 signature of the request or of the presigned URL
+func (b *bucket) authorized(w http.ResponseWriter, r *http.Request) bool {
+	err := storage.VerifySigV4(r, b.accessKey, b.secretKey, time.Now())
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, storage.ErrSigV4AccessKey):
+		s3Error(w, http.StatusForbidden, "InvalidAccessKeyId", "The AWS Access Key Id you provided does not exist in our records.")
+	case errors.Is(err, storage.ErrSigV4DoesNotMatch):
+		s3Error(w, http.StatusForbidden, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.")
+	case errors.Is(err, storage.ErrSigV4TimeSkewed):
+		s3Error(w, http.StatusForbidden, "RequestTimeTooSkewed", "The difference between the request time and the current time is too large.")
+	case errors.Is(err, storage.ErrSigV4Expired):
+		s3Error(w, http.StatusForbidden, "AccessDenied", "Request has expired.")
+	default:
+		s3Error(w, http.StatusForbidden, "AccessDenied", "Request is not signed.")
+	}
+	return false
+}
+
+func s3Error(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: message})
+}
@@ -17,6 +17,43 @@ type Config struct {
 	AppPort       string
 	LogLevel      string
 	LogErrorStack bool
+	FrontendURL   string
+	Notifier      string
+	NotifierFile  string
+	// RequireEmailVerification blocks login until the account email is verified
+	RequireEmailVerification bool
+	// LoginAttemptStore is where failed login counters are kept, "postgres" or "memory"
+	LoginAttemptStore string
+	// TrustProxyHeaders takes the client IP from X-Forwarded-For, only enable it behind a proxy
+	TrustProxyHeaders bool
+	// JWTKeysDir holds the <kid>.pem files tokens are signed and verified with
+	JWTKeysDir string
+	// JWTSigningKeyID is the kid of the key new tokens are signed with
+	JWTSigningKeyID string
+	// JWTPrivateKey is an inline PEM signing key, an alternative to JWTKeysDir
+	JWTPrivateKey string
+	// OIDC single sign-on, disabled when OIDCIssuerURL is empty
+	OIDCIssuerURL      string
+	OIDCClientID       string
+	OIDCClientSecret   string
+	OIDCRedirectURL    string
+	OIDCScopes         []string
+	OIDCStudentIDClaim string
+	// StorageDriver keeps attachments on the local disk or in an S3-compatible bucket, "local" or "s3"
+	StorageDriver string
+	// StorageLocalDir is where the local driver keeps files
+	StorageLocalDir string
+	// StoragePublicURL is the address of this API that local download links point at
+	StoragePublicURL string
+	// StorageSigningKey signs the download links of the local driver
+	StorageSigningKey string
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	// S3PathStyle puts the bucket in the path, MinIO and cmd/mock-s3 need it
+	S3PathStyle bool
 }
 
 func LoadConfig() *Config {
@@ -39,6 +76,21 @@ func LoadConfig() *Config {
 		port = "8080" // Default port
 	}
 
+	frontendURL := os.Getenv("FRONTEND_URL")
+	if frontendURL == "" {
+		frontendURL = "http://localhost:5173"
+	}
+
+	oidcStudentIDClaim := os.Getenv("OIDC_STUDENT_ID_CLAIM")
+	if oidcStudentIDClaim == "" {
+		oidcStudentIDClaim = "student_id_number"
+	}
+
+	storagePublicURL := os.Getenv("STORAGE_PUBLIC_URL")
+	if storagePublicURL == "" {
+		storagePublicURL = "http://localhost:" + port
+	}
+
 	return &Config{
 		DBHost:        os.Getenv("DB_HOST"),
 		DBPort:        os.Getenv("DB_PORT"),
@@ -48,5 +100,34 @@ func LoadConfig() *Config {
 		AppPort:       port,
 		LogLevel:      os.Getenv("LOG_LEVEL"),
 		LogErrorStack: logErrorStack,
+		FrontendURL:   strings.TrimRight(frontendURL, "/"),
+		Notifier:      os.Getenv("NOTIFIER"),
+		NotifierFile:  os.Getenv("NOTIFIER_FILE_PATH"),
+
+		RequireEmailVerification: strings.ToLower(os.Getenv("REQUIRE_EMAIL_VERIFICATION")) == "true",
+		LoginAttemptStore:        strings.ToLower(os.Getenv("LOGIN_ATTEMPT_STORE")),
+		TrustProxyHeaders:        strings.ToLower(os.Getenv("TRUST_PROXY_HEADERS")) == "true",
+
+		JWTKeysDir:      os.Getenv("JWT_KEYS_DIR"),
+		JWTSigningKeyID: os.Getenv("JWT_SIGNING_KEY_ID"),
+		JWTPrivateKey:   os.Getenv("JWT_PRIVATE_KEY"),
+
+		OIDCIssuerURL:      os.Getenv("OIDC_ISSUER_URL"),
+		OIDCClientID:       os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret:   os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:    os.Getenv("OIDC_REDIRECT_URL"),
+		OIDCScopes:         strings.Fields(os.Getenv("OIDC_SCOPES")),
+		OIDCStudentIDClaim: oidcStudentIDClaim,
+
+		StorageDriver:     strings.ToLower(os.Getenv("STORAGE_DRIVER")),
+		StorageLocalDir:   os.Getenv("STORAGE_LOCAL_DIR"),
+		StoragePublicURL:  strings.TrimRight(storagePublicURL, "/"),
+		StorageSigningKey: os.Getenv("STORAGE_SIGNING_KEY"),
+		S3Endpoint:        os.Getenv("S3_ENDPOINT"),
+		S3Region:          os.Getenv("S3_REGION"),
+		S3Bucket:          os.Getenv("S3_BUCKET"),
+		S3AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		S3PathStyle:       strings.ToLower(os.Getenv("S3_PATH_STYLE")) == "true",
 	}
 }
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_practicum_staff_user;
+
+DROP TABLE IF EXISTS practicum_staff;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS practicum_staff (
+    id SERIAL PRIMARY KEY,
+    id_practicum INT NOT NULL,
+    id_user INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (id_practicum, id_user),
+    FOREIGN KEY (id_practicum) REFERENCES practicums(id_practicum) ON DELETE CASCADE,
+    FOREIGN KEY (id_user) REFERENCES users(id_user) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_practicum_staff_user ON practicum_staff(id_user);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_access_token_denylist_expires_at;
+DROP TABLE IF EXISTS access_token_denylist;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS access_token_denylist (
+    jti UUID PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_token_denylist_expires_at ON access_token_denylist(expires_at);
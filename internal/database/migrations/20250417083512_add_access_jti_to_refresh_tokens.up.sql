@@ -0,0 +1,3 @@
+-- the access token issued together with a refresh token, so logging out every session can deny it
+ALTER TABLE refresh_tokens
+ADD COLUMN IF NOT EXISTS access_jti UUID;
@@ -0,0 +1,7 @@
+-- Keep roles that are still granted to users
+DELETE FROM roles
+WHERE
+    name IN ('admin', 'student', 'lecturer', 'laboratory_assistant')
+    AND NOT EXISTS (
+        SELECT 1 FROM user_roles ur WHERE ur.id_role = roles.id
+    );
@@ -0,0 +1,7 @@
+-- Roles are looked up by name, so their ids no longer matter
+INSERT INTO roles (name) VALUES
+('admin'),
+('student'),
+('lecturer'),
+('laboratory_assistant')
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS staff_profiles (
+    id SERIAL PRIMARY KEY,
+    id_user INT NOT NULL UNIQUE,
+    employee_id_number VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (id_user) REFERENCES users(id_user) ON DELETE CASCADE
+);
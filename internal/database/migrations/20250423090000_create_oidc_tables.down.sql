@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS oidc_auth_requests;
+DROP INDEX IF EXISTS idx_user_identities_user;
+DROP TABLE IF EXISTS user_identities;
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_practicums_name_active;
+
+-- Deleted practicums may share their name with another practicum, they get their ID appended
+-- so the name can be unique again. The original names aren't restored by a later up.
+UPDATE practicums p
+SET name = LEFT(p.name, 240) || ' (' || p.id_practicum || ')'
+WHERE p.deleted_at IS NOT NULL
+  AND EXISTS (
+      SELECT 1 FROM practicums other
+      WHERE other.name = p.name AND other.id_practicum <> p.id_practicum
+        AND (other.deleted_at IS NULL OR other.id_practicum < p.id_practicum)
+  );
+
+ALTER TABLE practicums ADD CONSTRAINT practicums_name_key UNIQUE (name);
+ALTER TABLE practicums DROP COLUMN IF EXISTS deleted_at;
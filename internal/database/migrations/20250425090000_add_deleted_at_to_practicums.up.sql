@@ -0,0 +1,5 @@
+ALTER TABLE practicums ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- A deleted practicum shouldn't keep its name from being reused
+ALTER TABLE practicums DROP CONSTRAINT IF EXISTS practicums_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_practicums_name_active ON practicums (name) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_practicums_semester;
+DROP INDEX IF EXISTS idx_practicums_search_vector;
+ALTER TABLE practicums DROP COLUMN IF EXISTS search_vector;
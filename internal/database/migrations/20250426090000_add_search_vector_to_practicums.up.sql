@@ -0,0 +1,9 @@
+-- 'simple' keeps words as written, the catalogue mixes Indonesian and English
+ALTER TABLE practicums ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(code, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_practicums_search_vector ON practicums USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_practicums_semester ON practicums (semester) WHERE deleted_at IS NULL;
@@ -0,0 +1,20 @@
+DROP INDEX IF EXISTS idx_practicums_name_term_active;
+
+-- Practicums of different terms may share a name, all but the first one get their ID appended
+-- so the name can be unique again. The original names aren't restored by a later up.
+UPDATE practicums p
+SET name = LEFT(p.name, 240) || ' (' || p.id_practicum || ')'
+WHERE p.deleted_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM practicums other
+      WHERE other.name = p.name AND other.deleted_at IS NULL
+        AND other.id_practicum < p.id_practicum
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_practicums_name_active ON practicums (name) WHERE deleted_at IS NULL;
+
+ALTER TABLE student_registration DROP COLUMN IF EXISTS academic_term_id;
+ALTER TABLE practicum_class DROP COLUMN IF EXISTS academic_term_id;
+ALTER TABLE practicums DROP COLUMN IF EXISTS academic_term_id;
+
+DROP TABLE IF EXISTS academic_terms;
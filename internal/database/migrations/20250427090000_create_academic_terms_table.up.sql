@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS academic_terms (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(20) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    registration_opens_at TIMESTAMP WITH TIME ZONE,
+    registration_closes_at TIMESTAMP WITH TIME ZONE,
+    is_active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date > start_date),
+    CHECK (registration_closes_at IS NULL OR registration_opens_at IS NULL OR registration_closes_at > registration_opens_at)
+);
+
+-- At most one term is the current one
+CREATE UNIQUE INDEX IF NOT EXISTS idx_academic_terms_active ON academic_terms (is_active) WHERE is_active;
+
+-- Existing rows keep a NULL term, they predate terms
+ALTER TABLE practicums ADD COLUMN academic_term_id INT REFERENCES academic_terms (id) ON DELETE RESTRICT;
+ALTER TABLE practicum_class ADD COLUMN academic_term_id INT REFERENCES academic_terms (id) ON DELETE RESTRICT;
+ALTER TABLE student_registration ADD COLUMN academic_term_id INT REFERENCES academic_terms (id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_practicums_academic_term_id ON practicums (academic_term_id);
+CREATE INDEX IF NOT EXISTS idx_practicum_class_academic_term_id ON practicum_class (academic_term_id);
+CREATE INDEX IF NOT EXISTS idx_student_registration_academic_term_id ON student_registration (academic_term_id);
+
+-- The same practicum runs again every year, so the name only has to be unique within a term
+DROP INDEX IF EXISTS idx_practicums_name_active;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_practicums_name_term_active ON practicums (name, COALESCE(academic_term_id, 0)) WHERE deleted_at IS NULL;
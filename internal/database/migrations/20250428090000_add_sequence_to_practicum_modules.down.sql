@@ -0,0 +1,2 @@
+ALTER TABLE practicum_modules DROP CONSTRAINT IF EXISTS unique_practicum_module_sequence;
+ALTER TABLE practicum_modules DROP COLUMN IF EXISTS sequence;
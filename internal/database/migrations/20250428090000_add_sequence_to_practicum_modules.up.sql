@@ -0,0 +1,16 @@
+ALTER TABLE practicum_modules ADD COLUMN sequence INT;
+
+-- Existing modules keep the order they were created in
+UPDATE practicum_modules m
+SET sequence = o.sequence
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY practicum_id ORDER BY id) AS sequence
+    FROM practicum_modules
+) o
+WHERE m.id = o.id;
+
+ALTER TABLE practicum_modules ALTER COLUMN sequence SET NOT NULL;
+
+-- Deferrable so a reorder can swap positions inside one transaction
+ALTER TABLE practicum_modules
+ADD CONSTRAINT unique_practicum_module_sequence UNIQUE (practicum_id, sequence) DEFERRABLE INITIALLY IMMEDIATE;
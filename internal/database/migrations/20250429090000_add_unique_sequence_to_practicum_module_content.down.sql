@@ -0,0 +1 @@
+ALTER TABLE practicum_module_content DROP CONSTRAINT IF EXISTS unique_module_content_sequence;
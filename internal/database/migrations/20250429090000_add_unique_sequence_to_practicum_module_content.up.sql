@@ -0,0 +1,12 @@
+-- Renumber every module 1..n, keeping the current order and breaking ties by creation
+UPDATE practicum_module_content c
+SET sequence = o.sequence
+FROM (
+    SELECT id_content, ROW_NUMBER() OVER (PARTITION BY id_module ORDER BY sequence, id_content) AS sequence
+    FROM practicum_module_content
+) o
+WHERE c.id_content = o.id_content AND c.sequence <> o.sequence;
+
+-- Deferrable so a reorder can swap positions inside one transaction
+ALTER TABLE practicum_module_content
+ADD CONSTRAINT unique_module_content_sequence UNIQUE (id_module, sequence) DEFERRABLE INITIALLY IMMEDIATE;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS practicum_module_content_versions;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS practicum_module_content_versions (
+    id SERIAL PRIMARY KEY,
+    material_id UUID NOT NULL,
+    version INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    content JSONB NOT NULL,
+    -- NULL for the versions recorded when this table was created and for deleted users
+    id_author INT,
+    -- Set when the version was made by restoring an older one
+    restored_from INT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (material_id) REFERENCES practicum_module_content (material_id) ON DELETE CASCADE,
+    FOREIGN KEY (id_author) REFERENCES users (id_user) ON DELETE SET NULL,
+    UNIQUE (material_id, version)
+);
+
+-- The current content of every material becomes its first version
+INSERT INTO practicum_module_content_versions (material_id, version, title, content, created_at)
+SELECT material_id, 1, title, content, updated_at
+FROM practicum_module_content;
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_practicum_module_content_status;
+
+ALTER TABLE practicum_module_content
+    DROP CONSTRAINT IF EXISTS practicum_module_content_status_check,
+    DROP COLUMN IF EXISTS review_note,
+    DROP COLUMN IF EXISTS reviewed_at,
+    DROP COLUMN IF EXISTS reviewed_by,
+    DROP COLUMN IF EXISTS submitted_at,
+    DROP COLUMN IF EXISTS submitted_by,
+    DROP COLUMN IF EXISTS publish_at,
+    DROP COLUMN IF EXISTS published_version,
+    DROP COLUMN IF EXISTS status;
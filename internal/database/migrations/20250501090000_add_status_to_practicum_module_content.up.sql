@@ -0,0 +1,20 @@
+-- status is the state of the latest revision, published_version the revision students see.
+-- Editing published content starts a new draft while students keep the published version.
+ALTER TABLE practicum_module_content
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    ADD COLUMN published_version INT,
+    ADD COLUMN publish_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN submitted_by INT REFERENCES users (id_user) ON DELETE SET NULL,
+    ADD COLUMN submitted_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN reviewed_by INT REFERENCES users (id_user) ON DELETE SET NULL,
+    ADD COLUMN reviewed_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN review_note TEXT,
+    ADD CONSTRAINT practicum_module_content_status_check
+        CHECK (status IN ('draft', 'in_review', 'published', 'archived'));
+
+-- Content students could already see stays visible
+UPDATE practicum_module_content c
+SET status = 'published',
+    published_version = (SELECT MAX(v.version) FROM practicum_module_content_versions v WHERE v.material_id = c.material_id);
+
+CREATE INDEX idx_practicum_module_content_status ON practicum_module_content (status);
@@ -0,0 +1,3 @@
+ALTER TABLE students DROP COLUMN IF EXISTS study_plan_attachment_id;
+
+DROP TABLE IF EXISTS attachments;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS attachments (
+    id UUID PRIMARY KEY,
+    purpose VARCHAR(20) NOT NULL CHECK (purpose IN ('material', 'study_plan')),
+    storage_key VARCHAR(512) NOT NULL UNIQUE,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(127) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes >= 0),
+    checksum_sha256 CHAR(64) NOT NULL,
+    uploaded_by INT REFERENCES users (id_user) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_attachments_uploaded_by ON attachments (uploaded_by);
+
+-- study_plan_file stays for plans recorded before uploads existed
+ALTER TABLE students
+    ADD COLUMN study_plan_attachment_id UUID REFERENCES attachments (id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS study_plan_practicums;
+DROP TABLE IF EXISTS study_plans;
@@ -0,0 +1,24 @@
+-- A study plan (KRS) is the PDF a student uploads each term with the practicums it lists.
+-- Staff check the file against the list, registration only accepts practicums of an approved plan.
+CREATE TABLE IF NOT EXISTS study_plans (
+    id SERIAL PRIMARY KEY,
+    student_id INT NOT NULL REFERENCES students (id) ON DELETE CASCADE,
+    attachment_id UUID NOT NULL REFERENCES attachments (id) ON DELETE RESTRICT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    rejection_reason TEXT,
+    reviewed_by INT REFERENCES users (id_user) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (status <> 'rejected' OR rejection_reason IS NOT NULL)
+);
+
+CREATE TABLE IF NOT EXISTS study_plan_practicums (
+    study_plan_id INT NOT NULL REFERENCES study_plans (id) ON DELETE CASCADE,
+    practicum_id INT NOT NULL REFERENCES practicums (id_practicum) ON DELETE CASCADE,
+    PRIMARY KEY (study_plan_id, practicum_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_study_plans_student_id ON study_plans (student_id);
+CREATE INDEX IF NOT EXISTS idx_study_plans_status ON study_plans (status);
+CREATE INDEX IF NOT EXISTS idx_study_plan_practicums_practicum_id ON study_plan_practicums (practicum_id);
@@ -0,0 +1,6 @@
+-- Scheduled versions hold back the published version again until publish_at
+UPDATE practicum_module_content
+SET published_version = scheduled_version
+WHERE scheduled_version IS NOT NULL;
+
+ALTER TABLE practicum_module_content DROP COLUMN IF EXISTS scheduled_version;
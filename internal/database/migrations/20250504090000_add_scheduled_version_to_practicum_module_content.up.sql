@@ -0,0 +1,8 @@
+-- scheduled_version takes over from published_version at publish_at, so content students can
+-- already see stays visible until a newer version is due
+ALTER TABLE practicum_module_content ADD COLUMN scheduled_version INT;
+
+-- publish_at used to hold back published_version itself, pending ones become scheduled versions
+UPDATE practicum_module_content
+SET scheduled_version = published_version, published_version = NULL
+WHERE published_version IS NOT NULL AND publish_at > NOW();
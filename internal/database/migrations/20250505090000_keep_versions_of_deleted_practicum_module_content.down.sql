@@ -0,0 +1,9 @@
+-- The history of deleted content goes, the foreign key can't be restored with it
+DELETE FROM practicum_module_content_versions v
+WHERE NOT EXISTS (SELECT 1 FROM practicum_module_content c WHERE c.material_id = v.material_id);
+
+ALTER TABLE practicum_module_content_versions DROP COLUMN IF EXISTS id_module;
+
+ALTER TABLE practicum_module_content_versions
+    ADD CONSTRAINT practicum_module_content_versions_material_id_fkey
+        FOREIGN KEY (material_id) REFERENCES practicum_module_content (material_id) ON DELETE CASCADE;
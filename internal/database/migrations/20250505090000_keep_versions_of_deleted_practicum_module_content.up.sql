@@ -0,0 +1,6 @@
+-- Deleting content used to delete its version history with it. The versions are kept under
+-- their material_id now, so the history of deleted content can still be read and restored.
+ALTER TABLE practicum_module_content_versions DROP CONSTRAINT practicum_module_content_versions_material_id_fkey;
+
+-- The module deleted content was in, restoring it puts it back there
+ALTER TABLE practicum_module_content_versions ADD COLUMN id_module INT;
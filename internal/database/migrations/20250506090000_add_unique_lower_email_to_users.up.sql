@@ -0,0 +1,18 @@
+-- Emails differing only in case belong to one address, the oldest account keeps it and the
+-- others get their ID prefixed so an admin can merge or remove them
+UPDATE users u
+SET email = 'duplicate-' || u.id_user || '+' || LEFT(u.email, 230), updated_at = CURRENT_TIMESTAMP
+WHERE EXISTS (
+    SELECT 1 FROM users other
+    WHERE LOWER(other.email) = LOWER(u.email) AND other.id_user < u.id_user
+);
+
+UPDATE users SET email = LOWER(email), updated_at = CURRENT_TIMESTAMP WHERE email <> LOWER(email);
+
+-- Pending verification links are compared against the stored email
+UPDATE email_verification_tokens t
+SET email = u.email
+FROM users u
+WHERE u.id_user = t.id_user AND LOWER(t.email) = u.email AND t.email <> u.email;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email));
@@ -0,0 +1,13 @@
+package dto
+
+import "time"
+
+// AcademicTermRequest takes start_date and end_date as YYYY-MM-DD
+type AcademicTermRequest struct {
+	Code                 string     `json:"code" validate:"required"`
+	Name                 string     `json:"name" validate:"required"`
+	StartDate            string     `json:"start_date" validate:"required"`
+	EndDate              string     `json:"end_date" validate:"required"`
+	RegistrationOpensAt  *time.Time `json:"registration_opens_at"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type RegisterRequest struct {
 	Email     string `json:"email"`
 	Password  string `json:"password"`
@@ -10,3 +12,53 @@ type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type UpdateProfileRequest struct {
+	Email           string `json:"email"`
+	CurrentPassword string `json:"current_password"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+type GrantRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type StaffProfileRequest struct {
+	EmployeeIDNumber string `json:"employee_id_number"`
+	Name             string `json:"name"`
+}
+
+type CreateUserRequest struct {
+	Email        string               `json:"email"`
+	Password     string               `json:"password"`
+	Roles        []string             `json:"roles"`
+	IDStudent    int                  `json:"id_student"`
+	StaffProfile *StaffProfileRequest `json:"staff_profile"`
+}
+
+type CreatePersonalAccessTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
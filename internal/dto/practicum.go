@@ -20,3 +20,32 @@ type PracticumWithMaterialResponse struct {
 	Semester    string           `json:"semester"`
 	Modules     []ModuleResponse `json:"modules"`
 }
+
+type UpdatePracticumRequest struct {
+	Code        string `json:"code" validate:"required"`
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+	Credits     string `json:"credits" validate:"required"`
+	Semester    string `json:"semester" validate:"required"`
+	// AcademicTermID may be null for practicums that aren't tied to a term
+	AcademicTermID *int `json:"academic_term_id"`
+}
+
+// PatchPracticumRequest leaves fields that are omitted unchanged
+type PatchPracticumRequest struct {
+	Code        *string `json:"code"`
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Credits     *string `json:"credits"`
+	Semester    *string `json:"semester"`
+	// AcademicTermID moves the practicum and its classes to another term
+	AcademicTermID *int `json:"academic_term_id"`
+}
+
+// ClonePracticumRequest copies a practicum into a term, the current term when academic_term_id is omitted
+type ClonePracticumRequest struct {
+	AcademicTermID *int `json:"academic_term_id"`
+	// Name defaults to the name of the source practicum
+	Name           string `json:"name"`
+	IncludeClasses bool   `json:"include_classes"`
+}
@@ -6,6 +6,16 @@ type CreatePracticumModuleRequest struct {
 }
 
 type PracticumModuleResponse struct {
-	ID    uint   `json:"id"`
-	Title string `json:"title"`
+	ID       uint   `json:"id"`
+	Title    string `json:"title"`
+	Sequence int    `json:"sequence"`
+}
+
+type UpdatePracticumModuleRequest struct {
+	Title string `json:"title" binding:"required"`
+}
+
+// ReorderPracticumModulesRequest lists every module of the practicum in its new order
+type ReorderPracticumModulesRequest struct {
+	ModuleIDs []int `json:"module_ids" binding:"required"`
 }
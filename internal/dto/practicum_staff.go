@@ -0,0 +1,5 @@
+package dto
+
+type AssignPracticumStaffRequest struct {
+	UserID int `json:"user_id" validate:"required"`
+}
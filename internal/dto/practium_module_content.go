@@ -2,6 +2,7 @@ package dto
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -10,7 +11,6 @@ type CreatePracticumModuleContentRequest struct {
 	IDModule int             `json:"id_module" validate:"required"`
 	Title    string          `json:"title" validate:"required"`
 	Content  json.RawMessage `json:"content" validate:"required"`
-	Sequence int             `json:"sequence" validate:"required"`
 }
 
 type UpdatePracticumModuleContentRequest struct {
@@ -23,6 +23,28 @@ type UpdatePracticumModuleContentRequest struct {
 
 
 type PracticumModuleContentResponse struct {
-	ID    uint   `json:"id"`
-	Title string `json:"title"`
+	ID       uint   `json:"id"`
+	Title    string `json:"title"`
+	Sequence int    `json:"sequence"`
+}
+
+// ReorderPracticumModuleContentsRequest lists every content of the module in its new order
+type ReorderPracticumModuleContentsRequest struct {
+	ContentIDs []int `json:"content_ids" validate:"required"`
+}
+
+// MovePracticumModuleContentRequest moves content to another module, position 0 or omitted appends it
+type MovePracticumModuleContentRequest struct {
+	ModuleID int `json:"module_id" validate:"required"`
+	Position int `json:"position"`
+}
+
+// PublishPracticumModuleContentRequest schedules the publication, publish_at omitted publishes right away
+type PublishPracticumModuleContentRequest struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// RejectPracticumModuleContentRequest sends submitted content back to its author
+type RejectPracticumModuleContentRequest struct {
+	Note string `json:"note"`
 }
@@ -1,5 +1,7 @@
 package dto
 
+import "github.com/google/uuid"
+
 type StudentPracticumActivity struct {
 	ID                int    `json:"id"`
 	PracticumName     string `json:"practicum_name"`
@@ -21,3 +23,7 @@ type StudentSchedules struct {
 type StudentCreateResponse struct {
 	StudentID int `json:"student_id"`
 }
+
+type SetStudyPlanRequest struct {
+	AttachmentID uuid.UUID `json:"attachment_id"`
+}
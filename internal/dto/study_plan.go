@@ -0,0 +1,12 @@
+package dto
+
+import "github.com/google/uuid"
+
+type SubmitStudyPlanRequest struct {
+	AttachmentID uuid.UUID `json:"attachment_id"`
+	PracticumIDs []int     `json:"practicum_ids"`
+}
+
+type RejectStudyPlanRequest struct {
+	Reason string `json:"reason"`
+}
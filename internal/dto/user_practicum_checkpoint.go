@@ -1,7 +1,7 @@
 package dto
 
 type CreateUserPracticumCheckpointRequest struct {
-	UserID    int `json:"user_id" binding:"required"`
+	UserID    int `json:"user_id"` // optional, defaults to the authenticated user
 	PracticumID int `json:"practicum_id" binding:"required"`
 	ModuleID  int `json:"module_id" binding:"required"`
 	ContentID int `json:"content_id" binding:"required"`
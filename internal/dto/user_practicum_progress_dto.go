@@ -1,7 +1,7 @@
 package dto
 
 type CreateUserPracticumProgressRequest struct {
-	UserID      int     `json:"user_id" validate:"required"`
+	UserID      int     `json:"user_id"` // optional, defaults to the authenticated user
 	PracticumID int     `json:"practicum_id" validate:"required"`
 	Progress    float64 `json:"progress" validate:"required,min=0,max=100"`
 }
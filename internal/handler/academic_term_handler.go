@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/egasa21/si-lab-api-go/internal/dto"
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/pkg"
+	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
+	"github.com/egasa21/si-lab-api-go/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+const termDateLayout = "2006-01-02"
+
+type AcademicTermHandler struct {
+	service service.AcademicTermService
+}
+
+func NewAcademicTermHandler(service service.AcademicTermService) *AcademicTermHandler {
+	return &AcademicTermHandler{service: service}
+}
+
+func (h *AcademicTermHandler) CreateAcademicTerm(w http.ResponseWriter, r *http.Request) {
+	term, ok := decodeAcademicTerm(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.CreateAcademicTerm(term); err != nil {
+		writeAcademicTermError(w, err, "Failed to create academic term")
+		return
+	}
+
+	response.NewSuccessResponse(w, term, "Academic term created successfully")
+}
+
+func (h *AcademicTermHandler) GetAllAcademicTerms(w http.ResponseWriter, r *http.Request) {
+	terms, err := h.service.GetAllAcademicTerms()
+	if err != nil {
+		writeAcademicTermError(w, err, "Unable to fetch academic terms")
+		return
+	}
+
+	response.NewSuccessResponse(w, terms, "Academic terms retrieved successfully")
+}
+
+// GetCurrentAcademicTerm returns the active term list endpoints default to
+func (h *AcademicTermHandler) GetCurrentAcademicTerm(w http.ResponseWriter, r *http.Request) {
+	term, err := h.service.GetCurrentAcademicTerm()
+	if err != nil {
+		writeAcademicTermError(w, err, "Unable to fetch the current academic term")
+		return
+	}
+
+	response.NewSuccessResponse(w, term, "Academic term retrieved successfully")
+}
+
+func (h *AcademicTermHandler) GetAcademicTermByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	term, err := h.service.GetAcademicTermByID(id)
+	if err != nil {
+		writeAcademicTermError(w, err, "Unable to fetch academic term")
+		return
+	}
+
+	response.NewSuccessResponse(w, term, "Academic term retrieved successfully")
+}
+
+func (h *AcademicTermHandler) UpdateAcademicTerm(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	term, ok := decodeAcademicTerm(w, r)
+	if !ok {
+		return
+	}
+	term.ID = id
+
+	if err := h.service.UpdateAcademicTerm(term); err != nil {
+		writeAcademicTermError(w, err, "Failed to update academic term")
+		return
+	}
+
+	response.NewSuccessResponse(w, term, "Academic term updated successfully")
+}
+
+// ActivateAcademicTerm makes the term the current one, the previously active term is deactivated
+func (h *AcademicTermHandler) ActivateAcademicTerm(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	term, err := h.service.ActivateAcademicTerm(id)
+	if err != nil {
+		writeAcademicTermError(w, err, "Failed to activate academic term")
+		return
+	}
+
+	response.NewSuccessResponse(w, term, "Academic term activated successfully")
+}
+
+func decodeAcademicTerm(w http.ResponseWriter, r *http.Request) (*model.AcademicTerm, bool) {
+	var req dto.AcademicTermRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return nil, false
+	}
+
+	startDate, startErr := time.Parse(termDateLayout, req.StartDate)
+	endDate, endErr := time.Parse(termDateLayout, req.EndDate)
+	if startErr != nil || endErr != nil {
+		appErr := pkg.NewAppError("start_date and end_date must be dates formatted as YYYY-MM-DD", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return nil, false
+	}
+
+	return &model.AcademicTerm{
+		Code:                 req.Code,
+		Name:                 req.Name,
+		StartDate:            startDate,
+		EndDate:              endDate,
+		RegistrationOpensAt:  req.RegistrationOpensAt,
+		RegistrationClosesAt: req.RegistrationClosesAt,
+	}, true
+}
+
+func writeAcademicTermError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrAcademicTermNotFound), errors.Is(err, service.ErrNoActiveAcademicTerm):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+	case errors.Is(err, service.ErrAcademicTermCodeTaken):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusConflict))
+	case errors.Is(err, service.ErrAcademicTermInvalid):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+	default:
+		log.Error().Err(err).Msg(message)
+		response.NewErrorResponse(w, pkg.NewAppError(message, http.StatusInternalServerError))
+	}
+}
+
+// parseTermQuery reads the term_id list parameter, term_id=all turns off the default of the
+// current term. A missing term_id returns 0 and false.
+func parseTermQuery(query url.Values) (termID int, allTerms bool, err error) {
+	value := query.Get("term_id")
+	switch value {
+	case "":
+		return 0, false, nil
+	case "all":
+		return 0, true, nil
+	}
+
+	termID, err = strconv.Atoi(value)
+	if err != nil || termID < 1 {
+		return 0, false, errors.New("invalid term id")
+	}
+	return termID, false, nil
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/egasa21/si-lab-api-go/internal/middlewares"
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/pkg"
+	"github.com/egasa21/si-lab-api-go/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// authorizeUserRecord checks that the caller may reach a record owned by ownerID in practicumID.
+// Owners and admins always pass, lecturers and laboratory assistants pass for the practicums they are assigned to.
+// Writing a record also needs the owner to be registered for the practicum, the services check that.
+func authorizeUserRecord(r *http.Request, staffService service.PracticumStaffService, ownerID, practicumID int) *pkg.AppError {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		return pkg.ErrUnauthorized
+	}
+
+	if principal.UserID == ownerID || principal.HasRole(model.RoleAdmin) {
+		return nil
+	}
+
+	if principal.HasRole(model.RoleLecturer, model.RoleLaboratoryAssistant) {
+		assigned, err := staffService.IsAssigned(principal.UserID, practicumID)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to check practicum assignment")
+			return pkg.ErrInternalServer
+		}
+		if assigned {
+			return nil
+		}
+	}
+
+	return pkg.ErrForbidden
+}
+
+// isStaff reports whether the caller signed in as staff, on routes that may be called anonymously
+func isStaff(r *http.Request) bool {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	return ok && principal.HasRole(model.RoleAdmin, model.RoleLecturer, model.RoleLaboratoryAssistant)
+}
+
+// authorizeStudentRecord checks that the caller may act for the student studentID.
+// Admins act for anyone, students only for the student record linked to their account.
+func authorizeStudentRecord(r *http.Request, studentService service.StudentService, studentID int) *pkg.AppError {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		return pkg.ErrUnauthorized
+	}
+
+	if principal.HasRole(model.RoleAdmin) {
+		return nil
+	}
+
+	student, err := studentService.GetStudentByUserID(principal.UserID)
+	if err != nil || student == nil || student.ID != studentID {
+		return pkg.ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/egasa21/si-lab-api-go/internal/middlewares"
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/pkg"
+	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
+	"github.com/egasa21/si-lab-api-go/internal/service"
+	"github.com/egasa21/si-lab-api-go/internal/storage"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// room for the multipart boundaries and headers around the file
+const multipartOverhead = 1 << 20
+
+type AttachmentHandler struct {
+	service service.AttachmentService
+	// localStore is set when blobs are on the local disk and the API serves the signed links itself
+	localStore *storage.LocalStore
+}
+
+func NewAttachmentHandler(service service.AttachmentService, store storage.BlobStore) *AttachmentHandler {
+	localStore, _ := store.(*storage.LocalStore)
+	return &AttachmentHandler{service: service, localStore: localStore}
+}
+
+// ServesBlobs reports whether the blob route has to be registered
+func (h *AttachmentHandler) ServesBlobs() bool {
+	return h.localStore != nil
+}
+
+// UploadAttachment takes the "file" part of a multipart form, ?purpose= is material or study_plan.
+// Material is uploaded by staff, study plans by students.
+func (h *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.ErrUnauthorized)
+		return
+	}
+
+	purpose := model.AttachmentPurpose(r.URL.Query().Get("purpose"))
+	switch purpose {
+	case model.AttachmentMaterial:
+		if !principal.HasRole(model.RoleAdmin, model.RoleLecturer, model.RoleLaboratoryAssistant) {
+			response.NewErrorResponse(w, pkg.ErrForbidden)
+			return
+		}
+	case model.AttachmentStudyPlan:
+		if !principal.HasRole(model.RoleStudent) {
+			response.NewErrorResponse(w, pkg.ErrForbidden)
+			return
+		}
+	default:
+		writeAttachmentError(w, service.ErrAttachmentPurposeInvalid, "")
+		return
+	}
+
+	// The service enforces the limit of the purpose, this only stops oversized bodies early
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxAttachmentSize()+multipartOverhead)
+
+	// Streamed part by part, the file is never held in memory as a whole
+	reader, err := r.MultipartReader()
+	if err != nil {
+		response.NewErrorResponse(w, pkg.NewAppError("Request has to be multipart/form-data with a file field", http.StatusBadRequest))
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeAttachmentError(w, err, "")
+			return
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.service.Upload(r.Context(), purpose, part.FileName(), part, principal.UserID)
+		part.Close()
+		if err != nil {
+			writeAttachmentError(w, err, "Failed to upload attachment")
+			return
+		}
+
+		response.NewSuccessResponse(w, attachment, "Attachment uploaded successfully")
+		return
+	}
+
+	response.NewErrorResponse(w, pkg.NewAppError("Request has no file field", http.StatusBadRequest))
+}
+
+func (h *AttachmentHandler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := h.readableAttachment(w, r)
+	if !ok {
+		return
+	}
+
+	response.NewSuccessResponse(w, attachment, "Attachment retrieved successfully")
+}
+
+// GetAttachmentDownloadURL returns a signed link valid for service.DownloadURLExpiry
+func (h *AttachmentHandler) GetAttachmentDownloadURL(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := h.readableAttachment(w, r)
+	if !ok {
+		return
+	}
+
+	download, err := h.service.DownloadURL(r.Context(), attachment)
+	if err != nil {
+		writeAttachmentError(w, err, "Failed to sign download link")
+		return
+	}
+
+	response.NewSuccessResponse(w, download, "Download link created successfully")
+}
+
+// DownloadAttachment redirects to a signed link, so it works as a plain href in rendered content
+func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := h.readableAttachment(w, r)
+	if !ok {
+		return
+	}
+
+	download, err := h.service.DownloadURL(r.Context(), attachment)
+	if err != nil {
+		writeAttachmentError(w, err, "Failed to sign download link")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, download.URL, http.StatusFound)
+}
+
+// DeleteAttachment is allowed to the uploader and to admins
+func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.ErrUnauthorized)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.NewErrorResponse(w, pkg.NewAppError("Invalid attachment ID", http.StatusBadRequest))
+		return
+	}
+
+	attachment, err := h.service.GetAttachment(id)
+	if err != nil {
+		writeAttachmentError(w, err, "Failed to fetch attachment")
+		return
+	}
+	owner := attachment.UploadedBy != nil && *attachment.UploadedBy == principal.UserID
+	if !owner && !principal.HasRole(model.RoleAdmin) {
+		response.NewErrorResponse(w, pkg.ErrForbidden)
+		return
+	}
+
+	if err := h.service.DeleteAttachment(r.Context(), id); err != nil {
+		writeAttachmentError(w, err, "Failed to delete attachment")
+		return
+	}
+
+	response.NewSuccessResponse(w, nil, "Attachment deleted successfully")
+}
+
+// ServeBlob serves a local blob behind a link signed by storage.LocalStore, the signature is
+// the only authorization
+func (h *AttachmentHandler) ServeBlob(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	query := r.URL.Query()
+	filename := query.Get("filename")
+
+	if err := h.localStore.Verify(key, query.Get("expires"), filename, query.Get("signature")); err != nil {
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusForbidden))
+		return
+	}
+
+	// Keys are "<purpose>/<id>", the row has the content type
+	_, rawID, _ := strings.Cut(key, "/")
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		response.NewErrorResponse(w, pkg.NewAppError(service.ErrAttachmentNotFound.Error(), http.StatusNotFound))
+		return
+	}
+	attachment, err := h.service.GetAttachment(id)
+	if err != nil || attachment.StorageKey != key {
+		writeAttachmentError(w, service.ErrAttachmentNotFound, "")
+		return
+	}
+
+	blob, err := h.localStore.Get(r.Context(), key)
+	if err != nil {
+		writeAttachmentError(w, err, "Failed to read attachment")
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	if filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+	if _, err := io.Copy(w, blob); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to stream attachment")
+	}
+}
+
+// readableAttachment loads the attachment of the path and checks the caller may read it.
+// Material is public like the contents referencing it, study plans are for the uploader and staff.
+func (h *AttachmentHandler) readableAttachment(w http.ResponseWriter, r *http.Request) (*model.Attachment, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.NewErrorResponse(w, pkg.NewAppError("Invalid attachment ID", http.StatusBadRequest))
+		return nil, false
+	}
+
+	attachment, err := h.service.GetAttachment(id)
+	if err != nil {
+		writeAttachmentError(w, err, "Failed to fetch attachment")
+		return nil, false
+	}
+
+	if attachment.Purpose != model.AttachmentMaterial {
+		principal, ok := middlewares.PrincipalFromContext(r.Context())
+		if !ok {
+			response.NewErrorResponse(w, pkg.ErrUnauthorized)
+			return nil, false
+		}
+		owner := attachment.UploadedBy != nil && *attachment.UploadedBy == principal.UserID
+		if !owner && !isStaff(r) {
+			response.NewErrorResponse(w, pkg.ErrForbidden)
+			return nil, false
+		}
+	}
+
+	return attachment, true
+}
+
+func writeAttachmentError(w http.ResponseWriter, err error, message string) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, service.ErrAttachmentNotFound), errors.Is(err, storage.ErrNotFound):
+		response.NewErrorResponse(w, pkg.NewAppError(service.ErrAttachmentNotFound.Error(), http.StatusNotFound))
+	case errors.Is(err, service.ErrAttachmentTooLarge), errors.As(err, &maxBytesErr):
+		response.NewErrorResponse(w, pkg.NewAppError(service.ErrAttachmentTooLarge.Error(), http.StatusRequestEntityTooLarge))
+	case errors.Is(err, service.ErrAttachmentTypeNotAllowed):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusUnsupportedMediaType))
+	case errors.Is(err, service.ErrAttachmentPurposeInvalid), errors.Is(err, service.ErrAttachmentEmpty):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+	case errors.Is(err, service.ErrAttachmentInUse):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusConflict))
+	case errors.Is(err, storage.ErrChecksumMismatch):
+		response.NewErrorResponse(w, pkg.NewAppError("The stored file doesn't match its checksum, upload it again", http.StatusConflict))
+	case message == "":
+		response.NewErrorResponse(w, pkg.NewAppError("Invalid multipart request", http.StatusBadRequest))
+	default:
+		log.Error().Err(err).Msg(message)
+		response.NewErrorResponse(w, pkg.NewAppError(message, http.StatusInternalServerError))
+	}
+}
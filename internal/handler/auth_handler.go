@@ -2,8 +2,11 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/egasa21/si-lab-api-go/internal/dto"
 	"github.com/egasa21/si-lab-api-go/internal/middlewares"
@@ -33,9 +36,6 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Log or check if the password exists
-	fmt.Printf("Password received in request: %s\n", req.Password)
-
 	// Populate the User model using the request data
 	user := model.User{
 		Email:     req.Email,
@@ -43,8 +43,12 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		IDStudent: req.IDStudent,
 	}
 
-	// Call the service with the User model and default roles
-	if err := h.authService.Register(&user, nil); err != nil {
+	// Public registration always creates a student account
+	if err := h.authService.Register(&user); err != nil {
+		if errors.Is(err, service.ErrStudentLinked) {
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusConflict))
+			return
+		}
 		appErr := pkg.NewAppError(err.Error(), http.StatusBadRequest)
 		response.NewErrorResponse(w, appErr)
 		return
@@ -66,10 +70,24 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.authService.Login(request.Email, request.Password)
+	token, err := h.authService.Login(request.Email, request.Password, clientIP(r))
 	if err != nil {
-		appErr := pkg.NewAppError(err.Error(), http.StatusUnauthorized)
-		response.NewErrorResponse(w, appErr)
+		var lockedErr *service.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusTooManyRequests))
+			return
+		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusForbidden))
+			return
+		}
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusUnauthorized))
+			return
+		}
+		log.Error().Err(err).Msg("Failed to log in")
+		response.NewErrorResponse(w, pkg.ErrInternalServer)
 		return
 	}
 
@@ -84,20 +102,13 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *AuthHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
-	userIDValue := r.Context().Value(middlewares.UserIDKey)
-	if userIDValue == nil {
-		log.Error().Msg("User ID not found in context")
-		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
-		return
-	}
-
-	userIDFloat, ok := userIDValue.(float64)
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
 	if !ok {
-		log.Error().Msgf("Invalid user ID type in context: %T", userIDValue)
+		log.Error().Msg("Principal not found in context")
 		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
 		return
 	}
-	userID := int(userIDFloat)
+	userID := principal.UserID
 
 	userData, err := h.authService.GetUserByID(userID)
 	if err != nil {
@@ -134,3 +145,244 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		"expiresIn":     newTokens.ExpiresIn,
 	}, "Token refreshed successfully")
 }
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
+		return
+	}
+
+	// the refresh token is optional, without it only the access token is revoked
+	var req dto.LogoutRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+			response.NewErrorResponse(w, appErr)
+			return
+		}
+	}
+
+	if err := h.authService.Logout(principal.UserID, principal.TokenID, principal.ExpiresAt, req.RefreshToken); err != nil {
+		if errors.Is(err, service.ErrRefreshTokenInvalid) {
+			response.NewErrorResponse(w, pkg.NewAppError("Invalid refresh token", http.StatusBadRequest))
+			return
+		}
+		log.Error().Err(err).Msg("Failed to logout")
+		response.NewErrorResponse(w, pkg.NewAppError("Failed to logout", http.StatusInternalServerError))
+		return
+	}
+
+	response.NewSuccessResponse(w, nil, "Logged out successfully")
+}
+
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
+		return
+	}
+
+	if err := h.authService.LogoutAll(principal.UserID, principal.TokenID, principal.ExpiresAt); err != nil {
+		log.Error().Err(err).Msg("Failed to logout all sessions")
+		response.NewErrorResponse(w, pkg.NewAppError("Failed to logout", http.StatusInternalServerError))
+		return
+	}
+
+	response.NewSuccessResponse(w, nil, "Logged out of all devices successfully")
+}
+
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req dto.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	if err := h.authService.ForgotPassword(req.Email); err != nil {
+		log.Error().Err(err).Msg("Failed to send password reset link")
+		response.NewErrorResponse(w, pkg.NewAppError("Failed to send password reset link", http.StatusInternalServerError))
+		return
+	}
+
+	// Same response whether or not the email exists
+	response.NewSuccessResponse(w, nil, "If the email is registered, a password reset link has been sent")
+}
+
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req dto.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	if err := h.authService.ResetPassword(req.Token, req.Password); err != nil {
+		if errors.Is(err, service.ErrResetTokenInvalid) {
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+			return
+		}
+		log.Error().Err(err).Msg("Failed to reset password")
+		response.NewErrorResponse(w, pkg.NewAppError("Failed to reset password", http.StatusBadRequest))
+		return
+	}
+
+	response.NewSuccessResponse(w, nil, "Password reset successfully")
+}
+
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
+		return
+	}
+
+	var req dto.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	tokens, err := h.authService.ChangePassword(principal.UserID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPassword) {
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusForbidden))
+			return
+		}
+		log.Error().Err(err).Msg("Failed to change password")
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+		return
+	}
+
+	// Other sessions are signed out, the caller continues with the new tokens
+	response.NewSuccessResponse(w, map[string]interface{}{
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expiresIn":     tokens.ExpiresIn,
+	}, "Password changed successfully")
+}
+
+func (h *AuthHandler) UpdateCurrentUser(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
+		return
+	}
+
+	var req dto.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	user, err := h.authService.UpdateEmail(principal.UserID, req.CurrentPassword, req.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPassword):
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusForbidden))
+		case errors.Is(err, service.ErrEmailTaken):
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusConflict))
+		case errors.Is(err, service.ErrInvalidEmail):
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+		default:
+			log.Error().Err(err).Msg("Failed to update user")
+			response.NewErrorResponse(w, pkg.NewAppError("Failed to update user", http.StatusInternalServerError))
+		}
+		return
+	}
+
+	response.NewSuccessResponse(w, user, "user data updated successfully")
+}
+
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		response.NewErrorResponse(w, pkg.NewAppError("Missing verification token", http.StatusBadRequest))
+		return
+	}
+
+	if err := h.authService.VerifyEmail(token); err != nil {
+		if errors.Is(err, service.ErrVerifyTokenInvalid) {
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+			return
+		}
+		log.Error().Err(err).Msg("Failed to verify email")
+		response.NewErrorResponse(w, pkg.NewAppError("Failed to verify email", http.StatusInternalServerError))
+		return
+	}
+
+	response.NewSuccessResponse(w, nil, "Email verified successfully")
+}
+
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req dto.ResendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	if err := h.authService.ResendVerification(req.Email); err != nil {
+		log.Error().Err(err).Msg("Failed to send verification link")
+		response.NewErrorResponse(w, pkg.NewAppError("Failed to send verification link", http.StatusInternalServerError))
+		return
+	}
+
+	// Same response whether or not the email exists
+	response.NewSuccessResponse(w, nil, "If the email is registered and not yet verified, a verification link has been sent")
+}
+
+// CreateUser lets an admin create an account with any role, e.g. lecturers and laboratory assistants
+func (h *AuthHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	user := model.User{
+		Email:     req.Email,
+		Password:  req.Password,
+		IDStudent: req.IDStudent,
+	}
+
+	var staffProfile *model.StaffProfile
+	if req.StaffProfile != nil {
+		staffProfile = &model.StaffProfile{
+			EmployeeIDNumber: req.StaffProfile.EmployeeIDNumber,
+			Name:             req.StaffProfile.Name,
+		}
+	}
+
+	created, err := h.authService.CreateUser(&user, req.Roles, staffProfile)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmailTaken), errors.Is(err, service.ErrEmployeeIDTaken):
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusConflict))
+		case errors.Is(err, service.ErrRoleNotFound),
+			errors.Is(err, service.ErrRolesRequired),
+			errors.Is(err, service.ErrStaffProfileInvalid):
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+		default:
+			log.Error().Err(err).Msg("Failed to create user")
+			response.NewErrorResponse(w, pkg.NewAppError("Failed to create user", http.StatusBadRequest))
+		}
+		return
+	}
+
+	response.NewSuccessResponse(w, created, "User created successfully")
+}
+
+// clientIP returns the address of the caller, RealIP in the server package already
+// replaced it with the forwarded address when running behind a trusted proxy
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
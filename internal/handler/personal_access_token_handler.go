@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/egasa21/si-lab-api-go/internal/dto"
+	"github.com/egasa21/si-lab-api-go/internal/middlewares"
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/pkg"
+	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
+	"github.com/egasa21/si-lab-api-go/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+type PersonalAccessTokenHandler struct {
+	service service.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenHandler(service service.PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{service: service}
+}
+
+// CreateToken issues a personal access token for the current user, the token is only returned here
+func (h *PersonalAccessTokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
+		return
+	}
+
+	var req dto.CreatePersonalAccessTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	scopes := make([]model.Scope, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		scopes[i] = model.Scope(scope)
+	}
+
+	token, plainToken, err := h.service.CreateToken(principal.UserID, req.Name, scopes, req.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTokenNameRequired),
+			errors.Is(err, service.ErrTokenScopesRequired),
+			errors.Is(err, service.ErrTokenScopeInvalid),
+			errors.Is(err, service.ErrTokenExpiryInvalid):
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+		default:
+			log.Error().Err(err).Msg("Failed to create api token")
+			response.NewErrorResponse(w, pkg.NewAppError("Failed to create token", http.StatusInternalServerError))
+		}
+		return
+	}
+
+	response.NewSuccessResponse(w, map[string]interface{}{
+		"token":                 plainToken,
+		"personal_access_token": token,
+	}, "Token created successfully, copy it now as it won't be shown again")
+}
+
+// GetTokens lists the active tokens of the current user
+func (h *PersonalAccessTokenHandler) GetTokens(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
+		return
+	}
+
+	tokens, err := h.service.GetTokensByUserID(principal.UserID)
+	if err != nil {
+		appErr := pkg.NewAppError("Unable to fetch tokens", http.StatusInternalServerError)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	response.NewSuccessResponse(w, tokens, "Tokens retrieved successfully")
+}
+
+// RevokeToken revokes one of the current user's tokens
+func (h *PersonalAccessTokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
+		return
+	}
+
+	tokenID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid token ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	if err := h.service.RevokeToken(principal.UserID, tokenID); err != nil {
+		if errors.Is(err, service.ErrTokenNotFound) {
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+			return
+		}
+		response.NewErrorResponse(w, pkg.NewAppError("Failed to revoke token", http.StatusInternalServerError))
+		return
+	}
+
+	response.NewSuccessResponse(w, nil, "Token revoked successfully")
+}
@@ -2,10 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
+	"github.com/egasa21/si-lab-api-go/internal/dto"
 	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/egasa21/si-lab-api-go/internal/pkg"
 	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
@@ -30,12 +35,9 @@ func (h *PracticumHandler) CreatePracticum(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// check existing practicum
-
 	err = h.service.CreatePracticum(&practicum)
 	if err != nil {
-		appErr := pkg.NewAppError("Failed to create practicum", http.StatusInternalServerError)
-		response.NewErrorResponse(w, appErr)
+		writePracticumError(w, err, "Failed to create practicum")
 		return
 	}
 
@@ -74,12 +76,15 @@ func (h *PracticumHandler) GetAllPracticums(w http.ResponseWriter, r *http.Reque
 		limit = 10
 	}
 
-	practicums, total, err := h.service.GetAllPracticums(page, limit)
+	filter, err := parsePracticumFilter(r.URL.Query())
 	if err != nil {
+		writePracticumError(w, err, "Unable to fetch practicums")
+		return
+	}
 
-		appErr := pkg.NewAppError("Unable to fetch practicums", http.StatusInternalServerError)
-		log.Error().Err(err)
-		response.NewErrorResponse(w, appErr)
+	practicums, total, err := h.service.GetAllPracticums(filter, page, limit)
+	if err != nil {
+		writePracticumError(w, err, "Unable to fetch practicums")
 		return
 	}
 
@@ -92,7 +97,7 @@ func (h *PracticumHandler) GetAllPracticums(w http.ResponseWriter, r *http.Reque
 		TotalItems: total,
 	}
 
-	response.NewPaginatedSuccessResponse(w, practicums, pagination, "Students retrieved successfully")
+	response.NewPaginatedSuccessResponse(w, practicums, pagination, "Practicums retrieved successfully")
 }
 
 func (h *PracticumHandler) GetPracticumWithMaterialContents(w http.ResponseWriter, r *http.Request) {
@@ -103,7 +108,8 @@ func (h *PracticumHandler) GetPracticumWithMaterialContents(w http.ResponseWrite
 		return
 	}
 
-	practicumWithMaterials, err := h.service.GetPracticumWithMaterialContents(practicumID)
+	// Staff see drafts too, students and anonymous callers only what has been published
+	practicumWithMaterials, err := h.service.GetPracticumWithMaterialContents(practicumID, !isStaff(r))
 	if err != nil {
 		appErr := pkg.NewAppError("practicum not found", http.StatusNotFound)
 		response.NewErrorResponse(w, appErr)
@@ -112,3 +118,178 @@ func (h *PracticumHandler) GetPracticumWithMaterialContents(w http.ResponseWrite
 
 	response.NewSuccessResponse(w, practicumWithMaterials, "Practicum with materials retrieved successfully")
 }
+
+// UpdatePracticum replaces every editable field of a practicum
+func (h *PracticumHandler) UpdatePracticum(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	var req dto.UpdatePracticumRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	practicum := model.Practicum{
+		ID:             id,
+		Code:           req.Code,
+		Name:           req.Name,
+		Description:    req.Description,
+		Credits:        req.Credits,
+		Semester:       req.Semester,
+		AcademicTermID: req.AcademicTermID,
+	}
+
+	if err := h.service.UpdatePracticum(&practicum); err != nil {
+		writePracticumError(w, err, "Failed to update practicum")
+		return
+	}
+
+	response.NewSuccessResponse(w, practicum, "Practicum updated successfully")
+}
+
+// PatchPracticum changes only the fields present in the body
+func (h *PracticumHandler) PatchPracticum(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	var req dto.PatchPracticumRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	practicum, err := h.service.PatchPracticum(id, req)
+	if err != nil {
+		writePracticumError(w, err, "Failed to update practicum")
+		return
+	}
+
+	response.NewSuccessResponse(w, practicum, "Practicum updated successfully")
+}
+
+// DeletePracticum soft deletes a practicum, it can be brought back with RestorePracticum
+func (h *PracticumHandler) DeletePracticum(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	if err := h.service.DeletePracticum(id); err != nil {
+		writePracticumError(w, err, "Failed to delete practicum")
+		return
+	}
+
+	response.NewSuccessResponse(w, nil, "Practicum deleted successfully")
+}
+
+func (h *PracticumHandler) RestorePracticum(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	practicum, err := h.service.RestorePracticum(id)
+	if err != nil {
+		writePracticumError(w, err, "Failed to restore practicum")
+		return
+	}
+
+	response.NewSuccessResponse(w, practicum, "Practicum restored successfully")
+}
+
+// ClonePracticum copies a practicum into a new term and returns the old to new ID mapping
+func (h *PracticumHandler) ClonePracticum(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	var req dto.ClonePracticumRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	result, err := h.service.ClonePracticum(id, req)
+	if err != nil {
+		writePracticumError(w, err, "Failed to clone practicum")
+		return
+	}
+
+	response.NewSuccessResponse(w, result, "Practicum cloned successfully")
+}
+
+func writePracticumError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrPracticumNotFound):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+	case errors.Is(err, service.ErrPracticumNameTaken):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusConflict))
+	case errors.Is(err, service.ErrPracticumInvalid), errors.Is(err, service.ErrPracticumSortInvalid), errors.Is(err, service.ErrPracticumFilterInvalid),
+		errors.Is(err, service.ErrAcademicTermNotFound):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+	default:
+		log.Error().Err(err).Msg(message)
+		response.NewErrorResponse(w, pkg.NewAppError(message, http.StatusInternalServerError))
+	}
+}
+
+// parsePracticumFilter reads term_id, q, semester, credits, min_credits, max_credits, sort and order
+func parsePracticumFilter(query url.Values) (model.PracticumFilter, error) {
+	termID, allTerms, err := parseTermQuery(query)
+	if err != nil {
+		return model.PracticumFilter{}, service.ErrPracticumFilterInvalid
+	}
+
+	filter := model.PracticumFilter{
+		AcademicTermID: termID,
+		AllTerms:       allTerms,
+		Search:         query.Get("q"),
+		Semester:       query.Get("semester"),
+		SortBy:         model.PracticumSortField(query.Get("sort")),
+	}
+
+	for param, target := range map[string]*int{
+		"credits":     &filter.Credits,
+		"min_credits": &filter.MinCredits,
+		"max_credits": &filter.MaxCredits,
+	} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return filter, service.ErrPracticumFilterInvalid
+		}
+		*target = n
+	}
+
+	switch strings.ToLower(query.Get("order")) {
+	case "", "asc":
+	case "desc":
+		filter.SortDesc = true
+	default:
+		return filter, service.ErrPracticumSortInvalid
+	}
+
+	return filter, nil
+}
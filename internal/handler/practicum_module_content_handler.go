@@ -2,14 +2,18 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/egasa21/si-lab-api-go/internal/dto"
+	"github.com/egasa21/si-lab-api-go/internal/middlewares"
 	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/egasa21/si-lab-api-go/internal/pkg"
 	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
 	"github.com/egasa21/si-lab-api-go/internal/service"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
@@ -22,6 +26,12 @@ func NewPracticumModuleContentHandler(service service.PracticumModuleContentServ
 }
 
 func (h *PracticumModuleContentHandler) CreateContent(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
+		return
+	}
+
 	var req dto.CreatePracticumModuleContentRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -34,20 +44,18 @@ func (h *PracticumModuleContentHandler) CreateContent(w http.ResponseWriter, r *
 		IDModule: req.IDModule,
 		Title:    req.Title,
 		Content:  req.Content,
-		Sequence: req.Sequence,
 	}
 
-	newModuleContent, err := h.service.CreateContent(&content)
+	newModuleContent, err := h.service.CreateContent(&content, principal.UserID)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to create practicum module content")
-		appErr := pkg.NewAppError("Failed to create content", http.StatusInternalServerError)
-		response.NewErrorResponse(w, appErr)
+		writeContentError(w, err, "Failed to create content")
 		return
 	}
 
 	moduleContentResponse := &dto.PracticumModuleContentResponse{
-		ID:    uint(newModuleContent.IDContent),
-		Title: newModuleContent.Title,
+		ID:       uint(newModuleContent.IDContent),
+		Title:    newModuleContent.Title,
+		Sequence: newModuleContent.Sequence,
 	}
 
 	response.NewSuccessResponse(w, moduleContentResponse, "Content created successfully")
@@ -61,7 +69,28 @@ func (h *PracticumModuleContentHandler) GetContentByID(w http.ResponseWriter, r
 		return
 	}
 
-	content, err := h.service.GetContentByID(id)
+	// Staff get the latest revision, everyone else the published version without quiz answers
+	publishedOnly := !isStaff(r)
+
+	// format=html or markdown returns the content rendered on the server, json the stored blocks
+	format := model.ContentFormat(r.URL.Query().Get("format"))
+	if format != "" && format != model.ContentFormatJSON {
+		rendered, err := h.service.RenderContent(id, format, publishedOnly)
+		if err != nil {
+			writeContentError(w, err, "Failed to render content")
+			return
+		}
+
+		response.NewSuccessResponse(w, rendered, "Content rendered successfully")
+		return
+	}
+
+	var content *model.PracticumModuleContent
+	if publishedOnly {
+		content, err = h.service.GetPublishedContentByID(id)
+	} else {
+		content, err = h.service.GetContentByID(id)
+	}
 	if err != nil {
 		appErr := pkg.NewAppError("Content not found", http.StatusNotFound)
 		response.NewErrorResponse(w, appErr)
@@ -92,7 +121,19 @@ func (h *PracticumModuleContentHandler) GetContentsByModuleID(w http.ResponseWri
 		limit = 10
 	}
 
-	contents, total, err := h.service.GetContentsByModuleID(moduleID, page, limit)
+	// Staff may filter the latest revisions by status, everyone else only gets published content
+	var contents []model.PracticumModuleContent
+	var total int
+	if isStaff(r) {
+		status := model.ContentStatus(r.URL.Query().Get("status"))
+		contents, total, err = h.service.GetContentsByModuleID(moduleID, status, page, limit)
+	} else {
+		contents, total, err = h.service.GetPublishedContentsByModuleID(moduleID, page, limit)
+	}
+	if errors.Is(err, service.ErrContentStatusFilter) {
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+		return
+	}
 	if err != nil {
 		appErr := pkg.NewAppError("Unable to fetch contents", http.StatusInternalServerError)
 		log.Error().Err(err)
@@ -112,6 +153,12 @@ func (h *PracticumModuleContentHandler) GetContentsByModuleID(w http.ResponseWri
 }
 
 func (h *PracticumModuleContentHandler) UpdateContentByID(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
+		return
+	}
+
 	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
 		appErr := pkg.NewAppError("Invalid ID", http.StatusBadRequest)
@@ -143,11 +190,9 @@ func (h *PracticumModuleContentHandler) UpdateContentByID(w http.ResponseWriter,
 		MaterialID: content.MaterialID,
 	}
 
-	err = h.service.UpdateContentByID(id, &updatedContent)
+	err = h.service.UpdateContentByID(id, &updatedContent, principal.UserID)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to update practicum module content")
-		appErr := pkg.NewAppError("Failed to update content", http.StatusInternalServerError)
-		response.NewErrorResponse(w, appErr)
+		writeContentError(w, err, "Failed to update content")
 		return
 	}
 
@@ -172,3 +217,278 @@ func (h *PracticumModuleContentHandler) DeleteContentByID(w http.ResponseWriter,
 
 	response.NewSuccessResponse(w, nil, "Content deleted successfully")
 }
+
+// ReorderContents sets the order of the contents of a module
+func (h *PracticumModuleContentHandler) ReorderContents(w http.ResponseWriter, r *http.Request) {
+	moduleID, err := strconv.Atoi(r.PathValue("module_id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid module ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	var req dto.ReorderPracticumModuleContentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	if err := h.service.ReorderContents(moduleID, req.ContentIDs); err != nil {
+		writeContentError(w, err, "Failed to reorder contents")
+		return
+	}
+
+	response.NewSuccessResponse(w, nil, "Contents reordered successfully")
+}
+
+// MoveContent moves content to another module of the same practicum
+func (h *PracticumModuleContentHandler) MoveContent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	var req dto.MovePracticumModuleContentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	content, err := h.service.MoveContent(id, req.ModuleID, req.Position)
+	if err != nil {
+		writeContentError(w, err, "Failed to move content")
+		return
+	}
+
+	response.NewSuccessResponse(w, content, "Content moved successfully")
+}
+
+// SubmitContent sends a draft to review
+func (h *PracticumModuleContentHandler) SubmitContent(w http.ResponseWriter, r *http.Request) {
+	principal, id, appErr := contentWorkflowRequest(r)
+	if appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	content, err := h.service.SubmitContent(id, principal.UserID)
+	if err != nil {
+		writeContentError(w, err, "Failed to submit content")
+		return
+	}
+
+	response.NewSuccessResponse(w, content, "Content submitted for review")
+}
+
+// PublishContent approves content for students, optionally at a later publish_at.
+// A version students already see stays visible until then.
+func (h *PracticumModuleContentHandler) PublishContent(w http.ResponseWriter, r *http.Request) {
+	principal, id, appErr := contentWorkflowRequest(r)
+	if appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	// The body is optional, without it the content is published right away
+	var req dto.PublishPracticumModuleContentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	content, err := h.service.PublishContent(id, principal.UserID, req.PublishAt)
+	if err != nil {
+		writeContentError(w, err, "Failed to publish content")
+		return
+	}
+
+	response.NewSuccessResponse(w, content, "Content published successfully")
+}
+
+// RejectContent returns submitted content to its author with a note
+func (h *PracticumModuleContentHandler) RejectContent(w http.ResponseWriter, r *http.Request) {
+	principal, id, appErr := contentWorkflowRequest(r)
+	if appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	var req dto.RejectPracticumModuleContentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	content, err := h.service.RejectContent(id, principal.UserID, req.Note)
+	if err != nil {
+		writeContentError(w, err, "Failed to reject content")
+		return
+	}
+
+	response.NewSuccessResponse(w, content, "Content returned to draft")
+}
+
+// ArchiveContent hides content from students
+func (h *PracticumModuleContentHandler) ArchiveContent(w http.ResponseWriter, r *http.Request) {
+	principal, id, appErr := contentWorkflowRequest(r)
+	if appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	content, err := h.service.ArchiveContent(id, principal.UserID)
+	if err != nil {
+		writeContentError(w, err, "Failed to archive content")
+		return
+	}
+
+	response.NewSuccessResponse(w, content, "Content archived successfully")
+}
+
+func contentWorkflowRequest(r *http.Request) (*middlewares.Principal, int, *pkg.AppError) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		return nil, 0, pkg.NewAppError("Unauthorized", http.StatusUnauthorized)
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, 0, pkg.NewAppError("Invalid ID", http.StatusBadRequest)
+	}
+	return principal, id, nil
+}
+
+// GetContentVersions lists the revisions of a material, newest first
+func (h *PracticumModuleContentHandler) GetContentVersions(w http.ResponseWriter, r *http.Request) {
+	materialID, err := uuid.Parse(r.PathValue("material_id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid material ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	versions, err := h.service.GetContentVersions(materialID)
+	if err != nil {
+		writeContentError(w, err, "Unable to fetch content versions")
+		return
+	}
+
+	response.NewSuccessResponse(w, versions, "Content versions retrieved successfully")
+}
+
+// GetContentVersion returns one revision of a material with its content
+func (h *PracticumModuleContentHandler) GetContentVersion(w http.ResponseWriter, r *http.Request) {
+	materialID, version, appErr := parseContentVersionPath(r)
+	if appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	found, err := h.service.GetContentVersion(materialID, version)
+	if err != nil {
+		writeContentError(w, err, "Unable to fetch content version")
+		return
+	}
+
+	response.NewSuccessResponse(w, found, "Content version retrieved successfully")
+}
+
+// DiffContentVersions compares the versions given by the from and to query parameters
+func (h *PracticumModuleContentHandler) DiffContentVersions(w http.ResponseWriter, r *http.Request) {
+	materialID, err := uuid.Parse(r.PathValue("material_id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid material ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil || from < 1 {
+		appErr := pkg.NewAppError("from must be a version number", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil || to < 1 {
+		appErr := pkg.NewAppError("to must be a version number", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	diff, err := h.service.DiffContentVersions(materialID, from, to)
+	if err != nil {
+		writeContentError(w, err, "Failed to compare content versions")
+		return
+	}
+
+	response.NewSuccessResponse(w, diff, "Content versions compared successfully")
+}
+
+// RestoreContentVersion makes an older revision the current content of the material
+func (h *PracticumModuleContentHandler) RestoreContentVersion(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
+		return
+	}
+
+	materialID, version, appErr := parseContentVersionPath(r)
+	if appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	restored, err := h.service.RestoreContentVersion(materialID, version, principal.UserID)
+	if err != nil {
+		writeContentError(w, err, "Failed to restore content version")
+		return
+	}
+
+	response.NewSuccessResponse(w, restored, "Content version restored successfully")
+}
+
+// GetContentSchema publishes the JSON Schema that module content must follow
+func (h *PracticumModuleContentHandler) GetContentSchema(w http.ResponseWriter, r *http.Request) {
+	response.NewSuccessResponse(w, h.service.GetContentSchema(), "Content schema retrieved successfully")
+}
+
+func parseContentVersionPath(r *http.Request) (uuid.UUID, int, *pkg.AppError) {
+	materialID, err := uuid.Parse(r.PathValue("material_id"))
+	if err != nil {
+		return uuid.Nil, 0, pkg.NewAppError("Invalid material ID", http.StatusBadRequest)
+	}
+
+	version, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil || version < 1 {
+		return uuid.Nil, 0, pkg.NewAppError("Invalid version", http.StatusBadRequest)
+	}
+	return materialID, version, nil
+}
+
+func writeContentError(w http.ResponseWriter, err error, message string) {
+	var validationErr *service.ContentValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		appErr := pkg.NewAppError(validationErr.Error(), http.StatusUnprocessableEntity)
+		response.NewErrorResponseWithData(w, appErr, validationErr.Errors)
+	case errors.Is(err, service.ErrContentNotFound), errors.Is(err, service.ErrModuleNotFound),
+		errors.Is(err, service.ErrContentVersionNotFound):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+	case errors.Is(err, service.ErrContentOrderInvalid), errors.Is(err, service.ErrContentMoveInvalid),
+		errors.Is(err, service.ErrContentFormatInvalid):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+	case errors.Is(err, service.ErrContentStatusInvalid):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusConflict))
+	case errors.Is(err, service.ErrContentNotRenderable):
+		response.NewErrorResponse(w, pkg.NewAppError(service.ErrContentNotRenderable.Error(), http.StatusUnprocessableEntity))
+	default:
+		log.Error().Err(err).Msg(message)
+		response.NewErrorResponse(w, pkg.NewAppError(message, http.StatusInternalServerError))
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -36,6 +37,10 @@ func (h *PracticumModuleHandler) CreateModule(w http.ResponseWriter, r *http.Req
 	}
 
 	newModule, err := h.service.CreateModule(&module)
+	if errors.Is(err, service.ErrPracticumNotFound) {
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+		return
+	}
 	if err != nil {
 		appErr := pkg.NewAppError("Failed to create module", http.StatusInternalServerError)
 		response.NewErrorResponse(w, appErr)
@@ -43,8 +48,9 @@ func (h *PracticumModuleHandler) CreateModule(w http.ResponseWriter, r *http.Req
 	}
 
 	moduleResponse := &dto.PracticumModuleResponse{
-		ID:    uint(newModule.ID),
-		Title: newModule.Title,
+		ID:       uint(newModule.ID),
+		Title:    newModule.Title,
+		Sequence: newModule.Sequence,
 	}
 
 	response.NewSuccessResponse(w, moduleResponse, "Module created successfully")
@@ -107,3 +113,82 @@ func (h *PracticumModuleHandler) GetModulesByPracticumID(w http.ResponseWriter,
 
 	response.NewPaginatedSuccessResponse(w, modules, pagination, "Modules retrieved successfully")
 }
+
+func (h *PracticumModuleHandler) UpdateModule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	var req dto.UpdatePracticumModuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	module := model.PracticumModule{ID: id, Title: req.Title}
+	if err := h.service.UpdateModule(&module); err != nil {
+		writeModuleError(w, err, "Failed to update module")
+		return
+	}
+
+	response.NewSuccessResponse(w, module, "Module updated successfully")
+}
+
+// DeleteModule deletes a module with its contents, modules students have checkpoints in are refused
+func (h *PracticumModuleHandler) DeleteModule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	if err := h.service.DeleteModule(id); err != nil {
+		writeModuleError(w, err, "Failed to delete module")
+		return
+	}
+
+	response.NewSuccessResponse(w, nil, "Module deleted successfully")
+}
+
+// ReorderModules sets the order of the modules of a practicum
+func (h *PracticumModuleHandler) ReorderModules(w http.ResponseWriter, r *http.Request) {
+	practicumID, err := strconv.Atoi(r.PathValue("practicum_id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid practicum ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	var req dto.ReorderPracticumModulesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	if err := h.service.ReorderModules(practicumID, req.ModuleIDs); err != nil {
+		writeModuleError(w, err, "Failed to reorder modules")
+		return
+	}
+
+	response.NewSuccessResponse(w, nil, "Modules reordered successfully")
+}
+
+func writeModuleError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrModuleNotFound), errors.Is(err, service.ErrPracticumNotFound):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+	case errors.Is(err, service.ErrModuleTitleInvalid), errors.Is(err, service.ErrModuleOrderInvalid):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+	case errors.Is(err, service.ErrModuleInUse):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusConflict))
+	default:
+		log.Error().Err(err).Msg(message)
+		response.NewErrorResponse(w, pkg.NewAppError(message, http.StatusInternalServerError))
+	}
+}
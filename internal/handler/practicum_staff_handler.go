@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/egasa21/si-lab-api-go/internal/dto"
+	"github.com/egasa21/si-lab-api-go/internal/pkg"
+	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
+	"github.com/egasa21/si-lab-api-go/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+type PracticumStaffHandler struct {
+	service service.PracticumStaffService
+}
+
+func NewPracticumStaffHandler(service service.PracticumStaffService) *PracticumStaffHandler {
+	return &PracticumStaffHandler{service: service}
+}
+
+// AssignStaff assigns a lecturer or laboratory assistant to a practicum
+func (h *PracticumStaffHandler) AssignStaff(w http.ResponseWriter, r *http.Request) {
+	practicumID, err := strconv.Atoi(r.PathValue("practicum_id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid practicum ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	var req dto.AssignPracticumStaffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == 0 {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	staff, err := h.service.AssignStaff(practicumID, req.UserID)
+	if errors.Is(err, service.ErrStaffRoleRequired) {
+		appErr := pkg.NewAppError(err.Error(), http.StatusUnprocessableEntity)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to assign practicum staff")
+		appErr := pkg.NewAppError("Failed to assign staff", http.StatusInternalServerError)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	response.NewSuccessResponse(w, staff, "Staff assigned successfully")
+}
+
+// GetStaffByPracticumID lists the staff assigned to a practicum
+func (h *PracticumStaffHandler) GetStaffByPracticumID(w http.ResponseWriter, r *http.Request) {
+	practicumID, err := strconv.Atoi(r.PathValue("practicum_id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid practicum ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	staff, err := h.service.GetStaffByPracticumID(practicumID)
+	if err != nil {
+		appErr := pkg.NewAppError("Unable to fetch staff", http.StatusInternalServerError)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	response.NewSuccessResponse(w, staff, "Staff retrieved successfully")
+}
+
+// RemoveStaff unassigns a staff member from a practicum
+func (h *PracticumStaffHandler) RemoveStaff(w http.ResponseWriter, r *http.Request) {
+	practicumID, err := strconv.Atoi(r.PathValue("practicum_id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid practicum ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	userID, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid user ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	if err := h.service.RemoveStaff(practicumID, userID); err != nil {
+		appErr := pkg.NewAppError("Failed to remove staff", http.StatusInternalServerError)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	response.NewSuccessResponse(w, nil, "Staff removed successfully")
+}
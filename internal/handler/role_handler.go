@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/egasa21/si-lab-api-go/internal/dto"
+	"github.com/egasa21/si-lab-api-go/internal/pkg"
+	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
+	"github.com/egasa21/si-lab-api-go/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+type RoleHandler struct {
+	service service.RoleService
+}
+
+func NewRoleHandler(service service.RoleService) *RoleHandler {
+	return &RoleHandler{service: service}
+}
+
+// GetAllRoles lists every role that can be granted
+func (h *RoleHandler) GetAllRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.service.GetAllRoles()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch roles")
+		appErr := pkg.NewAppError("Unable to fetch roles", http.StatusInternalServerError)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	response.NewSuccessResponse(w, roles, "Roles retrieved successfully")
+}
+
+// GetUserRoles lists the roles of a user
+func (h *RoleHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid user ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	roles, err := h.service.GetUserRoles(userID)
+	if err != nil {
+		writeRoleError(w, err, "Unable to fetch user roles")
+		return
+	}
+
+	response.NewSuccessResponse(w, roles, "User roles retrieved successfully")
+}
+
+// GrantRole grants a role to a user
+func (h *RoleHandler) GrantRole(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid user ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	var req dto.GrantRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Role == "" {
+		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	roles, err := h.service.GrantRole(userID, req.Role)
+	if err != nil {
+		writeRoleError(w, err, "Failed to grant role")
+		return
+	}
+
+	response.NewSuccessResponse(w, roles, "Role granted successfully")
+}
+
+// RevokeRole revokes a role from a user
+func (h *RoleHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid user ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	roles, err := h.service.RevokeRole(userID, r.PathValue("role"))
+	if err != nil {
+		writeRoleError(w, err, "Failed to revoke role")
+		return
+	}
+
+	response.NewSuccessResponse(w, roles, "Role revoked successfully")
+}
+
+func writeRoleError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrRoleNotFound):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+	case errors.Is(err, service.ErrLastAdmin):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusConflict))
+	default:
+		log.Error().Err(err).Msg(message)
+		response.NewErrorResponse(w, pkg.NewAppError(message, http.StatusInternalServerError))
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/egasa21/si-lab-api-go/internal/pkg"
+	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
+	"github.com/egasa21/si-lab-api-go/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// ssoStateCookie binds a sign-in to the browser that started it, a callback carrying
+// the state of someone else's sign-in is refused
+const ssoStateCookie = "sso_state"
+
+type SSOHandler struct {
+	service service.SSOService
+}
+
+func NewSSOHandler(service service.SSOService) *SSOHandler {
+	return &SSOHandler{service: service}
+}
+
+// Login redirects the browser to the identity provider
+func (h *SSOHandler) Login(w http.ResponseWriter, r *http.Request) {
+	loginURL, state, err := h.service.LoginURL(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to start single sign-on")
+		response.NewErrorResponse(w, pkg.NewAppError("Single sign-on is unavailable", http.StatusBadGateway))
+		return
+	}
+
+	setSSOStateCookie(w, r, state, int(service.SSOAuthRequestTTL.Seconds()))
+	http.Redirect(w, r, loginURL, http.StatusFound)
+}
+
+// Callback is where the identity provider sends the browser back with the authorization code
+func (h *SSOHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		log.Warn().Str("error", providerErr).Str("description", query.Get("error_description")).Msg("Identity provider rejected sign-in")
+		response.NewErrorResponse(w, pkg.NewAppError("Sign-in was cancelled or rejected", http.StatusUnauthorized))
+		return
+	}
+
+	code, state := query.Get("code"), query.Get("state")
+	if code == "" || state == "" {
+		response.NewErrorResponse(w, pkg.NewAppError("Missing code or state", http.StatusBadRequest))
+		return
+	}
+
+	cookie, err := r.Cookie(ssoStateCookie)
+	setSSOStateCookie(w, r, "", -1)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		response.NewErrorResponse(w, pkg.NewAppError(service.ErrSSOStateInvalid.Error(), http.StatusBadRequest))
+		return
+	}
+
+	tokens, err := h.service.Callback(r.Context(), code, state)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrSSOStateInvalid), errors.Is(err, service.ErrSSOEmailMissing):
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+		case errors.Is(err, service.ErrSSOEmailUnclaimed):
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusConflict))
+		default:
+			log.Error().Err(err).Msg("Single sign-on failed")
+			response.NewErrorResponse(w, pkg.NewAppError("Single sign-on failed", http.StatusUnauthorized))
+		}
+		return
+	}
+
+	response.NewSuccessResponse(w, map[string]interface{}{
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expiresIn":     tokens.ExpiresIn,
+	}, "login successfully ")
+}
+
+// setSSOStateCookie sets the state cookie, a negative maxAge removes it. It has to be Lax, the
+// identity provider sends the browser back with a cross-site redirect.
+func setSSOStateCookie(w http.ResponseWriter, r *http.Request, state string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    state,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -12,15 +13,16 @@ import (
 )
 
 type StudentClassEnrollmentHandler struct {
-	service service.StudentClassEnrollmentService
+	service        service.StudentClassEnrollmentService
+	studentService service.StudentService
 }
 
 // NewStudentClassEnrollmentHandler initializes a new handler
-func NewStudentClassEnrollmentHandler(service service.StudentClassEnrollmentService) *StudentClassEnrollmentHandler {
-	return &StudentClassEnrollmentHandler{service: service}
+func NewStudentClassEnrollmentHandler(service service.StudentClassEnrollmentService, studentService service.StudentService) *StudentClassEnrollmentHandler {
+	return &StudentClassEnrollmentHandler{service: service, studentService: studentService}
 }
 
-// EnrollStudent handles enrolling a student into a class
+// EnrollStudent handles enrolling a student into a class, students may only enroll themselves
 func (h *StudentClassEnrollmentHandler) EnrollStudent(w http.ResponseWriter, r *http.Request) {
 	var req dto.StudentEnrollmentRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
@@ -30,8 +32,20 @@ func (h *StudentClassEnrollmentHandler) EnrollStudent(w http.ResponseWriter, r *
 		return
 	}
 
+	if appErr := authorizeStudentRecord(r, h.studentService, req.StudentID); appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
 	err = h.service.EnrollStudent(req.ClassID, req.StudentID)
-	if err != nil {
+	switch {
+	case errors.Is(err, service.ErrClassNotFound):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+		return
+	case errors.Is(err, service.ErrNotRegisteredForPracticum):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusForbidden))
+		return
+	case err != nil:
 		appErr := pkg.NewAppError("Failed to enroll student", http.StatusInternalServerError)
 		response.NewErrorResponse(w, appErr)
 		return
@@ -78,15 +92,30 @@ func (h *StudentClassEnrollmentHandler) GetEnrollmentsByClassID(w http.ResponseW
 	response.NewSuccessResponse(w, enrollments, "Class enrollments retrieved successfully")
 }
 
-// UnenrollStudent handles removing a student from a class
+// UnenrollStudent handles removing a student from a class, students may only unenroll themselves
 func (h *StudentClassEnrollmentHandler) UnenrollStudent(w http.ResponseWriter, r *http.Request) {
-	enrollmentID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	enrollmentID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
 		appErr := pkg.NewAppError("Invalid enrollment ID", http.StatusBadRequest)
 		response.NewErrorResponse(w, appErr)
 		return
 	}
 
+	enrollment, err := h.service.GetEnrollmentByID(enrollmentID)
+	if errors.Is(err, service.ErrEnrollmentNotFound) {
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+		return
+	}
+	if err != nil {
+		appErr := pkg.NewAppError("Failed to unenroll student", http.StatusInternalServerError)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+	if appErr := authorizeStudentRecord(r, h.studentService, enrollment.StudentID); appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
 	err = h.service.UnenrollStudent(enrollmentID)
 	if err != nil {
 		appErr := pkg.NewAppError("Failed to unenroll student", http.StatusInternalServerError)
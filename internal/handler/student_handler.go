@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"github.com/egasa21/si-lab-api-go/internal/middlewares"
 	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/egasa21/si-lab-api-go/internal/pkg"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 
 	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
@@ -122,20 +124,13 @@ func (h *StudentHandler) CreateStudent(w http.ResponseWriter, r *http.Request) {
 
 func (h *StudentHandler) GetStudentPracticumActivities(w http.ResponseWriter, r *http.Request) {
 
-	userIDValue := r.Context().Value(middlewares.UserIDKey)
-	if userIDValue == nil {
-		log.Error().Msg("User ID not found in context")
-		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
-		return
-	}
-
-	userIDFloat, ok := userIDValue.(float64)
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
 	if !ok {
-		log.Error().Msgf("Invalid user ID type in context: %T", userIDValue)
+		log.Error().Msg("Principal not found in context")
 		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
 		return
 	}
-	userID := int(userIDFloat)
+	userID := principal.UserID
 
 	studentActivities, err := h.studentDataService.GetStudentPracticumActivity(userID)
 	if err != nil {
@@ -149,20 +144,13 @@ func (h *StudentHandler) GetStudentPracticumActivities(w http.ResponseWriter, r
 }
 
 func (h *StudentHandler) GetStudentSchedules(w http.ResponseWriter, r *http.Request) {
-	userIDValue := r.Context().Value(middlewares.UserIDKey)
-	if userIDValue == nil {
-		log.Error().Msg("User ID not found in context")
-		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
-		return
-	}
-
-	userIDFloat, ok := userIDValue.(float64)
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
 	if !ok {
-		log.Error().Msgf("Invalid user ID type in context: %T", userIDValue)
+		log.Error().Msg("Principal not found in context")
 		response.NewErrorResponse(w, pkg.NewAppError("Unauthorized", http.StatusUnauthorized))
 		return
 	}
-	userID := int(userIDFloat)
+	userID := principal.UserID
 
 	studentSchedules, err := h.studentDataService.GetStudentSchedules(userID)
 	if err != nil {
@@ -174,3 +162,33 @@ func (h *StudentHandler) GetStudentSchedules(w http.ResponseWriter, r *http.Requ
 
 	response.NewSuccessResponse(w, studentSchedules, "student schedules retrieved successfully")
 }
+
+func (h *StudentHandler) SetStudyPlan(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.ErrUnauthorized)
+		return
+	}
+
+	var req dto.SetStudyPlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AttachmentID == uuid.Nil {
+		response.NewErrorResponse(w, pkg.NewAppError("attachment_id is required", http.StatusBadRequest))
+		return
+	}
+
+	student, err := h.studentService.SetStudyPlan(principal.UserID, req.AttachmentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrStudentNotFound):
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+		case errors.Is(err, service.ErrStudyPlanAttachmentInvalid):
+			response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusUnprocessableEntity))
+		default:
+			log.Error().Err(err).Msg("Failed to set study plan")
+			response.NewErrorResponse(w, pkg.ErrInternalServer)
+		}
+		return
+	}
+
+	response.NewSuccessResponse(w, student, "Study plan updated successfully")
+}
@@ -2,22 +2,23 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/egasa21/si-lab-api-go/internal/dto"
-	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/egasa21/si-lab-api-go/internal/pkg"
 	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
 	"github.com/egasa21/si-lab-api-go/internal/service"
 )
 
 type StudentRegistrationHandler struct {
-	service service.StudentRegistrationService
+	service        service.StudentRegistrationService
+	studentService service.StudentService
 }
 
-func NewStudentRegistrationHandler(service service.StudentRegistrationService) *StudentRegistrationHandler {
-	return &StudentRegistrationHandler{service: service}
+func NewStudentRegistrationHandler(service service.StudentRegistrationService, studentService service.StudentService) *StudentRegistrationHandler {
+	return &StudentRegistrationHandler{service: service, studentService: studentService}
 }
 
 // RegisterStudent handles the creation of student registration
@@ -30,24 +31,32 @@ func (h *StudentRegistrationHandler) RegisterStudent(w http.ResponseWriter, r *h
 		return
 	}
 
-	// Loop through the PracticumIDs to register each practicum
-	for _, practicumID := range req.PracticumIDs {
-		registration := model.StudentRegistration{
-			StudentID:   req.StudentID,
-			PracticumID: practicumID,
-		}
-		err := h.service.RegisterStudent(&registration)
-		if err != nil {
-			appErr := pkg.NewAppError("Failed to register student for practicum", http.StatusInternalServerError)
-			response.NewErrorResponse(w, appErr)
-			return
-		}
+	// Students register themselves, admins may register anyone
+	if appErr := authorizeStudentRecord(r, h.studentService, req.StudentID); appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	// Every practicum is checked before any is registered, a refused one registers none
+	err = h.service.RegisterStudent(req.StudentID, req.PracticumIDs)
+	switch {
+	case errors.Is(err, service.ErrPracticumNotFound):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+		return
+	case errors.Is(err, service.ErrRegistrationClosed), errors.Is(err, service.ErrPracticumNotInStudyPlan):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusForbidden))
+		return
+	case err != nil:
+		appErr := pkg.NewAppError("Failed to register student for practicum", http.StatusInternalServerError)
+		response.NewErrorResponse(w, appErr)
+		return
 	}
 
 	response.NewSuccessResponse(w, nil, "Student registration successful")
 }
 
-// GetRegistrationsByStudentID retrieves all registrations for a student
+// GetRegistrationsByStudentID retrieves the registrations of a student in the current term,
+// term_id picks another term and term_id=all lists every term
 func (h *StudentRegistrationHandler) GetRegistrationsByStudentID(w http.ResponseWriter, r *http.Request) {
 	studentID, err := strconv.Atoi(r.PathValue("student_id"))
 	if err != nil {
@@ -56,7 +65,14 @@ func (h *StudentRegistrationHandler) GetRegistrationsByStudentID(w http.Response
 		return
 	}
 
-	registrations, err := h.service.GetRegistrationsByStudentID(studentID)
+	termID, allTerms, err := parseTermQuery(r.URL.Query())
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid term ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	registrations, err := h.service.GetRegistrationsByStudentID(studentID, termID, allTerms)
 	if err != nil {
 		appErr := pkg.NewAppError("Unable to fetch registrations", http.StatusInternalServerError)
 		response.NewErrorResponse(w, appErr)
@@ -85,15 +101,31 @@ func (h *StudentRegistrationHandler) GetRegistrationsByPracticumID(w http.Respon
 	response.NewSuccessResponse(w, registrations, "Registrations retrieved successfully")
 }
 
-// DeleteRegistration handles the deletion of a student registration by its ID
+// DeleteRegistration handles the deletion of a student registration by its ID,
+// students may only withdraw their own registrations
 func (h *StudentRegistrationHandler) DeleteRegistration(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
 		appErr := pkg.NewAppError("Invalid registration ID", http.StatusBadRequest)
 		response.NewErrorResponse(w, appErr)
 		return
 	}
 
+	registration, err := h.service.GetRegistrationByID(id)
+	if errors.Is(err, service.ErrRegistrationNotFound) {
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+		return
+	}
+	if err != nil {
+		appErr := pkg.NewAppError("Failed to delete registration", http.StatusInternalServerError)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+	if appErr := authorizeStudentRecord(r, h.studentService, registration.StudentID); appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
 	err = h.service.DeleteRegistration(id)
 	if err != nil {
 		appErr := pkg.NewAppError("Failed to delete registration", http.StatusInternalServerError)
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/egasa21/si-lab-api-go/internal/dto"
+	"github.com/egasa21/si-lab-api-go/internal/middlewares"
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/pkg"
+	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
+	"github.com/egasa21/si-lab-api-go/internal/service"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type StudyPlanHandler struct {
+	service        service.StudyPlanService
+	studentService service.StudentService
+}
+
+func NewStudyPlanHandler(service service.StudyPlanService, studentService service.StudentService) *StudyPlanHandler {
+	return &StudyPlanHandler{service: service, studentService: studentService}
+}
+
+// SubmitStudyPlan records a study plan uploaded with purpose study_plan and the practicums it lists
+func (h *StudyPlanHandler) SubmitStudyPlan(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.ErrUnauthorized)
+		return
+	}
+
+	var req dto.SubmitStudyPlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AttachmentID == uuid.Nil {
+		response.NewErrorResponse(w, pkg.NewAppError("attachment_id and practicum_ids are required", http.StatusBadRequest))
+		return
+	}
+
+	plan, err := h.service.SubmitStudyPlan(principal.UserID, req.AttachmentID, req.PracticumIDs)
+	if err != nil {
+		writeStudyPlanError(w, err, "Failed to submit study plan")
+		return
+	}
+
+	response.NewSuccessResponse(w, plan, "Study plan submitted for review")
+}
+
+// GetMyStudyPlans lists the caller's study plans, newest first
+func (h *StudyPlanHandler) GetMyStudyPlans(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.ErrUnauthorized)
+		return
+	}
+
+	plans, err := h.service.GetStudyPlansByUserID(principal.UserID)
+	if err != nil {
+		writeStudyPlanError(w, err, "Failed to fetch study plans")
+		return
+	}
+
+	response.NewSuccessResponse(w, plans, "Study plans retrieved successfully")
+}
+
+// GetStudyPlans is the review queue, ?status= filters it
+func (h *StudyPlanHandler) GetStudyPlans(w http.ResponseWriter, r *http.Request) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	plans, total, err := h.service.GetStudyPlans(model.StudyPlanStatus(r.URL.Query().Get("status")), page, limit)
+	if err != nil {
+		writeStudyPlanError(w, err, "Failed to fetch study plans")
+		return
+	}
+
+	pagination := response.Pagination{
+		Page:       page,
+		PerPage:    limit,
+		TotalPages: (total + limit - 1) / limit,
+		TotalItems: total,
+	}
+	response.NewPaginatedSuccessResponse(w, plans, pagination, "Study plans retrieved successfully")
+}
+
+// GetStudyPlan is readable by staff and by the student who submitted it
+func (h *StudyPlanHandler) GetStudyPlan(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.NewErrorResponse(w, pkg.NewAppError("Invalid study plan ID", http.StatusBadRequest))
+		return
+	}
+
+	plan, err := h.service.GetStudyPlan(id)
+	if err != nil {
+		writeStudyPlanError(w, err, "Failed to fetch study plan")
+		return
+	}
+
+	if !isStaff(r) {
+		student, err := h.studentService.GetStudentByUserID(principal.UserID)
+		if err != nil || student == nil || student.ID != plan.StudentID {
+			response.NewErrorResponse(w, pkg.ErrForbidden)
+			return
+		}
+	}
+
+	response.NewSuccessResponse(w, plan, "Study plan retrieved successfully")
+}
+
+func (h *StudyPlanHandler) ApproveStudyPlan(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.NewErrorResponse(w, pkg.NewAppError("Invalid study plan ID", http.StatusBadRequest))
+		return
+	}
+
+	plan, err := h.service.ApproveStudyPlan(id, principal.UserID)
+	if err != nil {
+		writeStudyPlanError(w, err, "Failed to approve study plan")
+		return
+	}
+
+	response.NewSuccessResponse(w, plan, "Study plan approved")
+}
+
+func (h *StudyPlanHandler) RejectStudyPlan(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.NewErrorResponse(w, pkg.NewAppError("Invalid study plan ID", http.StatusBadRequest))
+		return
+	}
+
+	var req dto.RejectStudyPlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.NewErrorResponse(w, pkg.NewAppError("Invalid request payload", http.StatusBadRequest))
+		return
+	}
+
+	plan, err := h.service.RejectStudyPlan(id, principal.UserID, req.Reason)
+	if err != nil {
+		writeStudyPlanError(w, err, "Failed to reject study plan")
+		return
+	}
+
+	response.NewSuccessResponse(w, plan, "Study plan rejected")
+}
+
+func writeStudyPlanError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrStudyPlanNotFound), errors.Is(err, service.ErrStudentNotFound),
+		errors.Is(err, service.ErrPracticumNotFound):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+	case errors.Is(err, service.ErrStudyPlanPracticumsRequired), errors.Is(err, service.ErrStudyPlanReasonRequired),
+		errors.Is(err, service.ErrStudyPlanStatusFilter):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+	case errors.Is(err, service.ErrStudyPlanAttachmentInvalid):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusUnprocessableEntity))
+	case errors.Is(err, service.ErrStudyPlanNotPending):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusConflict))
+	default:
+		log.Error().Err(err).Msg(message)
+		response.NewErrorResponse(w, pkg.NewAppError(message, http.StatusInternalServerError))
+	}
+}
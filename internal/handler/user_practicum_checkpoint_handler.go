@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/egasa21/si-lab-api-go/internal/dto"
+	"github.com/egasa21/si-lab-api-go/internal/middlewares"
 	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/egasa21/si-lab-api-go/internal/pkg"
 	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
@@ -13,12 +14,14 @@ import (
 )
 
 type UserPracticumCheckpointHandler struct {
-	service service.UserPracticumCheckpointService
+	service      service.UserPracticumCheckpointService
+	staffService service.PracticumStaffService
 }
 
-func NewUserPracticumCheckpointHandler(service service.UserPracticumCheckpointService) *UserPracticumCheckpointHandler {
+func NewUserPracticumCheckpointHandler(service service.UserPracticumCheckpointService, staffService service.PracticumStaffService) *UserPracticumCheckpointHandler {
 	return &UserPracticumCheckpointHandler{
-		service: service,
+		service:      service,
+		staffService: staffService,
 	}
 }
 
@@ -32,8 +35,24 @@ func (h *UserPracticumCheckpointHandler) CreateCheckpoint(w http.ResponseWriter,
 		return
 	}
 
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.ErrUnauthorized)
+		return
+	}
+
+	userID := principal.UserID
+	if req.UserID != 0 {
+		userID = req.UserID
+	}
+
+	if appErr := authorizeUserRecord(r, h.staffService, userID, req.PracticumID); appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
 	checkpoint := model.UserPracticumCheckpoint{
-		UserID:      req.UserID,
+		UserID:      userID,
 		PracticumID: req.PracticumID,
 		ModuleID:    req.ModuleID,
 		ContentID:   req.ContentID,
@@ -42,8 +61,7 @@ func (h *UserPracticumCheckpointHandler) CreateCheckpoint(w http.ResponseWriter,
 	// Call the service to create the checkpoint
 	err = h.service.CreateCheckpoint(&checkpoint)
 	if err != nil {
-		appErr := pkg.NewAppError("Failed to create checkpoint", http.StatusInternalServerError)
-		response.NewErrorResponse(w, appErr)
+		writeProgressError(w, err, "Failed to create checkpoint")
 		return
 	}
 
@@ -66,6 +84,11 @@ func (h *UserPracticumCheckpointHandler) GetCheckpointByUserAndPracticum(w http.
 		return
 	}
 
+	if appErr := authorizeUserRecord(r, h.staffService, userID, practicumID); appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
 	// Retrieve the checkpoint from the service
 	checkpoint, err := h.service.GetCheckpointByUserAndPracticum(userID, practicumID)
 	if err != nil {
@@ -92,12 +115,47 @@ func (h *UserPracticumCheckpointHandler) GetCheckpointByUser(w http.ResponseWrit
 		response.NewErrorResponse(w, appErr)
 		return
 	}
+
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.ErrUnauthorized)
+		return
+	}
+
 	userCheckpoint, err := h.service.GetCheckpointByUser(userID)
 	if err != nil {
 		appErr := pkg.NewAppError("Unable to fetch checkpoint", http.StatusInternalServerError)
 		response.NewErrorResponse(w, appErr)
 		return
 	}
+
+	// Staff only see the checkpoints of the practicums they are assigned to
+	if principal.UserID != userID && !principal.HasRole(model.RoleAdmin) {
+		if !principal.HasRole(model.RoleLecturer, model.RoleLaboratoryAssistant) {
+			response.NewErrorResponse(w, pkg.ErrForbidden)
+			return
+		}
+
+		practicumIDs, err := h.staffService.GetPracticumIDsByUserID(principal.UserID)
+		if err != nil {
+			appErr := pkg.NewAppError("Unable to fetch checkpoint", http.StatusInternalServerError)
+			response.NewErrorResponse(w, appErr)
+			return
+		}
+
+		assigned := make(map[int]bool, len(practicumIDs))
+		for _, practicumID := range practicumIDs {
+			assigned[practicumID] = true
+		}
+
+		var visible []model.UserPracticumCheckpoint
+		for _, item := range userCheckpoint {
+			if assigned[item.PracticumID] {
+				visible = append(visible, item)
+			}
+		}
+		userCheckpoint = visible
+	}
 	if userCheckpoint == nil {
 		appErr := pkg.NewAppError("Checkpoint not found", http.StatusNotFound)
 		response.NewErrorResponse(w, appErr)
@@ -110,25 +168,50 @@ func (h *UserPracticumCheckpointHandler) GetCheckpointByUser(w http.ResponseWrit
 
 // UpdateCheckpoint handles updating a user practicum checkpoint
 func (h *UserPracticumCheckpointHandler) UpdateCheckpoint(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		appErr := pkg.NewAppError("Invalid checkpoint ID", http.StatusBadRequest)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	existing, err := h.service.GetCheckpointByID(id)
+	if err != nil {
+		appErr := pkg.NewAppError("Unable to fetch checkpoint", http.StatusInternalServerError)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+	if existing == nil {
+		appErr := pkg.NewAppError("Checkpoint not found", http.StatusNotFound)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	if appErr := authorizeUserRecord(r, h.staffService, existing.UserID, existing.PracticumID); appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
 	var req dto.CreateUserPracticumCheckpointRequest
-	err := json.NewDecoder(r.Body).Decode(&req)
+	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		appErr := pkg.NewAppError("Invalid request payload", http.StatusBadRequest)
 		response.NewErrorResponse(w, appErr)
 		return
 	}
 
+	// The owner and practicum of a checkpoint never change, only its position does
 	checkpoint := model.UserPracticumCheckpoint{
-		UserID:      req.UserID,
-		PracticumID: req.PracticumID,
+		ID:          existing.ID,
+		UserID:      existing.UserID,
+		PracticumID: existing.PracticumID,
 		ModuleID:    req.ModuleID,
 		ContentID:   req.ContentID,
 	}
 
 	err = h.service.UpdateCheckpoint(&checkpoint)
 	if err != nil {
-		appErr := pkg.NewAppError("Failed to update checkpoint", http.StatusInternalServerError)
-		response.NewErrorResponse(w, appErr)
+		writeProgressError(w, err, "Failed to update checkpoint")
 		return
 	}
 
@@ -145,6 +228,23 @@ func (h *UserPracticumCheckpointHandler) DeleteCheckpoint(w http.ResponseWriter,
 		return
 	}
 
+	existing, err := h.service.GetCheckpointByID(id)
+	if err != nil {
+		appErr := pkg.NewAppError("Unable to fetch checkpoint", http.StatusInternalServerError)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+	if existing == nil {
+		appErr := pkg.NewAppError("Checkpoint not found", http.StatusNotFound)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	if appErr := authorizeUserRecord(r, h.staffService, existing.UserID, existing.PracticumID); appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
 	err = h.service.DeleteCheckpoint(id)
 	if err != nil {
 		appErr := pkg.NewAppError("Failed to delete checkpoint", http.StatusInternalServerError)
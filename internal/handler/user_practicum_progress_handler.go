@@ -2,23 +2,27 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/egasa21/si-lab-api-go/internal/dto"
+	"github.com/egasa21/si-lab-api-go/internal/middlewares"
 	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/egasa21/si-lab-api-go/internal/pkg"
 	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
 	"github.com/egasa21/si-lab-api-go/internal/service"
+	"github.com/rs/zerolog/log"
 )
 
 type UserPracticumProgressHandler struct {
-	service service.UserPracticumProgressService
+	service      service.UserPracticumProgressService
+	staffService service.PracticumStaffService
 }
 
-func NewUserPracticumProgressHandler(service service.UserPracticumProgressService) *UserPracticumProgressHandler {
-	return &UserPracticumProgressHandler{service: service}
+func NewUserPracticumProgressHandler(service service.UserPracticumProgressService, staffService service.PracticumStaffService) *UserPracticumProgressHandler {
+	return &UserPracticumProgressHandler{service: service, staffService: staffService}
 }
 
 func (h *UserPracticumProgressHandler) CreateProgress(w http.ResponseWriter, r *http.Request) {
@@ -30,16 +34,31 @@ func (h *UserPracticumProgressHandler) CreateProgress(w http.ResponseWriter, r *
 		return
 	}
 
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		response.NewErrorResponse(w, pkg.ErrUnauthorized)
+		return
+	}
+
+	userID := principal.UserID
+	if req.UserID != 0 {
+		userID = req.UserID
+	}
+
+	if appErr := authorizeUserRecord(r, h.staffService, userID, req.PracticumID); appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
 	progress := model.UserPracticumProgress{
-		UserID:      req.UserID,
+		UserID:      userID,
 		PracticumID: req.PracticumID,
 		Progress:    req.Progress,
 	}
 
 	err = h.service.CreateProgress(&progress)
 	if err != nil {
-		appErr := pkg.NewAppError("Failed to create progress", http.StatusInternalServerError)
-		response.NewErrorResponse(w, appErr)
+		writeProgressError(w, err, "Failed to create progress")
 		return
 	}
 
@@ -61,10 +80,14 @@ func (h *UserPracticumProgressHandler) GetProgress(w http.ResponseWriter, r *htt
 		return
 	}
 
+	if appErr := authorizeUserRecord(r, h.staffService, userID, practicumID); appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
 	progress, err := h.service.GetProgress(userID, practicumID)
 	if err != nil {
-		appErr := pkg.NewAppError("Progress not found", http.StatusNotFound)
-		response.NewErrorResponse(w, appErr)
+		writeProgressError(w, err, "Failed to fetch progress")
 		return
 	}
 
@@ -94,6 +117,24 @@ func (h *UserPracticumProgressHandler) UpdateProgress(w http.ResponseWriter, r *
 		return
 	}
 
+	existing, err := h.service.GetProgressByID(id)
+	if errors.Is(err, service.ErrProgressNotFound) {
+		appErr := pkg.NewAppError("Progress not found", http.StatusNotFound)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch user practicum progress")
+		appErr := pkg.NewAppError("Failed to fetch progress", http.StatusInternalServerError)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	if appErr := authorizeUserRecord(r, h.staffService, existing.UserID, existing.PracticumID); appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
 	var req dto.UpdateUserPracticumProgressRequest
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -103,14 +144,15 @@ func (h *UserPracticumProgressHandler) UpdateProgress(w http.ResponseWriter, r *
 	}
 
 	progress := model.UserPracticumProgress{
-		ID:       id,
-		Progress: req.Progress,
+		ID:          id,
+		UserID:      existing.UserID,
+		PracticumID: existing.PracticumID,
+		Progress:    req.Progress,
 	}
 
 	err = h.service.UpdateProgress(&progress)
 	if err != nil {
-		appErr := pkg.NewAppError("Failed to update progress", http.StatusInternalServerError)
-		response.NewErrorResponse(w, appErr)
+		writeProgressError(w, err, "Failed to update progress")
 		return
 	}
 
@@ -132,10 +174,14 @@ func (h *UserPracticumProgressHandler) MarkAsCompleted(w http.ResponseWriter, r
 		return
 	}
 
+	if appErr := authorizeUserRecord(r, h.staffService, userID, practicumID); appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
 	err = h.service.MarkAsCompleted(userID, practicumID)
 	if err != nil {
-		appErr := pkg.NewAppError("Failed to mark progress as completed", http.StatusInternalServerError)
-		response.NewErrorResponse(w, appErr)
+		writeProgressError(w, err, "Failed to mark progress as completed")
 		return
 	}
 
@@ -150,6 +196,24 @@ func (h *UserPracticumProgressHandler) DeleteProgress(w http.ResponseWriter, r *
 		return
 	}
 
+	existing, err := h.service.GetProgressByID(id)
+	if errors.Is(err, service.ErrProgressNotFound) {
+		appErr := pkg.NewAppError("Progress not found", http.StatusNotFound)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch user practicum progress")
+		appErr := pkg.NewAppError("Failed to fetch progress", http.StatusInternalServerError)
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
+	if appErr := authorizeUserRecord(r, h.staffService, existing.UserID, existing.PracticumID); appErr != nil {
+		response.NewErrorResponse(w, appErr)
+		return
+	}
+
 	err = h.service.DeleteProgress(id)
 	if err != nil {
 		appErr := pkg.NewAppError("Failed to delete progress", http.StatusInternalServerError)
@@ -159,3 +223,18 @@ func (h *UserPracticumProgressHandler) DeleteProgress(w http.ResponseWriter, r *
 
 	response.NewSuccessResponse(w, nil, "User practicum progress deleted successfully")
 }
+
+// writeProgressError maps the errors of progress and checkpoint writes
+func writeProgressError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrProgressNotFound):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusNotFound))
+	case errors.Is(err, service.ErrProgressNotRegistered):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusForbidden))
+	case errors.Is(err, service.ErrCheckpointPositionInvalid):
+		response.NewErrorResponse(w, pkg.NewAppError(err.Error(), http.StatusBadRequest))
+	default:
+		log.Error().Err(err).Msg(message)
+		response.NewErrorResponse(w, pkg.NewAppError(message, http.StatusInternalServerError))
+	}
+}
@@ -2,18 +2,95 @@ package middlewares
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/egasa21/si-lab-api-go/internal/pkg"
 	"github.com/egasa21/si-lab-api-go/internal/pkg/response"
 	"github.com/egasa21/si-lab-api-go/internal/service"
 	"github.com/egasa21/si-lab-api-go/internal/utils"
 	"github.com/egasa21/si-lab-api-go/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog/log"
 )
 
-const UserIDKey utils.ContextKey = "user_id"
+const (
+	PrincipalKey      utils.ContextKey = "principal"
+	acceptedScopesKey utils.ContextKey = "accepted_api_token_scopes"
+)
+
+// Principal is the authenticated caller attached to the request context
+type Principal struct {
+	UserID int
+	Roles  []model.Role
+	// TokenID and ExpiresAt identify the access token, used to revoke it on logout
+	TokenID   string
+	ExpiresAt time.Time
+	// APITokenID is set when the caller authenticated with a personal access token,
+	// Scopes then limits what the token may do
+	APITokenID int
+	Scopes     []model.Scope
+}
+
+// IsAPIToken reports whether the principal authenticated with a personal access token
+func (p *Principal) IsAPIToken() bool {
+	return p.APITokenID != 0
+}
+
+// HasScope reports whether the principal may act within the scope. Sessions are not scoped.
+func (p *Principal) HasScope(scope model.Scope) bool {
+	if !p.IsAPIToken() {
+		return true
+	}
+	for _, owned := range p.Scopes {
+		if owned == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole reports whether the principal holds at least one of the given roles
+func (p *Principal) HasRole(roles ...model.Role) bool {
+	for _, owned := range p.Roles {
+		for _, role := range roles {
+			if owned == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PrincipalFromContext returns the principal stored by AuthMiddleware
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(PrincipalKey).(*Principal)
+	return principal, ok && principal != nil
+}
 
-func AuthMiddleware(authService service.AuthService) func(next http.Handler) http.Handler {
+type acceptedScopes struct {
+	scopes []model.Scope
+}
+
+// AcceptAPITokens lets personal access tokens holding every given scope authenticate on the
+// route, without any scope every token is accepted. Routes without it only accept session
+// tokens. It must be chained before AuthMiddleware.
+func AcceptAPITokens(scopes ...model.Scope) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), acceptedScopesKey, acceptedScopes{scopes: scopes})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// AuthMiddleware authenticates the caller with a session JWT or, on routes marked with
+// AcceptAPITokens, a personal access token
+func AuthMiddleware(authService service.AuthService, tokenService service.PersonalAccessTokenService) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -26,6 +103,18 @@ func AuthMiddleware(authService service.AuthService) func(next http.Handler) htt
 			}
 
 			tokenString := authHeader[7:]
+			if strings.HasPrefix(tokenString, service.PersonalAccessTokenPrefix) {
+				principal, appErr := authenticateAPIToken(r, tokenService, tokenString)
+				if appErr != nil {
+					response.NewErrorResponse(w, appErr)
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), PrincipalKey, principal)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			claims, err := auth.VerifyToken(tokenString)
 			if err != nil {
 				response.NewErrorResponse(w, &pkg.AppError{
@@ -35,8 +124,116 @@ func AuthMiddleware(authService service.AuthService) func(next http.Handler) htt
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), UserIDKey, claims["user_id"])
+			principal, ok := principalFromClaims(claims)
+			if !ok {
+				response.NewErrorResponse(w, &pkg.AppError{
+					Message:    "Invalid or expired token",
+					StatusCode: http.StatusUnauthorized,
+				})
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), PrincipalKey, principal)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// OptionalAuth runs auth only when the request carries an Authorization header, so public routes
+// can tell signed in callers apart. A header with a bad token is still rejected.
+func OptionalAuth(auth func(next http.Handler) http.Handler) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		authenticated := auth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireRoles only lets the request through when the principal holds one of the given roles.
+// It must be chained after AuthMiddleware.
+func RequireRoles(roles ...model.Role) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				response.NewErrorResponse(w, pkg.ErrUnauthorized)
+				return
+			}
+
+			if !principal.HasRole(roles...) {
+				response.NewErrorResponse(w, pkg.NewAppError("You do not have permission to perform this action", http.StatusForbidden))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authenticateAPIToken checks the token and that it holds the scopes the route accepts
+func authenticateAPIToken(r *http.Request, tokenService service.PersonalAccessTokenService, tokenString string) (*Principal, *pkg.AppError) {
+	accepted, ok := r.Context().Value(acceptedScopesKey).(acceptedScopes)
+	if !ok {
+		return nil, pkg.NewAppError("API tokens are not accepted for this endpoint", http.StatusForbidden)
+	}
+
+	token, roles, err := tokenService.Authenticate(tokenString)
+	if err != nil {
+		if !errors.Is(err, service.ErrAPITokenInvalid) {
+			log.Error().Err(err).Msg("Failed to authenticate api token")
+		}
+		return nil, pkg.NewAppError("Invalid or expired token", http.StatusUnauthorized)
+	}
+
+	principal := &Principal{
+		UserID:     token.UserID,
+		ExpiresAt:  token.ExpiresAt,
+		APITokenID: token.ID,
+		Scopes:     token.Scopes,
+	}
+	for _, role := range roles {
+		principal.Roles = append(principal.Roles, role.Name)
+	}
+
+	for _, scope := range accepted.scopes {
+		if !principal.HasScope(scope) {
+			return nil, pkg.NewAppError(fmt.Sprintf("The API token is missing the %s scope", scope), http.StatusForbidden)
+		}
+	}
+
+	return principal, nil
+}
+
+func principalFromClaims(claims jwt.MapClaims) (*Principal, bool) {
+	// refresh tokens can only be exchanged at /auth/refresh-token
+	if tokenType, _ := claims["type"].(string); tokenType != auth.TokenTypeAccess {
+		return nil, false
+	}
+
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, false
+	}
+
+	principal := &Principal{UserID: int(userIDFloat)}
+	principal.TokenID, _ = claims["jti"].(string)
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		principal.ExpiresAt = exp.Time
+	}
+
+	// roles is decoded as []interface{} by the JWT parser
+	if rawRoles, ok := claims["roles"].([]interface{}); ok {
+		for _, rawRole := range rawRoles {
+			if role, ok := rawRole.(string); ok {
+				principal.Roles = append(principal.Roles, model.Role(role))
+			}
+		}
+	}
+
+	return principal, true
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// AcademicTerm is a semester practicums, classes and registrations belong to
+type AcademicTerm struct {
+	ID                   int        `json:"id"`
+	Code                 string     `json:"code"`
+	Name                 string     `json:"name"`
+	StartDate            time.Time  `json:"start_date"`
+	EndDate              time.Time  `json:"end_date"`
+	RegistrationOpensAt  *time.Time `json:"registration_opens_at"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at"`
+	IsActive             bool       `json:"is_active"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// RegistrationOpen reports whether students can register at t, a missing bound leaves that side open
+func (t *AcademicTerm) RegistrationOpen(at time.Time) bool {
+	if t.RegistrationOpensAt != nil && at.Before(*t.RegistrationOpensAt) {
+		return false
+	}
+	if t.RegistrationClosesAt != nil && !at.Before(*t.RegistrationClosesAt) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AttachmentPurpose is what an uploaded file is for, it decides the size and type limits and who may read it
+type AttachmentPurpose string
+
+const (
+	AttachmentMaterial  AttachmentPurpose = "material"
+	AttachmentStudyPlan AttachmentPurpose = "study_plan"
+)
+
+// Attachment is an uploaded file, the contents live in the blob store under StorageKey
+type Attachment struct {
+	ID             uuid.UUID         `json:"id"`
+	Purpose        AttachmentPurpose `json:"purpose"`
+	StorageKey     string            `json:"-"`
+	Filename       string            `json:"filename"`
+	ContentType    string            `json:"content_type"`
+	Size           int64             `json:"size"`
+	ChecksumSHA256 string            `json:"checksum_sha256"`
+	UploadedBy     *int              `json:"uploaded_by"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// AttachmentDownload is a time-limited link to an attachment's contents
+type AttachmentDownload struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,152 @@
+package model
+
+// ContentBlockType is the kind of a block in structured module content
+type ContentBlockType string
+
+const (
+	BlockHeading    ContentBlockType = "heading"
+	BlockParagraph  ContentBlockType = "paragraph"
+	BlockCode       ContentBlockType = "code"
+	BlockImage      ContentBlockType = "image"
+	BlockVideo      ContentBlockType = "video"
+	BlockQuiz       ContentBlockType = "quiz"
+	BlockCallout    ContentBlockType = "callout"
+	BlockAttachment ContentBlockType = "attachment"
+)
+
+// ContentFieldKind is the JSON type a block field holds
+type ContentFieldKind string
+
+const (
+	FieldString      ContentFieldKind = "string"
+	FieldInteger     ContentFieldKind = "integer"
+	FieldURL         ContentFieldKind = "url"
+	FieldStringArray ContentFieldKind = "string_array"
+	FieldUUID        ContentFieldKind = "uuid"
+)
+
+// ContentBlockField describes one field of a block. Min and Max bound integers,
+// or the number of items of an array.
+type ContentBlockField struct {
+	Name        string           `json:"name"`
+	Kind        ContentFieldKind `json:"kind"`
+	Required    bool             `json:"required"`
+	Enum        []string         `json:"enum,omitempty"`
+	Min         *int             `json:"min,omitempty"`
+	Max         *int             `json:"max,omitempty"`
+	Description string           `json:"description"`
+}
+
+// ContentBlockSchema lists the fields a block type accepts, besides type and id
+type ContentBlockSchema struct {
+	Type        ContentBlockType    `json:"type"`
+	Description string              `json:"description"`
+	Fields      []ContentBlockField `json:"fields"`
+}
+
+// ContentDocument is the shape module content is stored in
+type ContentDocument struct {
+	Blocks []ContentBlock `json:"blocks"`
+}
+
+// ContentBlock holds the fields of every block type, only the ones of its type are set
+type ContentBlock struct {
+	Type         ContentBlockType `json:"type"`
+	ID           string           `json:"id,omitempty"`
+	Text         string           `json:"text,omitempty"`
+	Level        int              `json:"level,omitempty"`
+	Language     string           `json:"language,omitempty"`
+	Code         string           `json:"code,omitempty"`
+	URL          string           `json:"url,omitempty"`
+	Alt          string           `json:"alt,omitempty"`
+	Caption      string           `json:"caption,omitempty"`
+	Question     string           `json:"question,omitempty"`
+	Options      []string         `json:"options,omitempty"`
+	Answer       *int             `json:"answer,omitempty"`
+	Explanation  string           `json:"explanation,omitempty"`
+	Variant      string           `json:"variant,omitempty"`
+	Title        string           `json:"title,omitempty"`
+	AttachmentID string           `json:"attachment_id,omitempty"`
+}
+
+// ContentFieldError is a problem with one field of the content, Path is a JSONPath like $.blocks[0].text
+type ContentFieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// ContentBlockSchemas defines every block type module content may use
+var ContentBlockSchemas = []ContentBlockSchema{
+	{
+		Type:        BlockHeading,
+		Description: "A section heading",
+		Fields: []ContentBlockField{
+			{Name: "level", Kind: FieldInteger, Required: true, Min: intPtr(1), Max: intPtr(6), Description: "Heading level, 1 is the largest"},
+			{Name: "text", Kind: FieldString, Required: true, Description: "Heading text"},
+		},
+	},
+	{
+		Type:        BlockParagraph,
+		Description: "A paragraph of text",
+		Fields: []ContentBlockField{
+			{Name: "text", Kind: FieldString, Required: true, Description: "Paragraph text"},
+		},
+	},
+	{
+		Type:        BlockCode,
+		Description: "A code listing",
+		Fields: []ContentBlockField{
+			{Name: "language", Kind: FieldString, Description: "Language used for highlighting, e.g. go or sql"},
+			{Name: "code", Kind: FieldString, Required: true, Description: "Source code"},
+		},
+	},
+	{
+		Type:        BlockImage,
+		Description: "An image",
+		Fields: []ContentBlockField{
+			{Name: "url", Kind: FieldURL, Required: true, Description: "http or https address of the image"},
+			{Name: "alt", Kind: FieldString, Required: true, Description: "Text alternative for screen readers"},
+			{Name: "caption", Kind: FieldString, Description: "Caption shown below the image"},
+		},
+	},
+	{
+		Type:        BlockVideo,
+		Description: "An embedded video",
+		Fields: []ContentBlockField{
+			{Name: "url", Kind: FieldURL, Required: true, Description: "http or https address of the video"},
+			{Name: "caption", Kind: FieldString, Description: "Caption shown below the video"},
+		},
+	},
+	{
+		Type:        BlockQuiz,
+		Description: "A multiple choice question",
+		Fields: []ContentBlockField{
+			{Name: "question", Kind: FieldString, Required: true, Description: "The question"},
+			{Name: "options", Kind: FieldStringArray, Required: true, Min: intPtr(2), Max: intPtr(10), Description: "Possible answers"},
+			{Name: "answer", Kind: FieldInteger, Required: true, Min: intPtr(0), Description: "Index of the correct option"},
+			{Name: "explanation", Kind: FieldString, Description: "Shown once the question is answered"},
+		},
+	},
+	{
+		Type:        BlockCallout,
+		Description: "A highlighted note",
+		Fields: []ContentBlockField{
+			{Name: "variant", Kind: FieldString, Required: true, Enum: []string{"info", "tip", "warning", "danger"}, Description: "Style of the note"},
+			{Name: "title", Kind: FieldString, Description: "Heading of the note"},
+			{Name: "text", Kind: FieldString, Required: true, Description: "Body of the note"},
+		},
+	},
+	{
+		Type:        BlockAttachment,
+		Description: "A file to download, uploaded as a material attachment",
+		Fields: []ContentBlockField{
+			{Name: "attachment_id", Kind: FieldUUID, Required: true, Description: "ID of an attachment uploaded with purpose material"},
+			{Name: "title", Kind: FieldString, Description: "Link text, defaults to a generic label"},
+			{Name: "caption", Kind: FieldString, Description: "Shown below the link"},
+		},
+	},
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type EmailVerificationToken struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"id_user"`
+	Email     string     `json:"email"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
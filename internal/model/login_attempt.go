@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// LoginAttempt counts the consecutive failed logins of an email or a client IP
+type LoginAttempt struct {
+	Key          string     `json:"key"`
+	Failures     int        `json:"failures"`
+	LastFailedAt time.Time  `json:"last_failed_at"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+type PasswordResetToken struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"id_user"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// Scope limits what a personal access token can be used for
+type Scope string
+
+const (
+	ScopeStudentsRead       Scope = "students:read"
+	ScopePracticumsRead     Scope = "practicums:read"
+	ScopePracticumsWrite    Scope = "practicums:write"
+	ScopeRegistrationsWrite Scope = "registrations:write"
+	ScopeProgressRead       Scope = "progress:read"
+	ScopeProgressWrite      Scope = "progress:write"
+)
+
+// Scopes lists every scope a token can be granted
+var Scopes = []Scope{
+	ScopeStudentsRead,
+	ScopePracticumsRead,
+	ScopePracticumsWrite,
+	ScopeRegistrationsWrite,
+	ScopeProgressRead,
+	ScopeProgressWrite,
+}
+
+// PersonalAccessToken is a long-lived token for scripts and integrations acting as a user
+type PersonalAccessToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"id_user"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"`
+	Scopes     []Scope    `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
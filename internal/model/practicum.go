@@ -3,19 +3,63 @@ package model
 import "time"
 
 type Practicum struct {
-	ID          int       `json:"id"`
-	Code        string    `json:"code"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Credits     string    `json:"credits"`
-	Semester    string    `json:"semester"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             int        `json:"id"`
+	Code           string     `json:"code"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	Credits        string     `json:"credits"`
+	Semester       string     `json:"semester"`
+	AcademicTermID *int       `json:"academic_term_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+}
+
+type PracticumSortField string
+
+const (
+	PracticumSortRelevance PracticumSortField = "relevance"
+	PracticumSortName      PracticumSortField = "name"
+	PracticumSortCode      PracticumSortField = "code"
+	PracticumSortCredits   PracticumSortField = "credits"
+	PracticumSortSemester  PracticumSortField = "semester"
+	PracticumSortCreatedAt PracticumSortField = "created_at"
+	PracticumSortUpdatedAt PracticumSortField = "updated_at"
+)
+
+// PracticumSortFields lists every field the practicum catalogue can be sorted by
+var PracticumSortFields = []PracticumSortField{
+	PracticumSortRelevance,
+	PracticumSortName,
+	PracticumSortCode,
+	PracticumSortCredits,
+	PracticumSortSemester,
+	PracticumSortCreatedAt,
+	PracticumSortUpdatedAt,
+}
+
+// PracticumFilter narrows the practicum catalogue, zero values mean no filter
+type PracticumFilter struct {
+	Search     string
+	Semester   string
+	Credits    int
+	MinCredits int
+	MaxCredits int
+	// AcademicTermID of 0 falls back to the active term unless AllTerms is set
+	AcademicTermID int
+	AllTerms       bool
+	// WithoutTerm also lists practicums that have no term, they predate terms
+	WithoutTerm bool
+	// SortBy defaults to relevance when searching and to the practicum ID otherwise
+	SortBy   PracticumSortField
+	SortDesc bool
 }
 
 type Material struct {
 	ID    uint   `json:"id"`
 	Title string `json:"title"`
+	// Status is only filled for staff, students only get published materials
+	Status ContentStatus `json:"status,omitempty"`
 }
 
 type ModuleWithMaterials struct {
@@ -9,6 +9,7 @@ type PracticumClass struct {
 	Quota            int       `json:"quota"`
 	Day              string    `json:"day"`
 	Time             string    `json:"time"`
+	AcademicTermID   *int      `json:"academic_term_id"`
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
 }
@@ -0,0 +1,13 @@
+package model
+
+import "github.com/google/uuid"
+
+// PracticumCloneResult is the new practicum together with the IDs of the copied rows,
+// keyed by the ID of the row they were copied from
+type PracticumCloneResult struct {
+	Practicum Practicum               `json:"practicum"`
+	Modules   map[int]int             `json:"modules"`
+	Contents  map[int]int             `json:"contents"`
+	Materials map[uuid.UUID]uuid.UUID `json:"materials"`
+	Classes   map[int]int             `json:"classes"`
+}
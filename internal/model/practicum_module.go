@@ -5,6 +5,7 @@ import "time"
 type PracticumModule struct {
 	ID          int       `json:"id"`
 	Title       string    `json:"title"`
+	Sequence    int       `json:"sequence"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	PracticumID int       `json:"practicum_id"`
@@ -15,4 +15,44 @@ type PracticumModuleContent struct {
 	CreatedAt time.Time       `json:"created_at"`
 	UpdatedAt time.Time       `json:"updated_at"`
 	MaterialID uuid.UUID       `json:"material_id"`
+	// Status is the state of the latest revision, PublishedVersion the version students see.
+	// ScheduledVersion replaces PublishedVersion once PublishAt has passed.
+	Status           ContentStatus `json:"status,omitempty"`
+	PublishedVersion *int          `json:"published_version,omitempty"`
+	ScheduledVersion *int          `json:"scheduled_version,omitempty"`
+	PublishAt        *time.Time    `json:"publish_at,omitempty"`
+	SubmittedBy      *int          `json:"submitted_by,omitempty"`
+	SubmittedAt      *time.Time    `json:"submitted_at,omitempty"`
+	ReviewedBy       *int          `json:"reviewed_by,omitempty"`
+	ReviewedAt       *time.Time    `json:"reviewed_at,omitempty"`
+	ReviewNote       string        `json:"review_note,omitempty"`
+}
+
+// ContentStatus is where content is in the review workflow
+type ContentStatus string
+
+const (
+	ContentDraft     ContentStatus = "draft"
+	ContentInReview  ContentStatus = "in_review"
+	ContentPublished ContentStatus = "published"
+	ContentArchived  ContentStatus = "archived"
+)
+
+// ContentFormat is an output format module content can be rendered to
+type ContentFormat string
+
+const (
+	ContentFormatJSON     ContentFormat = "json"
+	ContentFormatHTML     ContentFormat = "html"
+	ContentFormatMarkdown ContentFormat = "markdown"
+)
+
+// RenderedContent is module content rendered server side, Body holds the HTML or Markdown
+type RenderedContent struct {
+	IDContent  int           `json:"id_content"`
+	MaterialID uuid.UUID     `json:"material_id"`
+	Title      string        `json:"title"`
+	Format     ContentFormat `json:"format"`
+	Body       string        `json:"body"`
+	UpdatedAt  time.Time     `json:"updated_at"`
 }
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/egasa21/si-lab-api-go/internal/pkg/jsondiff"
+	"github.com/google/uuid"
+)
+
+// PracticumModuleContentVersion is one revision of a material, numbered from 1 per material
+type PracticumModuleContentVersion struct {
+	ID           int             `json:"id"`
+	MaterialID   uuid.UUID       `json:"material_id"`
+	Version      int             `json:"version"`
+	Title        string          `json:"title"`
+	Content      json.RawMessage `json:"content,omitempty"`
+	AuthorID     *int            `json:"author_id"`
+	RestoredFrom *int            `json:"restored_from,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// PracticumModuleContentDiff lists the changes between two versions of a material. Paths point
+// into {"title": ..., "content": ...} so title changes show up next to content changes.
+type PracticumModuleContentDiff struct {
+	MaterialID  uuid.UUID            `json:"material_id"`
+	FromVersion int                  `json:"from_version"`
+	ToVersion   int                  `json:"to_version"`
+	Changes     []jsondiff.Operation `json:"changes"`
+}
@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+type PracticumStaff struct {
+	ID          int       `json:"id"`
+	PracticumID int       `json:"practicum_id"`
+	UserID      int       `json:"user_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
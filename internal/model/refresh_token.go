@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefreshToken struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"id_user"`
+	TokenHash string     `json:"-"`
+	FamilyID  uuid.UUID  `json:"family_id"`
+	AccessJTI *uuid.UUID `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// StaffProfile holds the identity of a lecturer, laboratory assistant or admin account
+type StaffProfile struct {
+	ID               int       `json:"id"`
+	UserID           int       `json:"id_user"`
+	EmployeeIDNumber string    `json:"employee_id_number"`
+	Name             string    `json:"name"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -1,12 +1,18 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Student struct {
-	ID              int       `json:"id"`
-	StudentIDNumber string    `json:"student_id_number"`
-	Name            string    `json:"name"`
-	StudyPlanFile   string    `json:"study_plan_file"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              int    `json:"id"`
+	StudentIDNumber string `json:"student_id_number"`
+	Name            string `json:"name"`
+	StudyPlanFile   string `json:"study_plan_file"`
+	// StudyPlanAttachmentID is the uploaded study plan, StudyPlanFile predates uploads
+	StudyPlanAttachmentID *uuid.UUID `json:"study_plan_attachment_id"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
 }
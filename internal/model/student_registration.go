@@ -6,6 +6,7 @@ type StudentRegistration struct {
 	IDStudentRegistration int       `json:"id_student_registration"`
 	StudentID             int       `json:"student_id"`
 	PracticumID           int       `json:"practicum_id"`
+	AcademicTermID        *int      `json:"academic_term_id"`
 	CreatedAt             time.Time `json:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at"`
 }
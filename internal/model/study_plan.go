@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StudyPlanStatus is where a study plan is in its review
+type StudyPlanStatus string
+
+const (
+	StudyPlanPending  StudyPlanStatus = "pending"
+	StudyPlanApproved StudyPlanStatus = "approved"
+	StudyPlanRejected StudyPlanStatus = "rejected"
+)
+
+// StudyPlan is a study plan (KRS) a student submitted, PracticumIDs are the practicums it lists
+type StudyPlan struct {
+	ID              int             `json:"id"`
+	StudentID       int             `json:"student_id"`
+	AttachmentID    uuid.UUID       `json:"attachment_id"`
+	PracticumIDs    []int           `json:"practicum_ids"`
+	Status          StudyPlanStatus `json:"status"`
+	RejectionReason *string         `json:"rejection_reason"`
+	ReviewedBy      *int            `json:"reviewed_by"`
+	ReviewedAt      *time.Time      `json:"reviewed_at"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// UserIdentity links a user to an account at an external identity provider
+type UserIdentity struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"id_user"`
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OIDCAuthRequest holds the PKCE verifier and nonce of a login that is waiting for its callback
+type OIDCAuthRequest struct {
+	State        string    `json:"state"`
+	CodeVerifier string    `json:"-"`
+	Nonce        string    `json:"-"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,79 @@
+package notifier
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Message is a notification addressed to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users, e.g. password reset links
+type Notifier interface {
+	Notify(message Message) error
+}
+
+// New returns the notifier for the given driver, falling back to the log notifier
+func New(driver, filePath string) Notifier {
+	switch driver {
+	case "file":
+		return NewFileNotifier(filePath)
+	default:
+		return NewLogNotifier()
+	}
+}
+
+type logNotifier struct{}
+
+// NewLogNotifier writes every message to the application log, meant for local development
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) Notify(message Message) error {
+	log.Info().
+		Str("to", message.To).
+		Str("subject", message.Subject).
+		Str("body", message.Body).
+		Msg("Notification sent")
+	return nil
+}
+
+type fileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileNotifier appends every message to a file, meant for local development
+func NewFileNotifier(path string) Notifier {
+	if path == "" {
+		path = "notifications.log"
+	}
+	return &fileNotifier{path: path}
+}
+
+func (n *fileNotifier) Notify(message Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open notification file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "--- %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), message.To, message.Subject, message.Body)
+	if err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+	return nil
+}
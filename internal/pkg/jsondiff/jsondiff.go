@@ -0,0 +1,109 @@
+// Package jsondiff compares two JSON documents structurally
+package jsondiff
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+)
+
+// Operation is one difference between two documents, Path is a JSON pointer (RFC 6901).
+// From is null for additions and To is null for removals.
+type Operation struct {
+	Op   string      `json:"op"`
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Diff lists the operations that turn from into to. Objects are compared key by key and
+// arrays element by element, anything else that differs is replaced as a whole.
+func Diff(from, to json.RawMessage) ([]Operation, error) {
+	var a, b interface{}
+	if err := json.Unmarshal(from, &a); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(to, &b); err != nil {
+		return nil, err
+	}
+
+	ops := []Operation{}
+	return compare(ops, "", a, b), nil
+}
+
+func compare(ops []Operation, path string, a, b interface{}) []Operation {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			return compareObjects(ops, path, av, bv)
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			return compareArrays(ops, path, av, bv)
+		}
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return ops
+	}
+	return append(ops, Operation{Op: OpReplace, Path: path, From: a, To: b})
+}
+
+func compareObjects(ops []Operation, path string, a, b map[string]interface{}) []Operation {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	// Sorted so the same two documents always give the same diff
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := path + "/" + escape(key)
+		av, inA := a[key]
+		bv, inB := b[key]
+		switch {
+		case !inB:
+			ops = append(ops, Operation{Op: OpRemove, Path: child, From: av})
+		case !inA:
+			ops = append(ops, Operation{Op: OpAdd, Path: child, To: bv})
+		default:
+			ops = compare(ops, child, av, bv)
+		}
+	}
+	return ops
+}
+
+func compareArrays(ops []Operation, path string, a, b []interface{}) []Operation {
+	common := len(a)
+	if len(b) < common {
+		common = len(b)
+	}
+	for i := 0; i < common; i++ {
+		ops = compare(ops, path+"/"+strconv.Itoa(i), a[i], b[i])
+	}
+	for i := common; i < len(b); i++ {
+		ops = append(ops, Operation{Op: OpAdd, Path: path + "/" + strconv.Itoa(i), To: b[i]})
+	}
+	// Removed from the end first, so each path is still valid when the operations are applied in order
+	for i := len(a) - 1; i >= common; i-- {
+		ops = append(ops, Operation{Op: OpRemove, Path: path + "/" + strconv.Itoa(i), From: a[i]})
+	}
+	return ops
+}
+
+func escape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
@@ -62,13 +62,18 @@ func NewPaginatedSuccessResponse(w http.ResponseWriter, data interface{}, pagina
 
 // NewErrorResponse formats an error response based on AppError.
 func NewErrorResponse(w http.ResponseWriter, err *pkg.AppError) {
+	NewErrorResponseWithData(w, err, nil)
+}
+
+// NewErrorResponseWithData formats an error response carrying details about the error in data.
+func NewErrorResponseWithData(w http.ResponseWriter, err *pkg.AppError, data interface{}) {
 	response := Response{
 		Meta: Meta{
 			Success:   false,
 			Message:   err.Message,
 			ErrorCode: err.StatusCode,
 		},
-		Data: nil,
+		Data: data,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/rs/zerolog/log"
+)
+
+type AcademicTermRepository interface {
+	CreateAcademicTerm(term *model.AcademicTerm) error
+	GetAcademicTermByID(id int) (*model.AcademicTerm, error)
+	GetAcademicTermByCode(code string) (*model.AcademicTerm, error)
+	GetActiveAcademicTerm() (*model.AcademicTerm, error)
+	GetAllAcademicTerms() ([]model.AcademicTerm, error)
+	UpdateAcademicTerm(term *model.AcademicTerm) (bool, error)
+	ActivateAcademicTerm(id int) (bool, error)
+}
+
+type academicTermRepository struct {
+	db *sql.DB
+}
+
+func NewAcademicTermRepository(db *sql.DB) AcademicTermRepository {
+	return &academicTermRepository{db: db}
+}
+
+const academicTermColumns = `id, code, name, start_date, end_date, registration_opens_at, registration_closes_at, is_active, created_at, updated_at`
+
+func (r *academicTermRepository) CreateAcademicTerm(term *model.AcademicTerm) error {
+	query := `
+		INSERT INTO academic_terms (code, name, start_date, end_date, registration_opens_at, registration_closes_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, is_active, created_at, updated_at
+	`
+	err := r.db.QueryRow(query, term.Code, term.Name, term.StartDate, term.EndDate, term.RegistrationOpensAt, term.RegistrationClosesAt).
+		Scan(&term.ID, &term.IsActive, &term.CreatedAt, &term.UpdatedAt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create academic term")
+		return err
+	}
+	return nil
+}
+
+// GetAcademicTermByID returns nil when the term doesn't exist
+func (r *academicTermRepository) GetAcademicTermByID(id int) (*model.AcademicTerm, error) {
+	return r.getAcademicTerm("SELECT "+academicTermColumns+" FROM academic_terms WHERE id = $1", id)
+}
+
+// GetAcademicTermByCode returns nil when no term has the code
+func (r *academicTermRepository) GetAcademicTermByCode(code string) (*model.AcademicTerm, error) {
+	return r.getAcademicTerm("SELECT "+academicTermColumns+" FROM academic_terms WHERE code = $1", code)
+}
+
+// GetActiveAcademicTerm returns nil when no term is active
+func (r *academicTermRepository) GetActiveAcademicTerm() (*model.AcademicTerm, error) {
+	return r.getAcademicTerm("SELECT " + academicTermColumns + " FROM academic_terms WHERE is_active")
+}
+
+func (r *academicTermRepository) getAcademicTerm(query string, args ...interface{}) (*model.AcademicTerm, error) {
+	term, err := scanAcademicTerm(r.db.QueryRow(query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch academic term")
+		return nil, err
+	}
+	return term, nil
+}
+
+// GetAllAcademicTerms lists the terms, most recent first
+func (r *academicTermRepository) GetAllAcademicTerms() ([]model.AcademicTerm, error) {
+	rows, err := r.db.Query("SELECT " + academicTermColumns + " FROM academic_terms ORDER BY start_date DESC, id DESC")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch academic terms")
+		return nil, err
+	}
+	defer rows.Close()
+
+	terms := []model.AcademicTerm{}
+	for rows.Next() {
+		term, err := scanAcademicTerm(rows)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, *term)
+	}
+	return terms, rows.Err()
+}
+
+// UpdateAcademicTerm reports false when the term doesn't exist
+func (r *academicTermRepository) UpdateAcademicTerm(term *model.AcademicTerm) (bool, error) {
+	query := `
+		UPDATE academic_terms
+		SET code = $1, name = $2, start_date = $3, end_date = $4, registration_opens_at = $5, registration_closes_at = $6, updated_at = NOW()
+		WHERE id = $7
+		RETURNING is_active, created_at, updated_at
+	`
+	err := r.db.QueryRow(query, term.Code, term.Name, term.StartDate, term.EndDate, term.RegistrationOpensAt, term.RegistrationClosesAt, term.ID).
+		Scan(&term.IsActive, &term.CreatedAt, &term.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Int("term_id", term.ID).Msg("Failed to update academic term")
+		return false, err
+	}
+	return true, nil
+}
+
+// ActivateAcademicTerm makes the term the current one and deactivates the previous one
+func (r *academicTermRepository) ActivateAcademicTerm(id int) (activated bool, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil || !activated {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	if _, err = tx.Exec("UPDATE academic_terms SET is_active = FALSE, updated_at = NOW() WHERE is_active AND id <> $1", id); err != nil {
+		log.Error().Err(err).Msg("Failed to deactivate academic terms")
+		return false, err
+	}
+
+	result, err := tx.Exec("UPDATE academic_terms SET is_active = TRUE, updated_at = NOW() WHERE id = $1", id)
+	if err != nil {
+		log.Error().Err(err).Int("term_id", id).Msg("Failed to activate academic term")
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func scanAcademicTerm(row rowScanner) (*model.AcademicTerm, error) {
+	var term model.AcademicTerm
+	err := row.Scan(&term.ID, &term.Code, &term.Name, &term.StartDate, &term.EndDate, &term.RegistrationOpensAt, &term.RegistrationClosesAt, &term.IsActive, &term.CreatedAt, &term.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &term, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// AccessTokenDenylistRepository satisfies auth.Denylist so revoked access tokens are rejected before they expire
+type AccessTokenDenylistRepository interface {
+	DenyAccessToken(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+}
+
+type accessTokenDenylistRepository struct {
+	db *sql.DB
+}
+
+func NewAccessTokenDenylistRepository(db *sql.DB) AccessTokenDenylistRepository {
+	return &accessTokenDenylistRepository{db: db}
+}
+
+func (r *accessTokenDenylistRepository) DenyAccessToken(jti string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO access_token_denylist (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+	`
+	if _, err := r.db.Exec(query, jti, expiresAt); err != nil {
+		log.Error().Err(err).Msg("Failed to deny access token")
+		return err
+	}
+
+	// Entries are useless once the token itself has expired
+	if _, err := r.db.Exec(`DELETE FROM access_token_denylist WHERE expires_at < NOW()`); err != nil {
+		log.Warn().Err(err).Msg("Failed to prune access token denylist")
+	}
+	return nil
+}
+
+func (r *accessTokenDenylistRepository) IsRevoked(jti string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM access_token_denylist WHERE jti = $1)`, jti).Scan(&exists)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check access token denylist")
+		return false, err
+	}
+	return exists, nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type AttachmentRepository interface {
+	CreateAttachment(attachment *model.Attachment) error
+	// GetAttachmentByID returns nil when the attachment doesn't exist
+	GetAttachmentByID(id uuid.UUID) (*model.Attachment, error)
+	GetAttachmentsByIDs(ids []uuid.UUID) ([]model.Attachment, error)
+	DeleteAttachment(id uuid.UUID) (bool, error)
+	// AttachmentInUse reports whether a study plan or an attachment block of module content,
+	// current or in an older version, refers to the attachment
+	AttachmentInUse(id uuid.UUID) (bool, error)
+}
+
+type attachmentRepository struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+const attachmentColumns = `id, purpose, storage_key, filename, content_type, size_bytes, checksum_sha256, uploaded_by, created_at`
+
+func (r *attachmentRepository) CreateAttachment(attachment *model.Attachment) error {
+	var uploadedBy sql.NullInt64
+	if attachment.UploadedBy != nil {
+		uploadedBy = nullableUserID(*attachment.UploadedBy)
+	}
+
+	err := r.db.QueryRow(`
+		INSERT INTO attachments (id, purpose, storage_key, filename, content_type, size_bytes, checksum_sha256, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at
+	`, attachment.ID, attachment.Purpose, attachment.StorageKey, attachment.Filename, attachment.ContentType,
+		attachment.Size, attachment.ChecksumSHA256, uploadedBy).Scan(&attachment.CreatedAt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create attachment")
+		return err
+	}
+	return nil
+}
+
+func (r *attachmentRepository) GetAttachmentByID(id uuid.UUID) (*model.Attachment, error) {
+	attachment, err := scanAttachment(r.db.QueryRow("SELECT "+attachmentColumns+" FROM attachments WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return attachment, err
+}
+
+func (r *attachmentRepository) GetAttachmentsByIDs(ids []uuid.UUID) ([]model.Attachment, error) {
+	if len(ids) == 0 {
+		return []model.Attachment{}, nil
+	}
+
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+
+	rows, err := r.db.Query("SELECT "+attachmentColumns+" FROM attachments WHERE id = ANY($1::uuid[])", pq.Array(values))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch attachments")
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []model.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *attachment)
+	}
+	return attachments, rows.Err()
+}
+
+func (r *attachmentRepository) DeleteAttachment(id uuid.UUID) (bool, error) {
+	result, err := r.db.Exec("DELETE FROM attachments WHERE id = $1", id)
+	if err != nil {
+		log.Error().Err(err).Str("attachment_id", id.String()).Msg("Failed to delete attachment")
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *attachmentRepository) AttachmentInUse(id uuid.UUID) (bool, error) {
+	var inUse bool
+	query := `
+		SELECT EXISTS (SELECT 1 FROM study_plans WHERE attachment_id = $1)
+			OR EXISTS (SELECT 1 FROM practicum_module_content WHERE content @> $2::jsonb)
+			OR EXISTS (SELECT 1 FROM practicum_module_content_versions WHERE content @> $2::jsonb)
+	`
+	// Containment matches a block with the attachment_id anywhere in blocks
+	block := `{"blocks": [{"attachment_id": "` + id.String() + `"}]}`
+	err := r.db.QueryRow(query, id, block).Scan(&inUse)
+	if err != nil {
+		log.Error().Err(err).Str("attachment_id", id.String()).Msg("Failed to check attachment references")
+		return false, err
+	}
+	return inUse, nil
+}
+
+func scanAttachment(row rowScanner) (*model.Attachment, error) {
+	var attachment model.Attachment
+	var uploadedBy sql.NullInt64
+
+	err := row.Scan(&attachment.ID, &attachment.Purpose, &attachment.StorageKey, &attachment.Filename, &attachment.ContentType,
+		&attachment.Size, &attachment.ChecksumSHA256, &uploadedBy, &attachment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment.UploadedBy = nullIntPtr(uploadedBy)
+	return &attachment, nil
+}
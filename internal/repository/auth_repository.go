@@ -8,11 +8,24 @@ import (
 )
 
 type AuthRepository interface {
-	Register(user *model.User) error
+	// CreateUser inserts the user with its roles and, when given, its staff profile in one transaction
+	CreateUser(user *model.User, roleIDs []int, staffProfile *model.StaffProfile) error
 	GetUserByEmail(email string) (*model.User, error)
 	GetUserByID(id int) (*model.User, error)
 	AddRoleToUser(idUser int, idRole int) error
+	RemoveRoleFromUser(idUser int, idRole int) error
 	GetRolesByUserID(idUser int) ([]model.RoleModel, error)
+	GetRoleByName(name string) (*model.RoleModel, error)
+	GetAllRoles() ([]model.RoleModel, error)
+	CountUsersWithRole(idRole int) (int, error)
+	UpdatePassword(idUser int, hashedPassword string) error
+	UpdateEmail(idUser int, email string) error
+	// IsEmailTaken reports whether another user has the email, ignoring case
+	IsEmailTaken(email string, exceptUserID int) (bool, error)
+	MarkEmailVerified(idUser int, email string) (bool, error)
+	LinkStudent(idUser int, idStudent int) (bool, error)
+	// IsStudentLinked reports whether a user is linked to the student record
+	IsStudentLinked(idStudent int) (bool, error)
 }
 
 type authRepository struct {
@@ -23,35 +36,80 @@ func NewAuthRepository(db *sql.DB) AuthRepository {
 	return &authRepository{db: db}
 }
 
-func (r *authRepository) Register(user *model.User) error {
-	query := `
+func (r *authRepository) CreateUser(user *model.User, roleIDs []int, staffProfile *model.StaffProfile) (err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	// Staff accounts are not linked to a student
+	var idStudent sql.NullInt64
+	if user.IDStudent != 0 {
+		idStudent = sql.NullInt64{Int64: int64(user.IDStudent), Valid: true}
+	}
+
+	err = tx.QueryRow(`
 		INSERT INTO users (email, password, id_student)
 		VALUES ($1, $2, $3)
-		RETURNING id_user`
-
-	// Scan the returned id_user into the user struct
-	err := r.db.QueryRow(query, user.Email, user.Password, user.IDStudent).Scan(&user.IDUser)
+		RETURNING id_user
+	`, user.Email, user.Password, idStudent).Scan(&user.IDUser)
 	if err != nil {
 		return fmt.Errorf("failed to register user: %w", err)
 	}
+
+	for _, roleID := range roleIDs {
+		_, err = tx.Exec("INSERT INTO user_roles (id_user, id_role) VALUES ($1, $2) ON CONFLICT DO NOTHING", user.IDUser, roleID)
+		if err != nil {
+			return fmt.Errorf("failed to assign role %d to user: %w", roleID, err)
+		}
+	}
+
+	if staffProfile != nil {
+		staffProfile.UserID = user.IDUser
+		err = tx.QueryRow(`
+			INSERT INTO staff_profiles (id_user, employee_id_number, name)
+			VALUES ($1, $2, $3)
+			RETURNING id, created_at, updated_at
+		`, staffProfile.UserID, staffProfile.EmployeeIDNumber, staffProfile.Name).
+			Scan(&staffProfile.ID, &staffProfile.CreatedAt, &staffProfile.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create staff profile: %w", err)
+		}
+	}
+
 	return nil
 }
 
 func (r *authRepository) GetUserByEmail(email string) (*model.User, error) {
 	var user model.User
+	var idStudent sql.NullInt64
+	// Addresses are compared ignoring case, the unique index on LOWER(email) allows one match
 	query := `
-		SELECT id_user, email, password, id_student, created_at
-		FROM users WHERE email = $1`
+		SELECT id_user, email, password, id_student, verified_at, created_at
+		FROM users WHERE LOWER(email) = LOWER($1)`
 	err := r.db.QueryRow(query, email).Scan(
 		&user.IDUser,
 		&user.Email,
 		&user.Password,
-		&user.IDStudent,
+		&idStudent,
+		&user.VerifiedAt,
 		&user.CreatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	user.IDStudent = int(idStudent.Int64)
 	roles, err := r.GetRolesByUserID(user.IDUser)
 	if err != nil {
 		return nil, err
@@ -63,19 +121,22 @@ func (r *authRepository) GetUserByEmail(email string) (*model.User, error) {
 
 func (r *authRepository) GetUserByID(id int) (*model.User, error) {
 	var user model.User
+	var idStudent sql.NullInt64
 	query := `
-		SELECT id_user, email, password, id_student, created_at
+		SELECT id_user, email, password, id_student, verified_at, created_at
 		FROM users WHERE id_user = $1`
 	err := r.db.QueryRow(query, id).Scan(
 		&user.IDUser,
 		&user.Email,
 		&user.Password,
-		&user.IDStudent,
+		&idStudent,
+		&user.VerifiedAt,
 		&user.CreatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	user.IDStudent = int(idStudent.Int64)
 	roles, err := r.GetRolesByUserID(user.IDUser)
 	if err != nil {
 		return nil, err
@@ -95,6 +156,15 @@ func (r *authRepository) AddRoleToUser(idUser int, idRole int) error {
 	return err
 }
 
+func (r *authRepository) RemoveRoleFromUser(idUser int, idRole int) error {
+	query := `DELETE FROM user_roles WHERE id_user = $1 AND id_role = $2`
+	_, err := r.db.Exec(query, idUser, idRole)
+	if err != nil {
+		return fmt.Errorf("failed to remove role from user: %w", err)
+	}
+	return nil
+}
+
 func (r *authRepository) GetRolesByUserID(idUser int) ([]model.RoleModel, error) {
 	query := `
 	SELECT r.id, r.name
@@ -119,3 +189,125 @@ func (r *authRepository) GetRolesByUserID(idUser int) ([]model.RoleModel, error)
 
 	return roles, nil
 }
+
+// GetRoleByName returns nil when no role has the given name
+func (r *authRepository) GetRoleByName(name string) (*model.RoleModel, error) {
+	var role model.RoleModel
+	err := r.db.QueryRow(`SELECT id, name FROM roles WHERE name = $1`, name).Scan(&role.ID, &role.Name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return &role, nil
+}
+
+func (r *authRepository) GetAllRoles() ([]model.RoleModel, error) {
+	rows, err := r.db.Query(`SELECT id, name FROM roles ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []model.RoleModel
+	for rows.Next() {
+		var role model.RoleModel
+		if err := rows.Scan(&role.ID, &role.Name); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (r *authRepository) CountUsersWithRole(idRole int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM user_roles WHERE id_role = $1`, idRole).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users with role: %w", err)
+	}
+	return count, nil
+}
+
+func (r *authRepository) UpdatePassword(idUser int, hashedPassword string) error {
+	query := `
+		UPDATE users
+		SET password = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id_user = $2`
+	_, err := r.db.Exec(query, hashedPassword, idUser)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	return nil
+}
+
+func (r *authRepository) UpdateEmail(idUser int, email string) error {
+	query := `
+		UPDATE users
+		SET email = $1, verified_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id_user = $2`
+	_, err := r.db.Exec(query, email, idUser)
+	if err != nil {
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+	return nil
+}
+
+func (r *authRepository) IsEmailTaken(email string, exceptUserID int) (bool, error) {
+	var taken bool
+	query := `SELECT EXISTS (SELECT 1 FROM users WHERE LOWER(email) = LOWER($1) AND id_user <> $2)`
+	if err := r.db.QueryRow(query, email, exceptUserID).Scan(&taken); err != nil {
+		return false, fmt.Errorf("failed to check email: %w", err)
+	}
+	return taken, nil
+}
+
+// MarkEmailVerified verifies the account only if its email is still the one the link was sent to
+func (r *authRepository) MarkEmailVerified(idUser int, email string) (bool, error) {
+	query := `
+		UPDATE users
+		SET verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id_user = $1 AND email = $2`
+	result, err := r.db.Exec(query, idUser, email)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// LinkStudent links the user to a student record, unless the user already has one or another
+// user is linked to that student
+func (r *authRepository) LinkStudent(idUser int, idStudent int) (bool, error) {
+	query := `
+		UPDATE users
+		SET id_student = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id_user = $1
+			AND id_student IS NULL
+			AND NOT EXISTS (SELECT 1 FROM users WHERE id_student = $2)`
+	result, err := r.db.Exec(query, idUser, idStudent)
+	if err != nil {
+		return false, fmt.Errorf("failed to link student: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *authRepository) IsStudentLinked(idStudent int) (bool, error) {
+	var linked bool
+	err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE id_student = $1)", idStudent).Scan(&linked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check student link: %w", err)
+	}
+	return linked, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/rs/zerolog/log"
+)
+
+type EmailVerificationRepository interface {
+	CreateEmailVerificationToken(token *model.EmailVerificationToken) error
+	GetEmailVerificationTokenByHash(tokenHash string) (*model.EmailVerificationToken, error)
+	MarkEmailVerificationTokenUsed(id int) (bool, error)
+}
+
+type emailVerificationRepository struct {
+	db *sql.DB
+}
+
+func NewEmailVerificationRepository(db *sql.DB) EmailVerificationRepository {
+	return &emailVerificationRepository{db: db}
+}
+
+func (r *emailVerificationRepository) CreateEmailVerificationToken(token *model.EmailVerificationToken) error {
+	query := `
+		INSERT INTO email_verification_tokens (id_user, email, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(query, token.UserID, token.Email, token.TokenHash, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to store email verification token")
+		return err
+	}
+	return nil
+}
+
+// GetEmailVerificationTokenByHash returns nil when the token was never issued
+func (r *emailVerificationRepository) GetEmailVerificationTokenByHash(tokenHash string) (*model.EmailVerificationToken, error) {
+	query := `
+		SELECT id, id_user, email, token_hash, expires_at, used_at, created_at
+		FROM email_verification_tokens
+		WHERE token_hash = $1
+	`
+	var token model.EmailVerificationToken
+	err := r.db.QueryRow(query, tokenHash).
+		Scan(&token.ID, &token.UserID, &token.Email, &token.TokenHash, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Error().Err(err).Msg("Failed to fetch email verification token")
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkEmailVerificationTokenUsed consumes the token and reports false if it had already been used
+func (r *emailVerificationRepository) MarkEmailVerificationTokenUsed(id int) (bool, error) {
+	result, err := r.db.Exec(`UPDATE email_verification_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`, id)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to mark email verification token as used")
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+)
+
+type memoryLoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]*model.LoginAttempt
+}
+
+// NewMemoryLoginAttemptRepository keeps the counters in process memory. They are lost on restart
+// and not shared between instances, so it only suits single instance deployments.
+func NewMemoryLoginAttemptRepository() LoginAttemptRepository {
+	return &memoryLoginAttemptRepository{attempts: make(map[string]*model.LoginAttempt)}
+}
+
+func (r *memoryLoginAttemptRepository) GetLoginAttempt(key string) (*model.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *attempt
+	return &copied, nil
+}
+
+func (r *memoryLoginAttemptRepository) RecordLoginFailure(key string, windowStart time.Time) (*model.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	attempt, ok := r.attempts[key]
+	if !ok {
+		attempt = &model.LoginAttempt{Key: key}
+		r.attempts[key] = attempt
+	}
+	if attempt.LastFailedAt.Before(windowStart) {
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.LastFailedAt = now
+
+	// Counters outside the window and without an active lock no longer matter
+	for k, a := range r.attempts {
+		if a.LastFailedAt.Before(windowStart) && (a.LockedUntil == nil || a.LockedUntil.Before(now)) {
+			delete(r.attempts, k)
+		}
+	}
+
+	copied := *attempt
+	return &copied, nil
+}
+
+func (r *memoryLoginAttemptRepository) LockLogin(key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempt, ok := r.attempts[key]; ok {
+		attempt.LockedUntil = &until
+	}
+	return nil
+}
+
+func (r *memoryLoginAttemptRepository) ResetLoginAttempts(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/rs/zerolog/log"
+)
+
+// LoginAttemptRepository keeps the failed login counters used for lockouts
+type LoginAttemptRepository interface {
+	GetLoginAttempt(key string) (*model.LoginAttempt, error)
+	// RecordLoginFailure increments the counter, starting over when the last failure is older than windowStart
+	RecordLoginFailure(key string, windowStart time.Time) (*model.LoginAttempt, error)
+	LockLogin(key string, until time.Time) error
+	ResetLoginAttempts(key string) error
+}
+
+type loginAttemptRepository struct {
+	db *sql.DB
+}
+
+// NewLoginAttemptRepository stores the counters in Postgres so they are shared between instances
+func NewLoginAttemptRepository(db *sql.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+// GetLoginAttempt returns nil when the key has no recorded failures
+func (r *loginAttemptRepository) GetLoginAttempt(key string) (*model.LoginAttempt, error) {
+	query := `
+		SELECT key, failures, last_failed_at, locked_until
+		FROM login_attempts
+		WHERE key = $1
+	`
+	var attempt model.LoginAttempt
+	err := r.db.QueryRow(query, key).Scan(&attempt.Key, &attempt.Failures, &attempt.LastFailedAt, &attempt.LockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Error().Err(err).Msg("Failed to fetch login attempt")
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *loginAttemptRepository) RecordLoginFailure(key string, windowStart time.Time) (*model.LoginAttempt, error) {
+	query := `
+		INSERT INTO login_attempts (key, failures, last_failed_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failed_at < $2 THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failed_at = NOW()
+		RETURNING key, failures, last_failed_at, locked_until
+	`
+	var attempt model.LoginAttempt
+	err := r.db.QueryRow(query, key, windowStart).Scan(&attempt.Key, &attempt.Failures, &attempt.LastFailedAt, &attempt.LockedUntil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to record login failure")
+		return nil, err
+	}
+
+	// Counters outside the window and without an active lock no longer matter
+	prune := `DELETE FROM login_attempts WHERE last_failed_at < $1 AND (locked_until IS NULL OR locked_until < NOW())`
+	if _, err := r.db.Exec(prune, windowStart); err != nil {
+		log.Warn().Err(err).Msg("Failed to prune login attempts")
+	}
+	return &attempt, nil
+}
+
+func (r *loginAttemptRepository) LockLogin(key string, until time.Time) error {
+	if _, err := r.db.Exec(`UPDATE login_attempts SET locked_until = $2 WHERE key = $1`, key, until); err != nil {
+		log.Error().Err(err).Msg("Failed to lock login")
+		return err
+	}
+	return nil
+}
+
+func (r *loginAttemptRepository) ResetLoginAttempts(key string) error {
+	if _, err := r.db.Exec(`DELETE FROM login_attempts WHERE key = $1`, key); err != nil {
+		log.Error().Err(err).Msg("Failed to reset login attempts")
+		return err
+	}
+	return nil
+}
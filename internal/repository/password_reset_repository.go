@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/rs/zerolog/log"
+)
+
+type PasswordResetRepository interface {
+	CreatePasswordResetToken(token *model.PasswordResetToken) error
+	GetPasswordResetTokenByHash(tokenHash string) (*model.PasswordResetToken, error)
+	// ResetPassword consumes the token and sets the password of its user in one transaction,
+	// it reports false if the token had already been used
+	ResetPassword(id int, hashedPassword string) (bool, error)
+	InvalidatePasswordResetTokensByUserID(userID int) error
+}
+
+type passwordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordResetRepository(db *sql.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+func (r *passwordResetRepository) CreatePasswordResetToken(token *model.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (id_user, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(query, token.UserID, token.TokenHash, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to store password reset token")
+		return err
+	}
+	return nil
+}
+
+// GetPasswordResetTokenByHash returns nil when the token was never issued
+func (r *passwordResetRepository) GetPasswordResetTokenByHash(tokenHash string) (*model.PasswordResetToken, error) {
+	query := `
+		SELECT id, id_user, token_hash, expires_at, used_at, created_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1
+	`
+	var token model.PasswordResetToken
+	err := r.db.QueryRow(query, tokenHash).
+		Scan(&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Error().Err(err).Msg("Failed to fetch password reset token")
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *passwordResetRepository) ResetPassword(id int, hashedPassword string) (consumed bool, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil || !consumed {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var userID int
+	err = tx.QueryRow(`
+		UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE id = $1 AND used_at IS NULL
+		RETURNING id_user
+	`, id).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to mark password reset token as used")
+		return false, err
+	}
+
+	_, err = tx.Exec(`UPDATE users SET password = $1, updated_at = CURRENT_TIMESTAMP WHERE id_user = $2`, hashedPassword, userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to reset password")
+		return false, err
+	}
+	return true, nil
+}
+
+// InvalidatePasswordResetTokensByUserID consumes every outstanding token of the user
+func (r *passwordResetRepository) InvalidatePasswordResetTokensByUserID(userID int) error {
+	_, err := r.db.Exec(`UPDATE password_reset_tokens SET used_at = NOW() WHERE id_user = $1 AND used_at IS NULL`, userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to invalidate password reset tokens")
+		return err
+	}
+	return nil
+}
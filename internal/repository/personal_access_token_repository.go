@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type PersonalAccessTokenRepository interface {
+	CreatePersonalAccessToken(token *model.PersonalAccessToken) error
+	GetPersonalAccessTokenByHash(tokenHash string) (*model.PersonalAccessToken, error)
+	GetPersonalAccessTokensByUserID(userID int) ([]model.PersonalAccessToken, error)
+	RevokePersonalAccessToken(id, userID int) (bool, error)
+	RevokeAllByUserID(userID int) error
+	TouchPersonalAccessToken(id int) error
+}
+
+type personalAccessTokenRepository struct {
+	db *sql.DB
+}
+
+func NewPersonalAccessTokenRepository(db *sql.DB) PersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{db: db}
+}
+
+func (r *personalAccessTokenRepository) CreatePersonalAccessToken(token *model.PersonalAccessToken) error {
+	query := `
+		INSERT INTO personal_access_tokens (id_user, name, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(query, token.UserID, token.Name, token.TokenHash, pq.Array(scopeStrings(token.Scopes)), token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create personal access token")
+		return err
+	}
+	return nil
+}
+
+// GetPersonalAccessTokenByHash returns nil when no token has the hash
+func (r *personalAccessTokenRepository) GetPersonalAccessTokenByHash(tokenHash string) (*model.PersonalAccessToken, error) {
+	query := `
+		SELECT id, id_user, name, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM personal_access_tokens
+		WHERE token_hash = $1
+	`
+	token, err := scanPersonalAccessToken(r.db.QueryRow(query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Error().Err(err).Msg("Failed to fetch personal access token")
+		return nil, err
+	}
+	return token, nil
+}
+
+// GetPersonalAccessTokensByUserID lists the tokens of a user that are still usable
+func (r *personalAccessTokenRepository) GetPersonalAccessTokensByUserID(userID int) ([]model.PersonalAccessToken, error) {
+	query := `
+		SELECT id, id_user, name, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM personal_access_tokens
+		WHERE id_user = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch personal access tokens")
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []model.PersonalAccessToken{}
+	for rows.Next() {
+		token, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+// RevokePersonalAccessToken revokes a token of the given user, false means there was none to revoke
+func (r *personalAccessTokenRepository) RevokePersonalAccessToken(id, userID int) (bool, error) {
+	query := `UPDATE personal_access_tokens SET revoked_at = NOW() WHERE id = $1 AND id_user = $2 AND revoked_at IS NULL`
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to revoke personal access token")
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// RevokeAllByUserID revokes every personal access token of the user
+func (r *personalAccessTokenRepository) RevokeAllByUserID(userID int) error {
+	_, err := r.db.Exec(`UPDATE personal_access_tokens SET revoked_at = NOW() WHERE id_user = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to revoke user personal access tokens")
+		return err
+	}
+	return nil
+}
+
+// TouchPersonalAccessToken records that the token was used, at most once a minute to spare writes
+func (r *personalAccessTokenRepository) TouchPersonalAccessToken(id int) error {
+	query := `
+		UPDATE personal_access_tokens SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPersonalAccessToken(row rowScanner) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	var scopes []string
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash, pq.Array(&scopes),
+		&token.ExpiresAt, &token.LastUsedAt, &token.RevokedAt, &token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range scopes {
+		token.Scopes = append(token.Scopes, model.Scope(scope))
+	}
+	return &token, nil
+}
+
+func scopeStrings(scopes []model.Scope) []string {
+	values := make([]string, len(scopes))
+	for i, scope := range scopes {
+		values[i] = string(scope)
+	}
+	return values
+}
@@ -28,13 +28,15 @@ func NewPracticumClassRepository(db *sql.DB) PracticumClassRepository {
 }
 
 func (r *practicumClassRepository) CreateClass(class *model.PracticumClass) error {
+	// The class takes the academic term of its practicum
 	query := `
-		INSERT INTO practicum_class (practicum_id, name, quota, day, time, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
-		RETURNING id_practicum_class
+		INSERT INTO practicum_class (practicum_id, name, quota, day, time, academic_term_id, created_at, updated_at)
+		SELECT $1, $2, $3, $4, $5, academic_term_id, NOW(), NOW()
+		FROM practicums WHERE id_practicum = $1
+		RETURNING id_practicum_class, academic_term_id
 	`
 	err := r.db.QueryRow(query, class.PracticumID, class.Name, class.Quota, class.Day, class.Time).
-		Scan(&class.IDPracticumClass)
+		Scan(&class.IDPracticumClass, &class.AcademicTermID)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create practicum class")
 		return err
@@ -45,12 +47,12 @@ func (r *practicumClassRepository) CreateClass(class *model.PracticumClass) erro
 func (r *practicumClassRepository) GetClassByID(id int) (*model.PracticumClass, error) {
 	var class model.PracticumClass
 	query := `
-		SELECT id_practicum_class, practicum_id, name, quota, day, time, created_at, updated_at 
+		SELECT id_practicum_class, practicum_id, name, quota, day, time, academic_term_id, created_at, updated_at 
 		FROM practicum_class 
 		WHERE id_practicum_class = $1
 	`
 	err := r.db.QueryRow(query, id).
-		Scan(&class.IDPracticumClass, &class.PracticumID, &class.Name, &class.Quota, &class.Day, &class.Time, &class.CreatedAt, &class.UpdatedAt)
+		Scan(&class.IDPracticumClass, &class.PracticumID, &class.Name, &class.Quota, &class.Day, &class.Time, &class.AcademicTermID, &class.CreatedAt, &class.UpdatedAt)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get practicum class by ID")
 		return nil, err
@@ -60,7 +62,7 @@ func (r *practicumClassRepository) GetClassByID(id int) (*model.PracticumClass,
 
 func (r *practicumClassRepository) GetClassesByPracticumID(practicumID int) ([]model.PracticumClass, error) {
 	query := `
-		SELECT id_practicum_class, practicum_id, name, quota, day, time, created_at, updated_at 
+		SELECT id_practicum_class, practicum_id, name, quota, day, time, academic_term_id, created_at, updated_at 
 		FROM practicum_class 
 		WHERE practicum_id = $1
 	`
@@ -74,7 +76,7 @@ func (r *practicumClassRepository) GetClassesByPracticumID(practicumID int) ([]m
 	var classes []model.PracticumClass
 	for rows.Next() {
 		var class model.PracticumClass
-		if err := rows.Scan(&class.IDPracticumClass, &class.PracticumID, &class.Name, &class.Quota, &class.Day, &class.Time, &class.CreatedAt, &class.UpdatedAt); err != nil {
+		if err := rows.Scan(&class.IDPracticumClass, &class.PracticumID, &class.Name, &class.Quota, &class.Day, &class.Time, &class.AcademicTermID, &class.CreatedAt, &class.UpdatedAt); err != nil {
 			log.Error().Err(err).Msg("Failed to scan practicum class")
 			return nil, err
 		}
@@ -86,7 +88,8 @@ func (r *practicumClassRepository) GetClassesByPracticumID(practicumID int) ([]m
 func (r *practicumClassRepository) UpdateClass(class *model.PracticumClass) error {
 	query := `
 		UPDATE practicum_class 
-		SET practicum_id = $1, name = $2, quota = $3, day = $4, time = $5, updated_at = NOW() 
+		SET practicum_id = $1, name = $2, quota = $3, day = $4, time = $5, updated_at = NOW(),
+			academic_term_id = (SELECT academic_term_id FROM practicums WHERE id_practicum = $1)
 		WHERE id_practicum_class = $6
 	`
 	_, err := r.db.Exec(query, class.PracticumID, class.Name, class.Quota, class.Day, class.Time, class.IDPracticumClass)
@@ -118,7 +121,7 @@ func (r *practicumClassRepository) GetClassByIDs(ids []int) ([]model.PracticumCl
 	}
 
 	inClause := strings.Join(placeholders, ",")
-	query := fmt.Sprintf("SELECT id_practicum_class, practicum_id, name, quota, day, time, created_at, updated_at FROM practicum_class WHERE id_practicum_class IN (%s)", inClause)
+	query := fmt.Sprintf("SELECT id_practicum_class, practicum_id, name, quota, day, time, academic_term_id, created_at, updated_at FROM practicum_class WHERE id_practicum_class IN (%s)", inClause)
 
 	args := make([]interface{}, len(ids))
 	for i, id := range ids {
@@ -134,7 +137,7 @@ func (r *practicumClassRepository) GetClassByIDs(ids []int) ([]model.PracticumCl
 	practicumClasses := []model.PracticumClass{}
 	for rows.Next() {
 		practicumClass := model.PracticumClass{}
-		if err := rows.Scan(&practicumClass.IDPracticumClass, &practicumClass.PracticumID, &practicumClass.Name, &practicumClass.Quota, &practicumClass.Day, &practicumClass.Time, &practicumClass.CreatedAt, &practicumClass.UpdatedAt); err != nil {
+		if err := rows.Scan(&practicumClass.IDPracticumClass, &practicumClass.PracticumID, &practicumClass.Name, &practicumClass.Quota, &practicumClass.Day, &practicumClass.Time, &practicumClass.AcademicTermID, &practicumClass.CreatedAt, &practicumClass.UpdatedAt); err != nil {
 			return nil, err
 		}
 
@@ -5,21 +5,72 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 )
 
 type PracticumModuleContentRepository interface {
-	CreateContent(content *model.PracticumModuleContent) (*model.PracticumModuleContent, error)
+	// CreateContent stores the content and records it as version 1, authorID 0 leaves the author empty
+	CreateContent(content *model.PracticumModuleContent, authorID int) (*model.PracticumModuleContent, error)
 	GetContentByID(id int) (*model.PracticumModuleContent, error)
+	// GetContentByMaterialID returns nil when no content has the material ID
+	GetContentByMaterialID(materialID uuid.UUID) (*model.PracticumModuleContent, error)
 	GetContentByIDs(ids []int) ([]model.PracticumModuleContent, error)
-	GetContentsByModuleID(moduleID, page, limit int) ([]model.PracticumModuleContent, int, error)
-	UpdateContentByID(id int, updatedContent *model.PracticumModuleContent) error
+	// GetContentsByModuleID lists the latest revisions, an empty status lists every status
+	GetContentsByModuleID(moduleID int, status model.ContentStatus, page, limit int) ([]model.PracticumModuleContent, int, error)
+	// GetPublishedContentByID returns the version students see, nil when none is visible yet
+	GetPublishedContentByID(id int) (*model.PracticumModuleContent, error)
+	GetPublishedContentsByModuleID(moduleID, page, limit int) ([]model.PracticumModuleContent, int, error)
+	// GetPublishedContentByIDs leaves out the contents students can't see yet
+	GetPublishedContentByIDs(ids []int) ([]model.PracticumModuleContent, error)
+	// UpdateContentByID overwrites the content and records the result as a new version
+	UpdateContentByID(id int, updatedContent *model.PracticumModuleContent, authorID int) error
 	DeleteContentByID(id int) error
+	// ReorderContents reports false when contentIDs isn't exactly the set of contents of the module
+	ReorderContents(moduleID int, contentIDs []int) (bool, error)
+	// MoveContent puts the content at position in another module, position 0 appends it
+	MoveContent(id, moduleID, position int) (bool, error)
+	// GetContentVersions lists the versions of a material, newest first and without their content
+	GetContentVersions(materialID uuid.UUID) ([]model.PracticumModuleContentVersion, error)
+	// GetContentVersion returns nil when the material has no such version
+	GetContentVersion(materialID uuid.UUID, version int) (*model.PracticumModuleContentVersion, error)
+	// ContentVersionsExist reports whether the material has versions, deleted content keeps them
+	ContentVersionsExist(materialID uuid.UUID) (bool, error)
+	// RestoreContentVersion copies an older version back into the content as a new version,
+	// it returns nil when the material has no such version. Deleted content is recreated as a
+	// draft at the end of the module it was deleted from, sql.ErrNoRows means that module is gone.
+	RestoreContentVersion(materialID uuid.UUID, version, authorID int) (*model.PracticumModuleContentVersion, error)
+	// The workflow methods report false when the content doesn't exist or isn't in a status the change applies to
+	SubmitContent(id, userID int) (bool, error)
+	// PublishContent makes the latest version the one students see from publishAt on, nil publishes right away.
+	// A version students already see stays visible until publishAt.
+	PublishContent(id, reviewerID int, publishAt *time.Time) (bool, error)
+	RejectContent(id, reviewerID int, note string) (bool, error)
+	ArchiveContent(id, userID int) (bool, error)
 }
 
+const contentColumns = `id_content, id_module, title, content, sequence, created_at, updated_at, material_id,
+	status, published_version, scheduled_version, publish_at, submitted_by, submitted_at, reviewed_by, reviewed_at, review_note`
+
+// visibleContentVersion is the version students see of the content row alias: the scheduled
+// version once it is due, the published version before that
+func visibleContentVersion(alias string) string {
+	return "COALESCE(CASE WHEN " + alias + ".publish_at <= NOW() THEN " + alias + ".scheduled_version END, " + alias + ".published_version)"
+}
+
+// The published view reads title and content from the visible version and leaves out review details
+var publishedContentQuery = `
+	SELECT c.id_content, c.id_module, v.title, v.content, c.sequence, c.created_at, v.created_at, c.material_id,
+		'published', ` + visibleContentVersion("c") + `, NULL,
+		CASE WHEN c.scheduled_version IS NOT NULL AND c.publish_at <= NOW() THEN c.publish_at END, NULL, NULL, NULL, NULL, NULL
+	FROM practicum_module_content c
+	JOIN practicum_module_content_versions v ON v.material_id = c.material_id AND v.version = ` + visibleContentVersion("c") + `
+	WHERE ` + visibleContentVersion("c") + ` IS NOT NULL`
+
 type practicumModuleContentRepository struct {
 	db *sql.DB
 }
@@ -28,7 +79,7 @@ func NewPracticumModuleContentRepository(db *sql.DB) PracticumModuleContentRepos
 	return &practicumModuleContentRepository{db: db}
 }
 
-func (r *practicumModuleContentRepository) CreateContent(content *model.PracticumModuleContent) (*model.PracticumModuleContent, error) {
+func (r *practicumModuleContentRepository) CreateContent(content *model.PracticumModuleContent, authorID int) (*model.PracticumModuleContent, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
@@ -49,54 +100,69 @@ func (r *practicumModuleContentRepository) CreateContent(content *model.Practicu
 		content.MaterialID = uuid.New()
 	}
 
+	// New content goes last, locking the module keeps concurrent creates from picking the same sequence
+	if err = lockModules(tx, content.IDModule); err != nil {
+		return nil, err
+	}
+
 	query := `
 		INSERT INTO practicum_module_content (id_module, title, content, sequence, material_id)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id_content
+		SELECT $1, $2, $3, COALESCE(MAX(sequence), 0) + 1, $4 FROM practicum_module_content WHERE id_module = $1
+		RETURNING id_content, sequence
 	`
-	err = tx.QueryRow(query, content.IDModule, content.Title, content.Content, content.Sequence, content.MaterialID).
-		Scan(&content.IDContent)
+	err = tx.QueryRow(query, content.IDModule, content.Title, content.Content, content.MaterialID).
+		Scan(&content.IDContent, &content.Sequence)
+	if err != nil {
+		return nil, err
+	}
 
-	return content, err
+	_, err = insertContentVersion(tx, content.MaterialID, content.Title, content.Content, authorID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return content, nil
 }
 
 func (r *practicumModuleContentRepository) GetContentByID(id int) (*model.PracticumModuleContent, error) {
-	var content model.PracticumModuleContent
-	err := r.db.QueryRow(
-		`SELECT id_content, id_module, title, content, sequence, created_at, updated_at, material_id
-		 FROM practicum_module_content WHERE id_content = $1`, id,
-	).Scan(&content.IDContent, &content.IDModule, &content.Title, &content.Content, &content.Sequence, &content.CreatedAt, &content.UpdatedAt, &content.MaterialID)
-	if err != nil {
-		return nil, err
+	return scanContent(r.db.QueryRow("SELECT "+contentColumns+" FROM practicum_module_content WHERE id_content = $1", id))
+}
+
+func (r *practicumModuleContentRepository) GetContentByMaterialID(materialID uuid.UUID) (*model.PracticumModuleContent, error) {
+	content, err := scanContent(r.db.QueryRow("SELECT "+contentColumns+" FROM practicum_module_content WHERE material_id = $1", materialID))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	return &content, nil
+	return content, err
+}
+
+func (r *practicumModuleContentRepository) GetPublishedContentByID(id int) (*model.PracticumModuleContent, error) {
+	content, err := scanContent(r.db.QueryRow(publishedContentQuery+" AND c.id_content = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return content, err
 }
 
-func (r *practicumModuleContentRepository) GetContentsByModuleID(moduleID, page, limit int) ([]model.PracticumModuleContent, int, error) {
+func (r *practicumModuleContentRepository) GetContentsByModuleID(moduleID int, status model.ContentStatus, page, limit int) ([]model.PracticumModuleContent, int, error) {
 	offset := (page - 1) * limit
 
 	rows, err := r.db.Query(
-		`SELECT id_content, id_module, title, content, sequence, created_at, updated_at, material_id
-		 FROM practicum_module_content WHERE id_module = $1 ORDER BY sequence LIMIT $2 OFFSET $3`,
-		moduleID, limit, offset,
+		`SELECT `+contentColumns+`
+		 FROM practicum_module_content WHERE id_module = $1 AND ($2::text = '' OR status = $2::text) ORDER BY sequence LIMIT $3 OFFSET $4`,
+		moduleID, status, limit, offset,
 	)
 	if err != nil {
 		log.Error().Err(err)
 		return nil, 0, err
 	}
-	defer rows.Close()
-
-	var contents []model.PracticumModuleContent
-	for rows.Next() {
-		var content model.PracticumModuleContent
-		if err := rows.Scan(&content.IDContent, &content.IDModule, &content.Title, &content.Content, &content.Sequence, &content.CreatedAt, &content.UpdatedAt, &content.MaterialID); err != nil {
-			return nil, 0, err
-		}
-		contents = append(contents, content)
+	contents, err := scanContents(rows)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	var total int
-	err = r.db.QueryRow("SELECT COUNT(*) FROM practicum_module_content WHERE id_module = $1", moduleID).Scan(&total)
+	err = r.db.QueryRow("SELECT COUNT(*) FROM practicum_module_content WHERE id_module = $1 AND ($2::text = '' OR status = $2::text)", moduleID, status).Scan(&total)
 	if err != nil {
 		log.Error().Err(err)
 		return nil, 0, err
@@ -105,6 +171,48 @@ func (r *practicumModuleContentRepository) GetContentsByModuleID(moduleID, page,
 	return contents, total, nil
 }
 
+func (r *practicumModuleContentRepository) GetPublishedContentsByModuleID(moduleID, page, limit int) ([]model.PracticumModuleContent, int, error) {
+	offset := (page - 1) * limit
+
+	rows, err := r.db.Query(publishedContentQuery+" AND c.id_module = $1 ORDER BY c.sequence LIMIT $2 OFFSET $3", moduleID, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Int("module_id", moduleID).Msg("Failed to fetch published practicum module contents")
+		return nil, 0, err
+	}
+	contents, err := scanContents(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = r.db.QueryRow(`
+		SELECT COUNT(*) FROM practicum_module_content c
+		WHERE c.id_module = $1 AND `+visibleContentVersion("c")+` IS NOT NULL
+	`, moduleID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return contents, total, nil
+}
+
+func (r *practicumModuleContentRepository) GetPublishedContentByIDs(ids []int) ([]model.PracticumModuleContent, error) {
+	if len(ids) == 0 {
+		return []model.PracticumModuleContent{}, nil
+	}
+
+	ids64 := make([]int64, len(ids))
+	for i, id := range ids {
+		ids64[i] = int64(id)
+	}
+	rows, err := r.db.Query(publishedContentQuery+" AND c.id_content = ANY($1::int[])", pq.Array(ids64))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch published practicum module contents")
+		return nil, err
+	}
+	return scanContents(rows)
+}
+
 func (r *practicumModuleContentRepository) GetContentByIDs(ids []int) ([]model.PracticumModuleContent, error) {
 	if len(ids) == 0 {
 		return []model.PracticumModuleContent{}, nil
@@ -118,7 +226,7 @@ func (r *practicumModuleContentRepository) GetContentByIDs(ids []int) ([]model.P
 	}
 
 	query := fmt.Sprintf(
-		`SELECT id_content, id_module, title, content, sequence, created_at, updated_at, material_id
+		`SELECT `+contentColumns+`
 		 FROM practicum_module_content WHERE id_content IN (%s)`,
 		strings.Join(placeholders, ","),
 	)
@@ -127,45 +235,544 @@ func (r *practicumModuleContentRepository) GetContentByIDs(ids []int) ([]model.P
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return scanContents(rows)
+}
 
-	var contents []model.PracticumModuleContent
-	for rows.Next() {
-		var content model.PracticumModuleContent
-		if err := rows.Scan(&content.IDContent, &content.IDModule, &content.Title, &content.Content, &content.Sequence, &content.CreatedAt, &content.UpdatedAt, &content.MaterialID); err != nil {
-			return nil, err
-		}
-		contents = append(contents, content)
+func (r *practicumModuleContentRepository) UpdateContentByID(id int, updatedContent *model.PracticumModuleContent, authorID int) (err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
 	}
-	return contents, nil
-}
 
-func (r *practicumModuleContentRepository) UpdateContentByID(id int, updatedContent *model.PracticumModuleContent) error {
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	// The edit becomes a new draft, students keep the published version until it is published.
+	// The row lock taken by the update also keeps concurrent edits from picking the same version number.
 	query := `
 		UPDATE practicum_module_content
 		SET title = $1,
 			content = $2,
 			sequence = $3,
 			material_id = $4,
+			status = 'draft',
+			review_note = NULL,
 			updated_at = NOW()
 		WHERE id_content = $5
+		RETURNING material_id
 	`
 
-	_, err := r.db.Exec(query, updatedContent.Title, updatedContent.Content, updatedContent.Sequence, updatedContent.MaterialID, id)
+	var materialID uuid.UUID
+	err = tx.QueryRow(query, updatedContent.Title, updatedContent.Content, updatedContent.Sequence, updatedContent.MaterialID, id).Scan(&materialID)
 	if err != nil {
 		log.Error().Err(err).Msgf("Failed to update practicum module content with ID %d", id)
 		return err
 	}
 
+	_, err = insertContentVersion(tx, materialID, updatedContent.Title, updatedContent.Content, authorID, nil)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to record version of practicum module content with ID %d", id)
+		return err
+	}
+
 	return nil
 }
 
-func (r *practicumModuleContentRepository) DeleteContentByID(id int) error {
-	query := `DELETE FROM practicum_module_content WHERE id_content = $1`
-	_, err := r.db.Exec(query, id)
+// DeleteContentByID removes the content and closes the gap it leaves in the module. Its versions
+// are kept and remember the module, so it can be restored.
+func (r *practicumModuleContentRepository) DeleteContentByID(id int) (err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var moduleID, sequence int
+	var materialID uuid.UUID
+	err = tx.QueryRow(`DELETE FROM practicum_module_content WHERE id_content = $1 RETURNING id_module, sequence, material_id`, id).Scan(&moduleID, &sequence, &materialID)
+	if err == sql.ErrNoRows {
+		err = nil
+		return nil
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("failed to delete practicum module content")
 		return err
 	}
+
+	_, err = tx.Exec("UPDATE practicum_module_content_versions SET id_module = $2 WHERE material_id = $1", materialID, moduleID)
+	if err != nil {
+		log.Error().Err(err).Str("material_id", materialID.String()).Msg("Failed to keep the module of deleted practicum module content")
+		return err
+	}
+
+	if _, err = tx.Exec("SET CONSTRAINTS unique_module_content_sequence DEFERRED"); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE practicum_module_content SET sequence = sequence - 1 WHERE id_module = $1 AND sequence > $2", moduleID, sequence)
+	if err != nil {
+		log.Error().Err(err).Int("module_id", moduleID).Msg("Failed to renumber practicum module contents")
+		return err
+	}
+	return nil
+}
+
+func (r *practicumModuleContentRepository) ReorderContents(moduleID int, contentIDs []int) (reordered bool, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil || !reordered {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if err = lockModules(tx, moduleID); err != nil {
+		return false, err
+	}
+
+	// Every content has to be listed once, so the new order has no gaps or duplicates
+	var matched bool
+	err = tx.QueryRow(`
+		SELECT COALESCE(array_agg(id_content ORDER BY id_content), '{}') = (SELECT COALESCE(array_agg(DISTINCT x ORDER BY x), '{}') FROM unnest($2::int[]) x)
+			AND COUNT(*) = cardinality($2::int[])
+		FROM practicum_module_content WHERE id_module = $1
+	`, moduleID, pq.Array(contentIDs)).Scan(&matched)
+	if err != nil {
+		log.Error().Err(err).Int("module_id", moduleID).Msg("Failed to check practicum module content order")
+		return false, err
+	}
+	if !matched {
+		return false, nil
+	}
+
+	if _, err = tx.Exec("SET CONSTRAINTS unique_module_content_sequence DEFERRED"); err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(`
+		UPDATE practicum_module_content c
+		SET sequence = o.position, updated_at = NOW()
+		FROM unnest($2::int[]) WITH ORDINALITY AS o(id, position)
+		WHERE c.id_content = o.id AND c.id_module = $1 AND c.sequence <> o.position
+	`, moduleID, pq.Array(contentIDs))
+	if err != nil {
+		log.Error().Err(err).Int("module_id", moduleID).Msg("Failed to reorder practicum module contents")
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *practicumModuleContentRepository) MoveContent(id, moduleID, position int) (moved bool, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil || !moved {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var sourceModuleID, sequence int
+	err = tx.QueryRow("SELECT id_module, sequence FROM practicum_module_content WHERE id_content = $1", id).Scan(&sourceModuleID, &sequence)
+	if err == sql.ErrNoRows {
+		err = nil
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err = lockModules(tx, sourceModuleID, moduleID); err != nil {
+		return false, err
+	}
+	// Re-read under the lock, the content may have moved in the meantime
+	err = tx.QueryRow("SELECT id_module, sequence FROM practicum_module_content WHERE id_content = $1", id).Scan(&sourceModuleID, &sequence)
+	if err == sql.ErrNoRows {
+		err = nil
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err = tx.Exec("SET CONSTRAINTS unique_module_content_sequence DEFERRED"); err != nil {
+		return false, err
+	}
+
+	// Take the content out of its module, then make room for it at the new position
+	_, err = tx.Exec("UPDATE practicum_module_content SET sequence = sequence - 1 WHERE id_module = $1 AND sequence > $2", sourceModuleID, sequence)
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	err = tx.QueryRow("SELECT COUNT(*) FROM practicum_module_content WHERE id_module = $1 AND id_content <> $2", moduleID, id).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	if position < 1 || position > count+1 {
+		position = count + 1
+	}
+
+	_, err = tx.Exec("UPDATE practicum_module_content SET sequence = sequence + 1 WHERE id_module = $1 AND sequence >= $2 AND id_content <> $3", moduleID, position, id)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec("UPDATE practicum_module_content SET id_module = $1, sequence = $2, updated_at = NOW() WHERE id_content = $3", moduleID, position, id)
+	if err != nil {
+		log.Error().Err(err).Int("content_id", id).Msg("Failed to move practicum module content")
+		return false, err
+	}
+
+	// Checkpoints point at the module as well, keep them on the content's new module
+	_, err = tx.Exec("UPDATE user_practicum_checkpoint SET id_module = $1, updated_at = NOW() WHERE id_content = $2", moduleID, id)
+	if err != nil {
+		log.Error().Err(err).Int("content_id", id).Msg("Failed to move checkpoints with practicum module content")
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *practicumModuleContentRepository) GetContentVersions(materialID uuid.UUID) ([]model.PracticumModuleContentVersion, error) {
+	rows, err := r.db.Query(`
+		SELECT id, material_id, version, title, id_author, restored_from, created_at
+		FROM practicum_module_content_versions WHERE material_id = $1
+		ORDER BY version DESC
+	`, materialID)
+	if err != nil {
+		log.Error().Err(err).Str("material_id", materialID.String()).Msg("Failed to fetch practicum module content versions")
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []model.PracticumModuleContentVersion{}
+	for rows.Next() {
+		var version model.PracticumModuleContentVersion
+		var authorID, restoredFrom sql.NullInt64
+		if err := rows.Scan(&version.ID, &version.MaterialID, &version.Version, &version.Title, &authorID, &restoredFrom, &version.CreatedAt); err != nil {
+			return nil, err
+		}
+		version.AuthorID = nullIntPtr(authorID)
+		version.RestoredFrom = nullIntPtr(restoredFrom)
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+func (r *practicumModuleContentRepository) GetContentVersion(materialID uuid.UUID, version int) (*model.PracticumModuleContentVersion, error) {
+	var v model.PracticumModuleContentVersion
+	var authorID, restoredFrom sql.NullInt64
+	err := r.db.QueryRow(`
+		SELECT id, material_id, version, title, content, id_author, restored_from, created_at
+		FROM practicum_module_content_versions WHERE material_id = $1 AND version = $2
+	`, materialID, version).Scan(&v.ID, &v.MaterialID, &v.Version, &v.Title, &v.Content, &authorID, &restoredFrom, &v.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	v.AuthorID = nullIntPtr(authorID)
+	v.RestoredFrom = nullIntPtr(restoredFrom)
+	return &v, nil
+}
+
+func (r *practicumModuleContentRepository) RestoreContentVersion(materialID uuid.UUID, version, authorID int) (restored *model.PracticumModuleContentVersion, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil || restored == nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM practicum_module_content WHERE material_id = $1)", materialID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return restoreDeletedContent(tx, materialID, version, authorID)
+	}
+
+	// Copying the old version over the live row locks it, the same as a regular update
+	var title string
+	var content []byte
+	err = tx.QueryRow(`
+		UPDATE practicum_module_content c
+		SET title = v.title, content = v.content, status = 'draft', review_note = NULL, updated_at = NOW()
+		FROM practicum_module_content_versions v
+		WHERE v.material_id = c.material_id AND c.material_id = $1 AND v.version = $2
+		RETURNING c.title, c.content
+	`, materialID, version).Scan(&title, &content)
+	if err == sql.ErrNoRows {
+		err = nil
+		return nil, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Str("material_id", materialID.String()).Int("version", version).Msg("Failed to restore practicum module content version")
+		return nil, err
+	}
+
+	return insertContentVersion(tx, materialID, title, content, authorID, &version)
+}
+
+func (r *practicumModuleContentRepository) ContentVersionsExist(materialID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM practicum_module_content_versions WHERE material_id = $1)", materialID).Scan(&exists)
+	if err != nil {
+		log.Error().Err(err).Str("material_id", materialID.String()).Msg("Failed to check practicum module content versions")
+		return false, err
+	}
+	return exists, nil
+}
+
+// restoreDeletedContent recreates deleted content from one of its versions as a draft at the end
+// of the module it was deleted from
+func restoreDeletedContent(tx *sql.Tx, materialID uuid.UUID, version, authorID int) (*model.PracticumModuleContentVersion, error) {
+	var title string
+	var content []byte
+	var moduleID sql.NullInt64
+	err := tx.QueryRow(`
+		SELECT title, content, id_module
+		FROM practicum_module_content_versions WHERE material_id = $1 AND version = $2
+	`, materialID, version).Scan(&title, &content, &moduleID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Content deleted with its module has nowhere to go back to
+	if !moduleID.Valid {
+		return nil, sql.ErrNoRows
+	}
+
+	if err := lockModules(tx, int(moduleID.Int64)); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO practicum_module_content (id_module, title, content, sequence, material_id)
+		SELECT $1, $2, $3, COALESCE(MAX(sequence), 0) + 1, $4 FROM practicum_module_content WHERE id_module = $1
+	`, moduleID.Int64, title, content, materialID)
+	if err != nil {
+		log.Error().Err(err).Str("material_id", materialID.String()).Msg("Failed to recreate deleted practicum module content")
+		return nil, err
+	}
+
+	return insertContentVersion(tx, materialID, title, content, authorID, &version)
+}
+
+func (r *practicumModuleContentRepository) SubmitContent(id, userID int) (bool, error) {
+	return r.execStatusChange(`
+		UPDATE practicum_module_content
+		SET status = 'in_review', submitted_by = $2, submitted_at = NOW(), review_note = NULL
+		WHERE id_content = $1 AND status = 'draft'
+	`, id, nullableUserID(userID))
+}
+
+func (r *practicumModuleContentRepository) PublishContent(id, reviewerID int, publishAt *time.Time) (bool, error) {
+	// A schedule keeps the version students see now, a due schedule counts as published by then
+	return r.execStatusChange(`
+		UPDATE practicum_module_content c
+		SET status = 'published',
+			published_version = CASE WHEN $3::timestamptz IS NULL
+				THEN (SELECT MAX(v.version) FROM practicum_module_content_versions v WHERE v.material_id = c.material_id)
+				ELSE `+visibleContentVersion("c")+` END,
+			scheduled_version = CASE WHEN $3::timestamptz IS NULL THEN NULL
+				ELSE (SELECT MAX(v.version) FROM practicum_module_content_versions v WHERE v.material_id = c.material_id) END,
+			publish_at = $3,
+			reviewed_by = $2,
+			reviewed_at = NOW(),
+			review_note = NULL
+		WHERE c.id_content = $1 AND c.status IN ('draft', 'in_review')
+	`, id, nullableUserID(reviewerID), publishAt)
+}
+
+func (r *practicumModuleContentRepository) RejectContent(id, reviewerID int, note string) (bool, error) {
+	return r.execStatusChange(`
+		UPDATE practicum_module_content
+		SET status = 'draft', reviewed_by = $2, reviewed_at = NOW(), review_note = NULLIF($3, '')
+		WHERE id_content = $1 AND status = 'in_review'
+	`, id, nullableUserID(reviewerID), note)
+}
+
+// ArchiveContent hides the content from students, editing it afterwards starts a new draft
+func (r *practicumModuleContentRepository) ArchiveContent(id, userID int) (bool, error) {
+	return r.execStatusChange(`
+		UPDATE practicum_module_content
+		SET status = 'archived', published_version = NULL, scheduled_version = NULL, publish_at = NULL, reviewed_by = $2, reviewed_at = NOW()
+		WHERE id_content = $1 AND status <> 'archived'
+	`, id, nullableUserID(userID))
+}
+
+func (r *practicumModuleContentRepository) execStatusChange(query string, args ...interface{}) (bool, error) {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to change practicum module content status")
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// insertContentVersion records the content as the next version of the material. The caller holds
+// the lock on the content row, so the version numbers can't collide.
+func insertContentVersion(tx *sql.Tx, materialID uuid.UUID, title string, content []byte, authorID int, restoredFrom *int) (*model.PracticumModuleContentVersion, error) {
+	author := nullableUserID(authorID)
+	var from sql.NullInt64
+	if restoredFrom != nil {
+		from = sql.NullInt64{Int64: int64(*restoredFrom), Valid: true}
+	}
+
+	version := model.PracticumModuleContentVersion{
+		MaterialID:   materialID,
+		Title:        title,
+		Content:      content,
+		AuthorID:     nullIntPtr(author),
+		RestoredFrom: restoredFrom,
+	}
+	err := tx.QueryRow(`
+		INSERT INTO practicum_module_content_versions (material_id, version, title, content, id_author, restored_from)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5
+		FROM practicum_module_content_versions WHERE material_id = $1
+		RETURNING id, version, created_at
+	`, materialID, title, content, author, from).Scan(&version.ID, &version.Version, &version.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+func scanContent(row rowScanner) (*model.PracticumModuleContent, error) {
+	var content model.PracticumModuleContent
+	var publishedVersion, scheduledVersion, submittedBy, reviewedBy sql.NullInt64
+	var publishAt, submittedAt, reviewedAt sql.NullTime
+	var reviewNote sql.NullString
+
+	err := row.Scan(&content.IDContent, &content.IDModule, &content.Title, &content.Content, &content.Sequence, &content.CreatedAt, &content.UpdatedAt, &content.MaterialID,
+		&content.Status, &publishedVersion, &scheduledVersion, &publishAt, &submittedBy, &submittedAt, &reviewedBy, &reviewedAt, &reviewNote)
+	if err != nil {
+		return nil, err
+	}
+
+	content.PublishedVersion = nullIntPtr(publishedVersion)
+	content.ScheduledVersion = nullIntPtr(scheduledVersion)
+	content.PublishAt = nullTimePtr(publishAt)
+	content.SubmittedBy = nullIntPtr(submittedBy)
+	content.SubmittedAt = nullTimePtr(submittedAt)
+	content.ReviewedBy = nullIntPtr(reviewedBy)
+	content.ReviewedAt = nullTimePtr(reviewedAt)
+	content.ReviewNote = reviewNote.String
+	return &content, nil
+}
+
+func scanContents(rows *sql.Rows) ([]model.PracticumModuleContent, error) {
+	defer rows.Close()
+
+	var contents []model.PracticumModuleContent
+	for rows.Next() {
+		content, err := scanContent(rows)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, *content)
+	}
+	return contents, rows.Err()
+}
+
+// nullableUserID stores 0 as NULL, for users that aren't known
+func nullableUserID(id int) sql.NullInt64 {
+	if id == 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(id), Valid: true}
+}
+
+func nullTimePtr(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+func nullIntPtr(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	v := int(value.Int64)
+	return &v
+}
+
+// lockModules serializes changes to the order of the modules' contents, locking in ID order to avoid deadlocks
+func lockModules(tx *sql.Tx, moduleIDs ...int) error {
+	rows, err := tx.Query("SELECT id FROM practicum_modules WHERE id = ANY($1) ORDER BY id FOR UPDATE", pq.Array(moduleIDs))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to lock practicum modules")
+		return err
+	}
+	defer rows.Close()
+
+	locked := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		locked[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range moduleIDs {
+		if !locked[id] {
+			return sql.ErrNoRows
+		}
+	}
 	return nil
 }
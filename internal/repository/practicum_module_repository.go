@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 )
 
@@ -15,6 +16,13 @@ type PracticumModuleRepository interface {
 	GetModuleByID(id int) (*model.PracticumModule, error)
 	GetModuleByIDs(ids []int) ([]model.PracticumModule, error)
 	GetModulesByPracticumID(practicumID, page, limit int) ([]model.PracticumModule, int, error)
+	UpdateModule(module *model.PracticumModule) (bool, error)
+	// DeleteModule reports false when the module doesn't exist or students have checkpoints in it
+	DeleteModule(id int) (bool, error)
+	// ModuleInUse reports whether a student has a checkpoint in the module or its contents
+	ModuleInUse(id int) (bool, error)
+	// ReorderModules reports false when moduleIDs isn't exactly the set of modules of the practicum
+	ReorderModules(practicumID int, moduleIDs []int) (bool, error)
 }
 
 type practicumModuleRepository struct {
@@ -42,12 +50,17 @@ func (r *practicumModuleRepository) CreateModule(module *model.PracticumModule)
 		}
 	}()
 
+	// Locking the practicum keeps concurrent creates from picking the same sequence
+	if err = lockPracticum(tx, module.PracticumID); err != nil {
+		return nil, err
+	}
+
 	query := `
-        INSERT INTO practicum_modules (title, practicum_id)
-        VALUES ($1, $2)
-        RETURNING id
+        INSERT INTO practicum_modules (title, practicum_id, sequence)
+        SELECT $1, $2, COALESCE(MAX(sequence), 0) + 1 FROM practicum_modules WHERE practicum_id = $2
+        RETURNING id, sequence
     `
-	err = tx.QueryRow(query, module.Title, module.PracticumID).Scan(&module.ID)
+	err = tx.QueryRow(query, module.Title, module.PracticumID).Scan(&module.ID, &module.Sequence)
 	if err != nil {
 		return nil, err
 	}
@@ -58,8 +71,8 @@ func (r *practicumModuleRepository) CreateModule(module *model.PracticumModule)
 func (r *practicumModuleRepository) GetModuleByID(id int) (*model.PracticumModule, error) {
 	var module model.PracticumModule
 	err := r.db.QueryRow(
-		"SELECT id, title, practicum_id, created_at, updated_at FROM practicum_modules WHERE id = $1", id,
-	).Scan(&module.ID, &module.Title, &module.PracticumID, &module.CreatedAt, &module.UpdatedAt)
+		"SELECT id, title, sequence, practicum_id, created_at, updated_at FROM practicum_modules WHERE id = $1", id,
+	).Scan(&module.ID, &module.Title, &module.Sequence, &module.PracticumID, &module.CreatedAt, &module.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +83,7 @@ func (r *practicumModuleRepository) GetModulesByPracticumID(practicumID, page, l
 	offset := (page - 1) * limit
 
 	rows, err := r.db.Query(
-		"SELECT id, title, practicum_id, created_at, updated_at FROM practicum_modules WHERE practicum_id = $1 LIMIT $2 OFFSET $3",
+		"SELECT id, title, sequence, practicum_id, created_at, updated_at FROM practicum_modules WHERE practicum_id = $1 ORDER BY sequence, id LIMIT $2 OFFSET $3",
 		practicumID, limit, offset,
 	)
 	if err != nil {
@@ -82,7 +95,7 @@ func (r *practicumModuleRepository) GetModulesByPracticumID(practicumID, page, l
 	var modules []model.PracticumModule
 	for rows.Next() {
 		var module model.PracticumModule
-		if err := rows.Scan(&module.ID, &module.Title, &module.PracticumID, &module.CreatedAt, &module.UpdatedAt); err != nil {
+		if err := rows.Scan(&module.ID, &module.Title, &module.Sequence, &module.PracticumID, &module.CreatedAt, &module.UpdatedAt); err != nil {
 			return nil, 0, err
 		}
 		modules = append(modules, module)
@@ -109,7 +122,7 @@ func (r *practicumModuleRepository) GetModuleByIDs(ids []int) ([]model.Practicum
 	}
 
 	inClause := strings.Join(placeholders, ",")
-	query := fmt.Sprintf("SELECT id, title, created_at, updated_at FROM practicum_modules WHERE id IN (%s)", inClause)
+	query := fmt.Sprintf("SELECT id, title, sequence, created_at, updated_at FROM practicum_modules WHERE id IN (%s)", inClause)
 
 	args := make([]interface{}, len(ids))
 	for i, id := range ids {
@@ -125,7 +138,7 @@ func (r *practicumModuleRepository) GetModuleByIDs(ids []int) ([]model.Practicum
 	practicumModules := []model.PracticumModule{}
 	for rows.Next() {
 		practicumModule := model.PracticumModule{}
-		if err := rows.Scan(&practicumModule.ID, &practicumModule.Title, &practicumModule.CreatedAt, &practicumModule.UpdatedAt); err != nil {
+		if err := rows.Scan(&practicumModule.ID, &practicumModule.Title, &practicumModule.Sequence, &practicumModule.CreatedAt, &practicumModule.UpdatedAt); err != nil {
 			return nil, err
 		}
 		practicumModules = append(practicumModules, practicumModule)
@@ -133,3 +146,146 @@ func (r *practicumModuleRepository) GetModuleByIDs(ids []int) ([]model.Practicum
 
 	return practicumModules, nil
 }
+
+// UpdateModule renames a module, its practicum and position are left alone
+func (r *practicumModuleRepository) UpdateModule(module *model.PracticumModule) (bool, error) {
+	query := `
+		UPDATE practicum_modules
+		SET title = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING sequence, practicum_id, created_at, updated_at
+	`
+	err := r.db.QueryRow(query, module.Title, module.ID).
+		Scan(&module.Sequence, &module.PracticumID, &module.CreatedAt, &module.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Int("module_id", module.ID).Msg("Failed to update practicum module")
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteModule removes the module together with its contents and closes the gap it leaves in
+// the order. Modules students have progress in are kept, deleting them would wipe the progress.
+func (r *practicumModuleRepository) DeleteModule(id int) (deleted bool, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var practicumID, sequence int
+	err = tx.QueryRow(`
+		DELETE FROM practicum_modules
+		WHERE id = $1 AND NOT EXISTS (`+moduleCheckpointsQuery+`)
+		RETURNING practicum_id, sequence
+	`, id).Scan(&practicumID, &sequence)
+	if err == sql.ErrNoRows {
+		err = nil
+		return false, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Int("module_id", id).Msg("Failed to delete practicum module")
+		return false, err
+	}
+
+	if _, err = tx.Exec("SET CONSTRAINTS unique_practicum_module_sequence DEFERRED"); err != nil {
+		return false, err
+	}
+	_, err = tx.Exec("UPDATE practicum_modules SET sequence = sequence - 1 WHERE practicum_id = $1 AND sequence > $2", practicumID, sequence)
+	if err != nil {
+		log.Error().Err(err).Int("practicum_id", practicumID).Msg("Failed to renumber practicum modules")
+		return false, err
+	}
+	return true, nil
+}
+
+// moduleCheckpointsQuery selects the checkpoints in the module $1 or any of its contents
+const moduleCheckpointsQuery = `
+	SELECT 1 FROM user_practicum_checkpoint
+	WHERE id_module = $1
+		OR id_content IN (SELECT id_content FROM practicum_module_content WHERE id_module = $1)`
+
+func (r *practicumModuleRepository) ModuleInUse(id int) (bool, error) {
+	var inUse bool
+	err := r.db.QueryRow("SELECT EXISTS ("+moduleCheckpointsQuery+")", id).Scan(&inUse)
+	if err != nil {
+		log.Error().Err(err).Int("module_id", id).Msg("Failed to check module checkpoints")
+		return false, err
+	}
+	return inUse, nil
+}
+
+func (r *practicumModuleRepository) ReorderModules(practicumID int, moduleIDs []int) (reordered bool, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil || !reordered {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if err = lockPracticum(tx, practicumID); err != nil {
+		return false, err
+	}
+
+	// Every module has to be listed once, so the new order has no gaps or duplicates
+	var matched bool
+	err = tx.QueryRow(`
+		SELECT COALESCE(array_agg(id ORDER BY id), '{}') = (SELECT COALESCE(array_agg(DISTINCT x ORDER BY x), '{}') FROM unnest($2::int[]) x)
+			AND COUNT(*) = cardinality($2::int[])
+		FROM practicum_modules WHERE practicum_id = $1
+	`, practicumID, pq.Array(moduleIDs)).Scan(&matched)
+	if err != nil {
+		log.Error().Err(err).Int("practicum_id", practicumID).Msg("Failed to check practicum module order")
+		return false, err
+	}
+	if !matched {
+		return false, nil
+	}
+
+	if _, err = tx.Exec("SET CONSTRAINTS unique_practicum_module_sequence DEFERRED"); err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(`
+		UPDATE practicum_modules m
+		SET sequence = o.position, updated_at = NOW()
+		FROM unnest($2::int[]) WITH ORDINALITY AS o(id, position)
+		WHERE m.id = o.id AND m.practicum_id = $1 AND m.sequence <> o.position
+	`, practicumID, pq.Array(moduleIDs))
+	if err != nil {
+		log.Error().Err(err).Int("practicum_id", practicumID).Msg("Failed to reorder practicum modules")
+		return false, err
+	}
+	return true, nil
+}
+
+// lockPracticum serializes changes to the order of a practicum's modules
+func lockPracticum(tx *sql.Tx, practicumID int) error {
+	var id int
+	err := tx.QueryRow("SELECT id_practicum FROM practicums WHERE id_practicum = $1 FOR UPDATE", practicumID).Scan(&id)
+	if err != nil {
+		log.Error().Err(err).Int("practicum_id", practicumID).Msg("Failed to lock practicum")
+	}
+	return err
+}
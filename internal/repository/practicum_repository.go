@@ -1,12 +1,15 @@
 package repository
 
 import (
+	"bytes"
 	"database/sql"
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
@@ -14,8 +17,17 @@ type PracticumRepository interface {
 	CreatePracticum(practicum *model.Practicum) error
 	GetPracticumByID(id int) (*model.Practicum, error)
 	GetPracticumByIDs(ids []int) ([]model.Practicum, error)
-	GetAllPracticums(page, limit int) ([]model.Practicum, int, error)
-	GetPracticumWithMaterialContents(id int) (*model.PracticumWithMaterial, error)
+	GetAllPracticums(filter model.PracticumFilter, page, limit int) ([]model.Practicum, int, error)
+	// GetPracticumWithMaterialContents lists the modules and their materials, publishedOnly leaves out
+	// materials students can't see yet
+	GetPracticumWithMaterialContents(id int, publishedOnly bool) (*model.PracticumWithMaterial, error)
+	GetPracticumByName(name string, academicTermID *int) (*model.Practicum, error)
+	GetDeletedPracticumByID(id int) (*model.Practicum, error)
+	UpdatePracticum(practicum *model.Practicum) (bool, error)
+	SoftDeletePracticum(id int) (bool, error)
+	RestorePracticum(id int) (bool, error)
+	// ClonePracticum inserts target and copies the modules, contents and optionally the classes of sourceID into it
+	ClonePracticum(sourceID int, target *model.Practicum, includeClasses bool) (*model.PracticumCloneResult, error)
 }
 
 type practicumRepository struct {
@@ -44,12 +56,12 @@ func (r *practicumRepository) CreatePracticum(practicum *model.Practicum) error
 	}()
 
 	query := `
-        INSERT INTO practicums (name, code, description, credits, semester)
-        VALUES ($1, $2, $3, $4, $5)
+        INSERT INTO practicums (name, code, description, credits, semester, academic_term_id)
+        VALUES ($1, $2, $3, $4, $5, $6)
         RETURNING id_practicum
     `
 
-	_, err = tx.Exec(query, practicum.Name, practicum.Code, practicum.Description, practicum.Credits, practicum.Semester)
+	_, err = tx.Exec(query, practicum.Name, practicum.Code, practicum.Description, practicum.Credits, practicum.Semester, practicum.AcademicTermID)
 	if err != nil {
 		return err
 	}
@@ -59,47 +71,128 @@ func (r *practicumRepository) CreatePracticum(practicum *model.Practicum) error
 
 func (r *practicumRepository) GetPracticumByID(id int) (*model.Practicum, error) {
 	var practicum model.Practicum
-	err := r.db.QueryRow("SELECT id_practicum, name, code, description, credits, semester, created_at, updated_at FROM practicums WHERE id_practicum = $1", id).
-		Scan(&practicum.ID, &practicum.Name, &practicum.Code, &practicum.Description, &practicum.Credits, &practicum.Semester, &practicum.CreatedAt, &practicum.UpdatedAt)
+	err := r.db.QueryRow("SELECT id_practicum, name, code, description, credits, semester, academic_term_id, created_at, updated_at FROM practicums WHERE id_practicum = $1 AND deleted_at IS NULL", id).
+		Scan(&practicum.ID, &practicum.Name, &practicum.Code, &practicum.Description, &practicum.Credits, &practicum.Semester, &practicum.AcademicTermID, &practicum.CreatedAt, &practicum.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return &practicum, nil
 }
 
-func (r *practicumRepository) GetAllPracticums(page, limit int) ([]model.Practicum, int, error) {
+// practicumSortColumns whitelists the columns the catalogue can be sorted by
+var practicumSortColumns = map[model.PracticumSortField]string{
+	model.PracticumSortName:      "name",
+	model.PracticumSortCode:      "code",
+	model.PracticumSortCredits:   "credits",
+	model.PracticumSortSemester:  "semester",
+	model.PracticumSortCreatedAt: "created_at",
+	model.PracticumSortUpdatedAt: "updated_at",
+}
+
+func (r *practicumRepository) GetAllPracticums(filter model.PracticumFilter, page, limit int) ([]model.Practicum, int, error) {
 	offset := (page - 1) * limit
 
-	rows, err := r.db.Query(
-		"SELECT id_practicum, name, code, description, credits, semester, created_at, updated_at FROM practicums LIMIT $1 OFFSET $2",
-		limit, offset,
+	conditions := []string{"deleted_at IS NULL"}
+	args := []interface{}{}
+	addArg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	tsQuery := prefixTSQuery(filter.Search)
+	queryArg := ""
+	if tsQuery != "" {
+		queryArg = addArg(tsQuery)
+		conditions = append(conditions, "search_vector @@ to_tsquery('simple', "+queryArg+")")
+	}
+	if filter.AcademicTermID > 0 {
+		termCondition := "academic_term_id = " + addArg(filter.AcademicTermID)
+		if filter.WithoutTerm {
+			termCondition = "(" + termCondition + " OR academic_term_id IS NULL)"
+		}
+		conditions = append(conditions, termCondition)
+	}
+	if filter.Semester != "" {
+		conditions = append(conditions, "semester = "+addArg(filter.Semester))
+	}
+	if filter.Credits > 0 {
+		conditions = append(conditions, "credits = "+addArg(filter.Credits))
+	}
+	if filter.MinCredits > 0 {
+		conditions = append(conditions, "credits >= "+addArg(filter.MinCredits))
+	}
+	if filter.MaxCredits > 0 {
+		conditions = append(conditions, "credits <= "+addArg(filter.MaxCredits))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	direction := "ASC"
+	if filter.SortDesc {
+		direction = "DESC"
+	}
+
+	// id_practicum breaks ties so pages don't overlap or skip rows
+	orderBy := "id_practicum " + direction
+	switch column, ok := practicumSortColumns[filter.SortBy]; {
+	case ok:
+		orderBy = column + " " + direction + ", id_practicum " + direction
+	case (filter.SortBy == model.PracticumSortRelevance || filter.SortBy == "") && queryArg != "":
+		// Best match first unless the caller asked for the reverse
+		rankDirection := "DESC"
+		if filter.SortBy == model.PracticumSortRelevance && filter.SortDesc {
+			rankDirection = "ASC"
+		}
+		orderBy = "ts_rank(search_vector, to_tsquery('simple', " + queryArg + ")) " + rankDirection + ", id_practicum ASC"
+	}
+
+	query := fmt.Sprintf(
+		"SELECT id_practicum, name, code, description, credits, semester, academic_term_id, created_at, updated_at FROM practicums WHERE %s ORDER BY %s LIMIT %s OFFSET %s",
+		where, orderBy, addArg(limit), addArg(offset),
 	)
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		log.Error().Err(err)
+		log.Error().Err(err).Msg("Failed to fetch practicums")
 		return nil, 0, err
 	}
 
 	defer rows.Close()
 
-	var practicums []model.Practicum
+	practicums := []model.Practicum{}
 	for rows.Next() {
 		var practicum model.Practicum
-		if err := rows.Scan(&practicum.ID, &practicum.Name, &practicum.Code, &practicum.Description, &practicum.Credits, &practicum.Semester, &practicum.CreatedAt, &practicum.UpdatedAt); err != nil {
+		if err := rows.Scan(&practicum.ID, &practicum.Name, &practicum.Code, &practicum.Description, &practicum.Credits, &practicum.Semester, &practicum.AcademicTermID, &practicum.CreatedAt, &practicum.UpdatedAt); err != nil {
 			return nil, 0, err
 		}
 		practicums = append(practicums, practicum)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
 
 	var total int
-	err = r.db.QueryRow("SELECT COUNT(*) FROM practicums").Scan(&total)
+	// The count shares the filter arguments, limit and offset are the last two
+	err = r.db.QueryRow("SELECT COUNT(*) FROM practicums WHERE "+where, args[:len(args)-2]...).Scan(&total)
 	if err != nil {
-		log.Error().Err(err)
+		log.Error().Err(err).Msg("Failed to count practicums")
 		return nil, 0, err
 	}
 
 	return practicums, total, nil
 }
 
+// prefixTSQuery turns free text into a tsquery where every word has to match as a prefix,
+// so "pem web" finds "Pemrograman Web". Anything but letters and digits is dropped.
+func prefixTSQuery(search string) string {
+	words := strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
 func (r *practicumRepository) GetPracticumByIDs(ids []int) ([]model.Practicum, error) {
 	if len(ids) == 0 {
 		return []model.Practicum{}, nil
@@ -111,7 +204,7 @@ func (r *practicumRepository) GetPracticumByIDs(ids []int) ([]model.Practicum, e
 	}
 
 	inClause := strings.Join(placeholders, ",")
-	query := fmt.Sprintf("SELECT id_practicum, name, code, description, credits, semester, created_at, updated_at FROM practicums WHERE id_practicum IN (%s)", inClause)
+	query := fmt.Sprintf("SELECT id_practicum, name, code, description, credits, semester, academic_term_id, created_at, updated_at FROM practicums WHERE id_practicum IN (%s) AND deleted_at IS NULL", inClause)
 
 	args := make([]interface{}, len(ids))
 	for i, id := range ids {
@@ -127,7 +220,7 @@ func (r *practicumRepository) GetPracticumByIDs(ids []int) ([]model.Practicum, e
 	practicums := []model.Practicum{}
 	for rows.Next() {
 		practicum := model.Practicum{}
-		if err := rows.Scan(&practicum.ID, &practicum.Name, &practicum.Code, &practicum.Description, &practicum.Credits, &practicum.Semester, &practicum.CreatedAt, &practicum.UpdatedAt); err != nil {
+		if err := rows.Scan(&practicum.ID, &practicum.Name, &practicum.Code, &practicum.Description, &practicum.Credits, &practicum.Semester, &practicum.AcademicTermID, &practicum.CreatedAt, &practicum.UpdatedAt); err != nil {
 			return nil, err
 		}
 		practicums = append(practicums, practicum)
@@ -136,20 +229,24 @@ func (r *practicumRepository) GetPracticumByIDs(ids []int) ([]model.Practicum, e
 	return practicums, nil
 }
 
-func (r *practicumRepository) GetPracticumWithMaterialContents(id int) (*model.PracticumWithMaterial, error) {
+func (r *practicumRepository) GetPracticumWithMaterialContents(id int, publishedOnly bool) (*model.PracticumWithMaterial, error) {
+	// Students get the titles of the published versions, staff the latest revisions with their status
 	query := `
 		SELECT 
 			p.id_practicum, p.name, p.code, p.description, p.credits, p.semester,
 			pm.id, pm.title,
-			pmc.id_content, pmc.title
+			pmc.id_content, COALESCE(pv.title, pmc.title), CASE WHEN NOT $2 THEN pmc.status END
 		FROM practicums p
 		LEFT JOIN practicum_modules pm ON pm.practicum_id = p.id_practicum
 		LEFT JOIN practicum_module_content pmc ON pmc.id_module = pm.id
-		WHERE p.id_practicum = $1
-		ORDER BY pm.id, pmc.sequence
+			AND (NOT $2 OR ` + visibleContentVersion("pmc") + ` IS NOT NULL)
+		LEFT JOIN practicum_module_content_versions pv ON $2
+			AND pv.material_id = pmc.material_id AND pv.version = ` + visibleContentVersion("pmc") + `
+		WHERE p.id_practicum = $1 AND p.deleted_at IS NULL
+		ORDER BY pm.sequence, pm.id, pmc.sequence
 	`
 
-	rows, err := r.db.Query(query, id)
+	rows, err := r.db.Query(query, id, publishedOnly)
 	if err != nil {
 		return nil, err
 	}
@@ -163,13 +260,13 @@ func (r *practicumRepository) GetPracticumWithMaterialContents(id int) (*model.P
 			practicumID                                  uint
 			practicumName, code, desc, credits, semester string
 			moduleID, contentID                          sql.NullInt64
-			moduleTitle, contentTitle                    sql.NullString
+			moduleTitle, contentTitle, contentStatus     sql.NullString
 		)
 
 		if err := rows.Scan(
 			&practicumID, &practicumName, &code, &desc, &credits, &semester,
 			&moduleID, &moduleTitle,
-			&contentID, &contentTitle,
+			&contentID, &contentTitle, &contentStatus,
 		); err != nil {
 			return nil, err
 		}
@@ -199,8 +296,9 @@ func (r *practicumRepository) GetPracticumWithMaterialContents(id int) (*model.P
 
 			if contentID.Valid {
 				moduleMap[modID].Materials = append(moduleMap[modID].Materials, model.Material{
-					ID:    uint(contentID.Int64),
-					Title: contentTitle.String,
+					ID:     uint(contentID.Int64),
+					Title:  contentTitle.String,
+					Status: model.ContentStatus(contentStatus.String),
 				})
 			}
 		}
@@ -208,3 +306,286 @@ func (r *practicumRepository) GetPracticumWithMaterialContents(id int) (*model.P
 
 	return practicum, nil
 }
+
+// GetPracticumByName returns nil when no practicum of the term that isn't deleted has the name
+func (r *practicumRepository) GetPracticumByName(name string, academicTermID *int) (*model.Practicum, error) {
+	var practicum model.Practicum
+	err := r.db.QueryRow("SELECT id_practicum, name, code, description, credits, semester, academic_term_id, created_at, updated_at FROM practicums WHERE name = $1 AND academic_term_id IS NOT DISTINCT FROM $2 AND deleted_at IS NULL", name, academicTermID).
+		Scan(&practicum.ID, &practicum.Name, &practicum.Code, &practicum.Description, &practicum.Credits, &practicum.Semester, &practicum.AcademicTermID, &practicum.CreatedAt, &practicum.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &practicum, nil
+}
+
+// GetDeletedPracticumByID returns nil when the practicum doesn't exist or isn't deleted
+func (r *practicumRepository) GetDeletedPracticumByID(id int) (*model.Practicum, error) {
+	var practicum model.Practicum
+	err := r.db.QueryRow("SELECT id_practicum, name, code, description, credits, semester, academic_term_id, created_at, updated_at, deleted_at FROM practicums WHERE id_practicum = $1 AND deleted_at IS NOT NULL", id).
+		Scan(&practicum.ID, &practicum.Name, &practicum.Code, &practicum.Description, &practicum.Credits, &practicum.Semester, &practicum.AcademicTermID, &practicum.CreatedAt, &practicum.UpdatedAt, &practicum.DeletedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &practicum, nil
+}
+
+// UpdatePracticum reports false when the practicum doesn't exist or is deleted
+func (r *practicumRepository) UpdatePracticum(practicum *model.Practicum) (bool, error) {
+	// Classes follow their practicum into the new term, registrations keep the term they were made in
+	query := `
+		WITH updated AS (
+			UPDATE practicums
+			SET name = $1, code = $2, description = $3, credits = $4, semester = $5, academic_term_id = $6, updated_at = NOW()
+			WHERE id_practicum = $7 AND deleted_at IS NULL
+			RETURNING id_practicum, academic_term_id, created_at, updated_at
+		), classes AS (
+			UPDATE practicum_class c
+			SET academic_term_id = u.academic_term_id, updated_at = NOW()
+			FROM updated u
+			WHERE c.practicum_id = u.id_practicum AND c.academic_term_id IS DISTINCT FROM u.academic_term_id
+		)
+		SELECT created_at, updated_at FROM updated
+	`
+
+	err := r.db.QueryRow(query, practicum.Name, practicum.Code, practicum.Description, practicum.Credits, practicum.Semester, practicum.AcademicTermID, practicum.ID).
+		Scan(&practicum.CreatedAt, &practicum.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Int("practicum_id", practicum.ID).Msg("Failed to update practicum")
+		return false, err
+	}
+	return true, nil
+}
+
+// SoftDeletePracticum hides the practicum, its modules, classes and registrations are kept
+func (r *practicumRepository) SoftDeletePracticum(id int) (bool, error) {
+	result, err := r.db.Exec("UPDATE practicums SET deleted_at = NOW() WHERE id_practicum = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		log.Error().Err(err).Int("practicum_id", id).Msg("Failed to delete practicum")
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *practicumRepository) RestorePracticum(id int) (bool, error) {
+	result, err := r.db.Exec("UPDATE practicums SET deleted_at = NULL, updated_at = NOW() WHERE id_practicum = $1 AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		log.Error().Err(err).Int("practicum_id", id).Msg("Failed to restore practicum")
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *practicumRepository) ClonePracticum(sourceID int, target *model.Practicum, includeClasses bool) (result *model.PracticumCloneResult, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	err = tx.QueryRow(`
+		INSERT INTO practicums (name, code, description, credits, semester, academic_term_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id_practicum, created_at, updated_at
+	`, target.Name, target.Code, target.Description, target.Credits, target.Semester, target.AcademicTermID).
+		Scan(&target.ID, &target.CreatedAt, &target.UpdatedAt)
+	if err != nil {
+		log.Error().Err(err).Int("practicum_id", sourceID).Msg("Failed to insert practicum copy")
+		return nil, err
+	}
+
+	result = &model.PracticumCloneResult{
+		Modules:   map[int]int{},
+		Contents:  map[int]int{},
+		Materials: map[uuid.UUID]uuid.UUID{},
+		Classes:   map[int]int{},
+	}
+
+	// Rows are read in full before inserting, a transaction runs one statement at a time
+	modules, err := r.getModulesToClone(tx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, module := range modules {
+		var newID int
+		err = tx.QueryRow("INSERT INTO practicum_modules (title, practicum_id, sequence) VALUES ($1, $2, $3) RETURNING id", module.Title, target.ID, module.Sequence).Scan(&newID)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to copy practicum module")
+			return nil, err
+		}
+		result.Modules[module.ID] = newID
+	}
+
+	contents, err := r.getContentsToClone(tx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, source := range contents {
+		copied := source.PracticumModuleContent
+		copied.IDModule = result.Modules[source.IDModule]
+		copied.MaterialID = uuid.New()
+		// The version students see stays published as version 1 of the copy, changes made since
+		// follow as a draft version 2. Content nobody sees yet starts as a draft.
+		published := source.publishedContent != nil
+		changed := !published || copied.Title != source.publishedTitle || !bytes.Equal(copied.Content, source.publishedContent)
+		var publishedVersion sql.NullInt64
+		if published {
+			publishedVersion = sql.NullInt64{Int64: 1, Valid: true}
+		}
+		copied.Status = model.ContentPublished
+		if changed {
+			copied.Status = model.ContentDraft
+		}
+
+		err = tx.QueryRow(`
+			INSERT INTO practicum_module_content (id_module, title, content, sequence, material_id, status, published_version)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id_content
+		`, copied.IDModule, copied.Title, copied.Content, copied.Sequence, copied.MaterialID, copied.Status, publishedVersion).Scan(&copied.IDContent)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to copy practicum module content")
+			return nil, err
+		}
+
+		// The copy starts its own history from the source's published and current content
+		if published {
+			if _, err = insertContentVersion(tx, copied.MaterialID, source.publishedTitle, source.publishedContent, 0, nil); err != nil {
+				log.Error().Err(err).Msg("Failed to record published version of copied practicum module content")
+				return nil, err
+			}
+		}
+		if changed {
+			if _, err = insertContentVersion(tx, copied.MaterialID, copied.Title, copied.Content, 0, nil); err != nil {
+				log.Error().Err(err).Msg("Failed to record version of copied practicum module content")
+				return nil, err
+			}
+		}
+		result.Contents[source.IDContent] = copied.IDContent
+		result.Materials[source.MaterialID] = copied.MaterialID
+	}
+
+	if includeClasses {
+		var classes []model.PracticumClass
+		classes, err = r.getClassesToClone(tx, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		for _, class := range classes {
+			var newID int
+			err = tx.QueryRow(`
+				INSERT INTO practicum_class (practicum_id, name, quota, day, time, academic_term_id, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+				RETURNING id_practicum_class
+			`, target.ID, class.Name, class.Quota, class.Day, class.Time, target.AcademicTermID).Scan(&newID)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to copy practicum class")
+				return nil, err
+			}
+			result.Classes[class.IDPracticumClass] = newID
+		}
+	}
+
+	result.Practicum = *target
+	return result, nil
+}
+
+func (r *practicumRepository) getModulesToClone(tx *sql.Tx, practicumID int) ([]model.PracticumModule, error) {
+	rows, err := tx.Query("SELECT id, title, sequence FROM practicum_modules WHERE practicum_id = $1 ORDER BY sequence", practicumID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read practicum modules to clone")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var modules []model.PracticumModule
+	for rows.Next() {
+		var module model.PracticumModule
+		if err := rows.Scan(&module.ID, &module.Title, &module.Sequence); err != nil {
+			return nil, err
+		}
+		modules = append(modules, module)
+	}
+	return modules, rows.Err()
+}
+
+// contentToClone is the latest revision of a content with the version students see, if any
+type contentToClone struct {
+	model.PracticumModuleContent
+	publishedTitle   string
+	publishedContent []byte
+}
+
+func (r *practicumRepository) getContentsToClone(tx *sql.Tx, practicumID int) ([]contentToClone, error) {
+	query := `
+		SELECT c.id_content, c.id_module, c.title, c.content, c.sequence, c.material_id, c.status, pv.title, pv.content
+		FROM practicum_module_content c
+		JOIN practicum_modules m ON m.id = c.id_module
+		LEFT JOIN practicum_module_content_versions pv ON pv.material_id = c.material_id AND pv.version = ` + visibleContentVersion("c") + `
+		WHERE m.practicum_id = $1
+		ORDER BY c.id_module, c.sequence, c.id_content
+	`
+	rows, err := tx.Query(query, practicumID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read practicum module contents to clone")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contents []contentToClone
+	for rows.Next() {
+		var content contentToClone
+		var publishedTitle sql.NullString
+		if err := rows.Scan(&content.IDContent, &content.IDModule, &content.Title, &content.Content, &content.Sequence, &content.MaterialID, &content.Status,
+			&publishedTitle, &content.publishedContent); err != nil {
+			return nil, err
+		}
+		content.publishedTitle = publishedTitle.String
+		contents = append(contents, content)
+	}
+	return contents, rows.Err()
+}
+
+func (r *practicumRepository) getClassesToClone(tx *sql.Tx, practicumID int) ([]model.PracticumClass, error) {
+	rows, err := tx.Query("SELECT id_practicum_class, name, quota, day, time FROM practicum_class WHERE practicum_id = $1 ORDER BY id_practicum_class", practicumID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read practicum classes to clone")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var classes []model.PracticumClass
+	for rows.Next() {
+		var class model.PracticumClass
+		if err := rows.Scan(&class.IDPracticumClass, &class.Name, &class.Quota, &class.Day, &class.Time); err != nil {
+			return nil, err
+		}
+		classes = append(classes, class)
+	}
+	return classes, rows.Err()
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/rs/zerolog/log"
+)
+
+type PracticumStaffRepository interface {
+	AssignStaff(staff *model.PracticumStaff) error
+	RemoveStaff(practicumID, userID int) error
+	GetStaffByPracticumID(practicumID int) ([]model.PracticumStaff, error)
+	GetPracticumIDsByUserID(userID int) ([]int, error)
+	IsAssigned(userID, practicumID int) (bool, error)
+}
+
+type practicumStaffRepository struct {
+	db *sql.DB
+}
+
+func NewPracticumStaffRepository(db *sql.DB) PracticumStaffRepository {
+	return &practicumStaffRepository{db: db}
+}
+
+// AssignStaff links a lecturer or laboratory assistant to a practicum
+func (r *practicumStaffRepository) AssignStaff(staff *model.PracticumStaff) error {
+	query := `
+		INSERT INTO practicum_staff (id_practicum, id_user)
+		VALUES ($1, $2)
+		ON CONFLICT (id_practicum, id_user) DO UPDATE SET id_user = EXCLUDED.id_user
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(query, staff.PracticumID, staff.UserID).Scan(&staff.ID, &staff.CreatedAt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to assign practicum staff")
+		return err
+	}
+	return nil
+}
+
+// RemoveStaff unlinks a staff member from a practicum
+func (r *practicumStaffRepository) RemoveStaff(practicumID, userID int) error {
+	query := `DELETE FROM practicum_staff WHERE id_practicum = $1 AND id_user = $2`
+	_, err := r.db.Exec(query, practicumID, userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to remove practicum staff")
+		return err
+	}
+	return nil
+}
+
+// GetStaffByPracticumID lists every staff member assigned to a practicum
+func (r *practicumStaffRepository) GetStaffByPracticumID(practicumID int) ([]model.PracticumStaff, error) {
+	query := `
+		SELECT id, id_practicum, id_user, created_at
+		FROM practicum_staff
+		WHERE id_practicum = $1
+		ORDER BY id
+	`
+	rows, err := r.db.Query(query, practicumID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch practicum staff")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var staff []model.PracticumStaff
+	for rows.Next() {
+		var member model.PracticumStaff
+		if err := rows.Scan(&member.ID, &member.PracticumID, &member.UserID, &member.CreatedAt); err != nil {
+			return nil, err
+		}
+		staff = append(staff, member)
+	}
+	return staff, nil
+}
+
+// GetPracticumIDsByUserID returns the practicums a staff member is assigned to
+func (r *practicumStaffRepository) GetPracticumIDsByUserID(userID int) ([]int, error) {
+	rows, err := r.db.Query(`SELECT id_practicum FROM practicum_staff WHERE id_user = $1`, userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch assigned practicums")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var practicumIDs []int
+	for rows.Next() {
+		var practicumID int
+		if err := rows.Scan(&practicumID); err != nil {
+			return nil, err
+		}
+		practicumIDs = append(practicumIDs, practicumID)
+	}
+	return practicumIDs, nil
+}
+
+// IsAssigned reports whether the user is assigned to the practicum
+func (r *practicumStaffRepository) IsAssigned(userID, practicumID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM practicum_staff WHERE id_user = $1 AND id_practicum = $2)`,
+		userID, practicumID,
+	).Scan(&exists)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check practicum staff assignment")
+		return false, err
+	}
+	return exists, nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type RefreshTokenRepository interface {
+	CreateRefreshToken(token *model.RefreshToken) error
+	GetRefreshTokenByHash(tokenHash string) (*model.RefreshToken, error)
+	RevokeRefreshToken(id int) (bool, error)
+	RevokeFamily(familyID uuid.UUID) error
+	// GetRefreshTokensIssuedSince lists every refresh token of the user created after since, revoked or not
+	GetRefreshTokensIssuedSince(userID int, since time.Time) ([]model.RefreshToken, error)
+	RevokeAllByUserID(userID int) error
+}
+
+type refreshTokenRepository struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepository(db *sql.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+// CreateRefreshToken stores the hash of an issued refresh token
+func (r *refreshTokenRepository) CreateRefreshToken(token *model.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (id_user, token_hash, family_id, access_jti, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(query, token.UserID, token.TokenHash, token.FamilyID, token.AccessJTI, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to store refresh token")
+		return err
+	}
+	return nil
+}
+
+// GetRefreshTokenByHash returns nil when the token was never issued
+func (r *refreshTokenRepository) GetRefreshTokenByHash(tokenHash string) (*model.RefreshToken, error) {
+	query := `
+		SELECT id, id_user, token_hash, family_id, access_jti, expires_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+	var token model.RefreshToken
+	err := r.db.QueryRow(query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.FamilyID,
+		&token.AccessJTI,
+		&token.ExpiresAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Error().Err(err).Msg("Failed to fetch refresh token")
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeRefreshToken revokes a single token and reports false if it was already revoked,
+// which lets concurrent refreshes with the same token be detected as reuse.
+func (r *refreshTokenRepository) RevokeRefreshToken(id int) (bool, error) {
+	result, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to revoke refresh token")
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// RevokeFamily revokes every token descending from the same login
+func (r *refreshTokenRepository) RevokeFamily(familyID uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`, familyID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to revoke refresh token family")
+		return err
+	}
+	return nil
+}
+
+func (r *refreshTokenRepository) GetRefreshTokensIssuedSince(userID int, since time.Time) ([]model.RefreshToken, error) {
+	query := `
+		SELECT id, id_user, token_hash, family_id, access_jti, expires_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE id_user = $1 AND created_at > $2
+	`
+	rows, err := r.db.Query(query, userID, since)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch recent refresh tokens")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []model.RefreshToken
+	for rows.Next() {
+		var token model.RefreshToken
+		if err := rows.Scan(&token.ID, &token.UserID, &token.TokenHash, &token.FamilyID, &token.AccessJTI, &token.ExpiresAt, &token.RevokedAt, &token.CreatedAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// RevokeAllByUserID revokes every refresh token of the user
+func (r *refreshTokenRepository) RevokeAllByUserID(userID int) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = NOW() WHERE id_user = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to revoke user refresh tokens")
+		return err
+	}
+	return nil
+}
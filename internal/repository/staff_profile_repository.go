@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/rs/zerolog/log"
+)
+
+type StaffProfileRepository interface {
+	CreateStaffProfile(profile *model.StaffProfile) error
+	GetStaffProfileByUserID(userID int) (*model.StaffProfile, error)
+	GetStaffProfileByEmployeeIDNumber(employeeIDNumber string) (*model.StaffProfile, error)
+}
+
+type staffProfileRepository struct {
+	db *sql.DB
+}
+
+func NewStaffProfileRepository(db *sql.DB) StaffProfileRepository {
+	return &staffProfileRepository{db: db}
+}
+
+func (r *staffProfileRepository) CreateStaffProfile(profile *model.StaffProfile) error {
+	query := `
+		INSERT INTO staff_profiles (id_user, employee_id_number, name)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`
+	err := r.db.QueryRow(query, profile.UserID, profile.EmployeeIDNumber, profile.Name).
+		Scan(&profile.ID, &profile.CreatedAt, &profile.UpdatedAt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create staff profile")
+		return err
+	}
+	return nil
+}
+
+// GetStaffProfileByUserID returns nil when the user has no staff profile
+func (r *staffProfileRepository) GetStaffProfileByUserID(userID int) (*model.StaffProfile, error) {
+	return r.getStaffProfile(`WHERE id_user = $1`, userID)
+}
+
+// GetStaffProfileByEmployeeIDNumber returns nil when no profile uses the number
+func (r *staffProfileRepository) GetStaffProfileByEmployeeIDNumber(employeeIDNumber string) (*model.StaffProfile, error) {
+	return r.getStaffProfile(`WHERE employee_id_number = $1`, employeeIDNumber)
+}
+
+func (r *staffProfileRepository) getStaffProfile(where string, arg interface{}) (*model.StaffProfile, error) {
+	query := `
+		SELECT id, id_user, employee_id_number, name, created_at, updated_at
+		FROM staff_profiles
+	` + where
+	var profile model.StaffProfile
+	err := r.db.QueryRow(query, arg).
+		Scan(&profile.ID, &profile.UserID, &profile.EmployeeIDNumber, &profile.Name, &profile.CreatedAt, &profile.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Error().Err(err).Msg("Failed to fetch staff profile")
+		return nil, err
+	}
+	return &profile, nil
+}
@@ -11,6 +11,8 @@ type StudentClassEnrollmentRepository interface {
 	EnrollStudent(classID, studentID int) error
 	GetEnrollmentsByStudentID(studentID int) ([]model.StudentClassEnrollment, error)
 	GetEnrollmentsByClassID(classID int) ([]model.StudentClassEnrollment, error)
+	// GetEnrollmentByID returns nil when the enrollment doesn't exist
+	GetEnrollmentByID(enrollmentID int) (*model.StudentClassEnrollment, error)
 	DeleteEnrollment(enrollmentID int) error
 }
 
@@ -93,6 +95,26 @@ func (r *studentClassEnrollmentRepository) GetEnrollmentsByClassID(classID int)
 	return enrollments, nil
 }
 
+// GetEnrollmentByID retrieves a single class enrollment
+func (r *studentClassEnrollmentRepository) GetEnrollmentByID(enrollmentID int) (*model.StudentClassEnrollment, error) {
+	query := `
+		SELECT id, class_id, student_id, created_at, updated_at
+		FROM student_class_enrollment
+		WHERE id = $1
+	`
+	var enrollment model.StudentClassEnrollment
+	err := r.db.QueryRow(query, enrollmentID).
+		Scan(&enrollment.ID, &enrollment.ClassID, &enrollment.StudentID, &enrollment.CreatedAt, &enrollment.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to retrieve enrollment")
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
 // DeleteEnrollment removes a student from a class
 func (r *studentClassEnrollmentRepository) DeleteEnrollment(enrollmentID int) error {
 	query := `
@@ -8,9 +8,15 @@ import (
 )
 
 type StudentRegistrationRepository interface {
-	RegisterStudent(registration *model.StudentRegistration) error
-	GetRegistrationsByStudentID(studentID int) ([]model.StudentRegistration, error)
+	// RegisterStudent inserts the registrations in one transaction, all of them or none
+	RegisterStudent(registrations []model.StudentRegistration) error
+	// GetRegistrationsByStudentID lists the registrations of a student in a term, 0 for every term.
+	// withoutTerm adds the registrations made before terms existed.
+	GetRegistrationsByStudentID(studentID, academicTermID int, withoutTerm bool) ([]model.StudentRegistration, error)
 	GetRegistrationsByPracticumID(practicumID int) ([]model.StudentRegistration, error)
+	// GetRegistrationByID returns nil when the registration doesn't exist
+	GetRegistrationByID(id int) (*model.StudentRegistration, error)
+	IsStudentRegistered(studentID, practicumID int) (bool, error)
 	DeleteRegistration(id int) error
 }
 
@@ -22,28 +28,50 @@ func NewStudentRegistrationRepository(db *sql.DB) StudentRegistrationRepository
 	return &studentRegistrationRepository{db: db}
 }
 
-func (r *studentRegistrationRepository) RegisterStudent(registration *model.StudentRegistration) error {
-	query := `
-		INSERT INTO student_registration (student_id, practicum_id)
-		VALUES ($1, $2)
-		RETURNING id_student_registration, created_at, updated_at
-	`
-	err := r.db.QueryRow(query, registration.StudentID, registration.PracticumID).
-		Scan(&registration.IDStudentRegistration, &registration.CreatedAt, &registration.UpdatedAt)
+func (r *studentRegistrationRepository) RegisterStudent(registrations []model.StudentRegistration) (err error) {
+	tx, err := r.db.Begin()
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to register student")
 		return err
 	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	// The registration records the term of the practicum at the time it was made
+	query := `
+		INSERT INTO student_registration (student_id, practicum_id, academic_term_id)
+		SELECT $1, $2, academic_term_id
+		FROM practicums WHERE id_practicum = $2
+		RETURNING id_student_registration, academic_term_id, created_at, updated_at
+	`
+	for i := range registrations {
+		registration := &registrations[i]
+		err = tx.QueryRow(query, registration.StudentID, registration.PracticumID).
+			Scan(&registration.IDStudentRegistration, &registration.AcademicTermID, &registration.CreatedAt, &registration.UpdatedAt)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to register student")
+			return err
+		}
+	}
 	return nil
 }
 
-func (r *studentRegistrationRepository) GetRegistrationsByStudentID(studentID int) ([]model.StudentRegistration, error) {
+func (r *studentRegistrationRepository) GetRegistrationsByStudentID(studentID, academicTermID int, withoutTerm bool) ([]model.StudentRegistration, error) {
 	query := `
-		SELECT id_student_registration, student_id, practicum_id, created_at, updated_at
+		SELECT id_student_registration, student_id, practicum_id, academic_term_id, created_at, updated_at
 		FROM student_registration
-		WHERE student_id = $1
+		WHERE student_id = $1 AND ($2 = 0 OR academic_term_id = $2 OR ($3 AND academic_term_id IS NULL))
+		ORDER BY id_student_registration
 	`
-	rows, err := r.db.Query(query, studentID)
+	rows, err := r.db.Query(query, studentID, academicTermID, withoutTerm)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to fetch registrations by student ID")
 		return nil, err
@@ -53,7 +81,7 @@ func (r *studentRegistrationRepository) GetRegistrationsByStudentID(studentID in
 	var registrations []model.StudentRegistration
 	for rows.Next() {
 		var reg model.StudentRegistration
-		if err := rows.Scan(&reg.IDStudentRegistration, &reg.StudentID, &reg.PracticumID, &reg.CreatedAt, &reg.UpdatedAt); err != nil {
+		if err := rows.Scan(&reg.IDStudentRegistration, &reg.StudentID, &reg.PracticumID, &reg.AcademicTermID, &reg.CreatedAt, &reg.UpdatedAt); err != nil {
 			return nil, err
 		}
 		registrations = append(registrations, reg)
@@ -64,7 +92,7 @@ func (r *studentRegistrationRepository) GetRegistrationsByStudentID(studentID in
 
 func (r *studentRegistrationRepository) GetRegistrationsByPracticumID(practicumID int) ([]model.StudentRegistration, error) {
 	query := `
-		SELECT id_student_registration, student_id, practicum_id, created_at, updated_at
+		SELECT id_student_registration, student_id, practicum_id, academic_term_id, created_at, updated_at
 		FROM student_registration
 		WHERE practicum_id = $1
 	`
@@ -78,7 +106,7 @@ func (r *studentRegistrationRepository) GetRegistrationsByPracticumID(practicumI
 	var registrations []model.StudentRegistration
 	for rows.Next() {
 		var reg model.StudentRegistration
-		if err := rows.Scan(&reg.IDStudentRegistration, &reg.StudentID, &reg.PracticumID, &reg.CreatedAt, &reg.UpdatedAt); err != nil {
+		if err := rows.Scan(&reg.IDStudentRegistration, &reg.StudentID, &reg.PracticumID, &reg.AcademicTermID, &reg.CreatedAt, &reg.UpdatedAt); err != nil {
 			return nil, err
 		}
 		registrations = append(registrations, reg)
@@ -87,6 +115,35 @@ func (r *studentRegistrationRepository) GetRegistrationsByPracticumID(practicumI
 	return registrations, nil
 }
 
+func (r *studentRegistrationRepository) GetRegistrationByID(id int) (*model.StudentRegistration, error) {
+	query := `
+		SELECT id_student_registration, student_id, practicum_id, academic_term_id, created_at, updated_at
+		FROM student_registration
+		WHERE id_student_registration = $1
+	`
+	var reg model.StudentRegistration
+	err := r.db.QueryRow(query, id).
+		Scan(&reg.IDStudentRegistration, &reg.StudentID, &reg.PracticumID, &reg.AcademicTermID, &reg.CreatedAt, &reg.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch student registration")
+		return nil, err
+	}
+	return &reg, nil
+}
+
+func (r *studentRegistrationRepository) IsStudentRegistered(studentID, practicumID int) (bool, error) {
+	var registered bool
+	query := "SELECT EXISTS (SELECT 1 FROM student_registration WHERE student_id = $1 AND practicum_id = $2)"
+	if err := r.db.QueryRow(query, studentID, practicumID).Scan(&registered); err != nil {
+		log.Error().Err(err).Msg("Failed to check student registration")
+		return false, err
+	}
+	return registered, nil
+}
+
 func (r *studentRegistrationRepository) DeleteRegistration(id int) error {
 	query := "DELETE FROM student_registration WHERE id_student_registration = $1"
 	_, err := r.db.Exec(query, id)
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 
 	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/google/uuid"
 )
 
 type StudentRepository interface {
@@ -12,6 +13,8 @@ type StudentRepository interface {
 	GetStudentByUserID(id int) (*model.Student, error)
 	CreateStudent(student *model.Student) (int, error)
 	GetStudentByStudentID(student_id_number string) (*model.Student, error)
+	// SetStudyPlanAttachment links an uploaded study plan to the student
+	SetStudyPlanAttachment(studentID int, attachmentID uuid.UUID) (bool, error)
 }
 
 type studentRepository struct {
@@ -28,7 +31,7 @@ func (r *studentRepository) GetAllStudents(page, limit int) ([]model.Student, in
 
 	// Query to get the students with pagination
 	rows, err := r.db.Query(
-		"SELECT id, student_id_number, name, study_plan_file, created_at, updated_at FROM students LIMIT $1 OFFSET $2",
+		"SELECT id, student_id_number, name, study_plan_file, study_plan_attachment_id, created_at, updated_at FROM students LIMIT $1 OFFSET $2",
 		limit, offset,
 	)
 	if err != nil {
@@ -39,7 +42,7 @@ func (r *studentRepository) GetAllStudents(page, limit int) ([]model.Student, in
 	var students []model.Student
 	for rows.Next() {
 		var student model.Student
-		if err := rows.Scan(&student.ID, &student.StudentIDNumber, &student.Name, &student.StudyPlanFile, &student.CreatedAt, &student.UpdatedAt); err != nil {
+		if err := rows.Scan(&student.ID, &student.StudentIDNumber, &student.Name, &student.StudyPlanFile, &student.StudyPlanAttachmentID, &student.CreatedAt, &student.UpdatedAt); err != nil {
 			return nil, 0, err
 		}
 		students = append(students, student)
@@ -57,8 +60,8 @@ func (r *studentRepository) GetAllStudents(page, limit int) ([]model.Student, in
 
 func (r *studentRepository) GetStudentByID(id int) (*model.Student, error) {
 	var student model.Student
-	err := r.db.QueryRow("SELECT id, student_id_number, name, study_plan_file, created_at, updated_at FROM students WHERE id = $1", id).
-		Scan(&student.ID, &student.StudentIDNumber, &student.Name, &student.StudyPlanFile, &student.CreatedAt, &student.UpdatedAt)
+	err := r.db.QueryRow("SELECT id, student_id_number, name, study_plan_file, study_plan_attachment_id, created_at, updated_at FROM students WHERE id = $1", id).
+		Scan(&student.ID, &student.StudentIDNumber, &student.Name, &student.StudyPlanFile, &student.StudyPlanAttachmentID, &student.CreatedAt, &student.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +72,7 @@ func (r *studentRepository) GetStudentByUserID(id int) (*model.Student, error) {
 	var student model.Student
 	err := r.db.QueryRow(`
         SELECT
-            s.id, s.student_id_number, s.name, s.study_plan_file, s.created_at, s.updated_at
+            s.id, s.student_id_number, s.name, s.study_plan_file, s.study_plan_attachment_id, s.created_at, s.updated_at
         FROM
             students s
         JOIN
@@ -77,7 +80,7 @@ func (r *studentRepository) GetStudentByUserID(id int) (*model.Student, error) {
         WHERE
             u.id_user = $1
     `, id).
-		Scan(&student.ID, &student.StudentIDNumber, &student.Name, &student.StudyPlanFile, &student.CreatedAt, &student.UpdatedAt)
+		Scan(&student.ID, &student.StudentIDNumber, &student.Name, &student.StudyPlanFile, &student.StudyPlanAttachmentID, &student.CreatedAt, &student.UpdatedAt)
 
 	if err != nil {
 		return nil, err
@@ -87,8 +90,8 @@ func (r *studentRepository) GetStudentByUserID(id int) (*model.Student, error) {
 
 func (r *studentRepository) GetStudentByStudentID(student_id_number string) (*model.Student, error) {
 	var student model.Student
-	err := r.db.QueryRow("SELECT id, student_id_number, name, study_plan_file, created_at, updated_at FROM students WHERE student_id_number = $1", student_id_number).
-		Scan(&student.ID, &student.StudentIDNumber, &student.Name, &student.StudyPlanFile, &student.CreatedAt, &student.UpdatedAt)
+	err := r.db.QueryRow("SELECT id, student_id_number, name, study_plan_file, study_plan_attachment_id, created_at, updated_at FROM students WHERE student_id_number = $1", student_id_number).
+		Scan(&student.ID, &student.StudentIDNumber, &student.Name, &student.StudyPlanFile, &student.StudyPlanAttachmentID, &student.CreatedAt, &student.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil // No student found, return nil without error
@@ -121,3 +124,16 @@ func (r *studentRepository) CreateStudent(student *model.Student) (int, error) {
 
 	return id, nil
 }
+
+func (r *studentRepository) SetStudyPlanAttachment(studentID int, attachmentID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec("UPDATE students SET study_plan_attachment_id = $1, updated_at = NOW() WHERE id = $2", attachmentID, studentID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
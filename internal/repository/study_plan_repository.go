@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+type StudyPlanRepository interface {
+	// CreateStudyPlan records the plan with its practicums and makes it the student's current study plan,
+	// studyPlanFile is stored in students.study_plan_file for clients reading that column
+	CreateStudyPlan(plan *model.StudyPlan, studyPlanFile string) error
+	// GetStudyPlanByID returns nil when the plan doesn't exist
+	GetStudyPlanByID(id int) (*model.StudyPlan, error)
+	GetStudyPlansByStudentID(studentID int) ([]model.StudyPlan, error)
+	// GetStudyPlans lists plans oldest first, an empty status lists every status
+	GetStudyPlans(status model.StudyPlanStatus, page, limit int) ([]model.StudyPlan, int, error)
+	// ReviewStudyPlan approves or rejects a pending plan, it reports false when the plan isn't pending
+	ReviewStudyPlan(id int, status model.StudyPlanStatus, reviewerID int, reason *string) (bool, error)
+	// IsPracticumApproved reports whether an approved plan of the student lists the practicum
+	IsPracticumApproved(studentID, practicumID int) (bool, error)
+}
+
+type studyPlanRepository struct {
+	db *sql.DB
+}
+
+func NewStudyPlanRepository(db *sql.DB) StudyPlanRepository {
+	return &studyPlanRepository{db: db}
+}
+
+const studyPlanColumns = `sp.id, sp.student_id, sp.attachment_id,
+	ARRAY(SELECT spp.practicum_id FROM study_plan_practicums spp WHERE spp.study_plan_id = sp.id ORDER BY spp.practicum_id),
+	sp.status, sp.rejection_reason, sp.reviewed_by, sp.reviewed_at, sp.created_at, sp.updated_at`
+
+func (r *studyPlanRepository) CreateStudyPlan(plan *model.StudyPlan, studyPlanFile string) (err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	err = tx.QueryRow(`
+		INSERT INTO study_plans (student_id, attachment_id, status)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`, plan.StudentID, plan.AttachmentID, model.StudyPlanPending).Scan(&plan.ID, &plan.CreatedAt, &plan.UpdatedAt)
+	if err != nil {
+		log.Error().Err(err).Int("student_id", plan.StudentID).Msg("Failed to create study plan")
+		return err
+	}
+	plan.Status = model.StudyPlanPending
+
+	practicumIDs := make([]int64, len(plan.PracticumIDs))
+	for i, id := range plan.PracticumIDs {
+		practicumIDs[i] = int64(id)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO study_plan_practicums (study_plan_id, practicum_id)
+		SELECT $1, UNNEST($2::int[])
+		ON CONFLICT DO NOTHING
+	`, plan.ID, pq.Array(practicumIDs))
+	if err != nil {
+		log.Error().Err(err).Int("study_plan_id", plan.ID).Msg("Failed to record study plan practicums")
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE students SET study_plan_attachment_id = $1, study_plan_file = $2, updated_at = NOW() WHERE id = $3",
+		plan.AttachmentID, studyPlanFile, plan.StudentID)
+	if err != nil {
+		log.Error().Err(err).Int("student_id", plan.StudentID).Msg("Failed to update the student's study plan")
+		return err
+	}
+	return nil
+}
+
+func (r *studyPlanRepository) GetStudyPlanByID(id int) (*model.StudyPlan, error) {
+	plan, err := scanStudyPlan(r.db.QueryRow("SELECT "+studyPlanColumns+" FROM study_plans sp WHERE sp.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return plan, err
+}
+
+func (r *studyPlanRepository) GetStudyPlansByStudentID(studentID int) ([]model.StudyPlan, error) {
+	rows, err := r.db.Query("SELECT "+studyPlanColumns+" FROM study_plans sp WHERE sp.student_id = $1 ORDER BY sp.created_at DESC", studentID)
+	if err != nil {
+		log.Error().Err(err).Int("student_id", studentID).Msg("Failed to fetch study plans of student")
+		return nil, err
+	}
+	return scanStudyPlans(rows)
+}
+
+func (r *studyPlanRepository) GetStudyPlans(status model.StudyPlanStatus, page, limit int) ([]model.StudyPlan, int, error) {
+	offset := (page - 1) * limit
+
+	rows, err := r.db.Query(
+		"SELECT "+studyPlanColumns+" FROM study_plans sp WHERE ($1::text = '' OR sp.status = $1::text) ORDER BY sp.created_at, sp.id LIMIT $2 OFFSET $3",
+		status, limit, offset,
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch study plans")
+		return nil, 0, err
+	}
+	plans, err := scanStudyPlans(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = r.db.QueryRow("SELECT COUNT(*) FROM study_plans WHERE ($1::text = '' OR status = $1::text)", status).Scan(&total)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to count study plans")
+		return nil, 0, err
+	}
+
+	return plans, total, nil
+}
+
+func (r *studyPlanRepository) ReviewStudyPlan(id int, status model.StudyPlanStatus, reviewerID int, reason *string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE study_plans
+		SET status = $1, rejection_reason = $2, reviewed_by = $3, reviewed_at = NOW(), updated_at = NOW()
+		WHERE id = $4 AND status = $5
+	`, status, reason, nullableUserID(reviewerID), id, model.StudyPlanPending)
+	if err != nil {
+		log.Error().Err(err).Int("study_plan_id", id).Msg("Failed to review study plan")
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *studyPlanRepository) IsPracticumApproved(studentID, practicumID int) (bool, error) {
+	var approved bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM study_plans sp
+			JOIN study_plan_practicums spp ON spp.study_plan_id = sp.id
+			WHERE sp.student_id = $1 AND spp.practicum_id = $2 AND sp.status = $3
+		)
+	`, studentID, practicumID, model.StudyPlanApproved).Scan(&approved)
+	if err != nil {
+		log.Error().Err(err).Int("student_id", studentID).Int("practicum_id", practicumID).Msg("Failed to check study plan approval")
+		return false, err
+	}
+	return approved, nil
+}
+
+func scanStudyPlans(rows *sql.Rows) ([]model.StudyPlan, error) {
+	defer rows.Close()
+
+	plans := []model.StudyPlan{}
+	for rows.Next() {
+		plan, err := scanStudyPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, *plan)
+	}
+	return plans, rows.Err()
+}
+
+func scanStudyPlan(row rowScanner) (*model.StudyPlan, error) {
+	var plan model.StudyPlan
+	var practicumIDs []int64
+	var reason sql.NullString
+	var reviewedBy sql.NullInt64
+	var reviewedAt sql.NullTime
+
+	err := row.Scan(&plan.ID, &plan.StudentID, &plan.AttachmentID, pq.Array(&practicumIDs), &plan.Status, &reason,
+		&reviewedBy, &reviewedAt, &plan.CreatedAt, &plan.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.PracticumIDs = make([]int, len(practicumIDs))
+	for i, id := range practicumIDs {
+		plan.PracticumIDs[i] = int(id)
+	}
+	if reason.Valid {
+		plan.RejectionReason = &reason.String
+	}
+	plan.ReviewedBy = nullIntPtr(reviewedBy)
+	plan.ReviewedAt = nullTimePtr(reviewedAt)
+	return &plan, nil
+}
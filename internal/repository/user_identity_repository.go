@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/rs/zerolog/log"
+)
+
+type UserIdentityRepository interface {
+	CreateUserIdentity(identity *model.UserIdentity) error
+	GetUserIdentity(issuer, subject string) (*model.UserIdentity, error)
+	CreateAuthRequest(request *model.OIDCAuthRequest) error
+	ConsumeAuthRequest(state string) (*model.OIDCAuthRequest, error)
+}
+
+type userIdentityRepository struct {
+	db *sql.DB
+}
+
+func NewUserIdentityRepository(db *sql.DB) UserIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
+
+func (r *userIdentityRepository) CreateUserIdentity(identity *model.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (id_user, issuer, subject)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(query, identity.UserID, identity.Issuer, identity.Subject).Scan(&identity.ID, &identity.CreatedAt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create user identity")
+		return err
+	}
+	return nil
+}
+
+// GetUserIdentity returns nil when the external account isn't linked yet
+func (r *userIdentityRepository) GetUserIdentity(issuer, subject string) (*model.UserIdentity, error) {
+	query := `
+		SELECT id, id_user, issuer, subject, created_at
+		FROM user_identities
+		WHERE issuer = $1 AND subject = $2
+	`
+	var identity model.UserIdentity
+	err := r.db.QueryRow(query, issuer, subject).
+		Scan(&identity.ID, &identity.UserID, &identity.Issuer, &identity.Subject, &identity.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Error().Err(err).Msg("Failed to fetch user identity")
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *userIdentityRepository) CreateAuthRequest(request *model.OIDCAuthRequest) error {
+	query := `
+		INSERT INTO oidc_auth_requests (state, code_verifier, nonce, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+	err := r.db.QueryRow(query, request.State, request.CodeVerifier, request.Nonce, request.ExpiresAt).Scan(&request.CreatedAt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to store oidc auth request")
+		return err
+	}
+
+	// Logins that were never completed
+	if _, err := r.db.Exec(`DELETE FROM oidc_auth_requests WHERE expires_at < NOW()`); err != nil {
+		log.Warn().Err(err).Msg("Failed to prune oidc auth requests")
+	}
+	return nil
+}
+
+// ConsumeAuthRequest deletes and returns the request so a state can only be used once,
+// nil is returned for an unknown state
+func (r *userIdentityRepository) ConsumeAuthRequest(state string) (*model.OIDCAuthRequest, error) {
+	query := `
+		DELETE FROM oidc_auth_requests
+		WHERE state = $1
+		RETURNING state, code_verifier, nonce, expires_at, created_at
+	`
+	var request model.OIDCAuthRequest
+	err := r.db.QueryRow(query, state).
+		Scan(&request.State, &request.CodeVerifier, &request.Nonce, &request.ExpiresAt, &request.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Error().Err(err).Msg("Failed to consume oidc auth request")
+		return nil, err
+	}
+	return &request, nil
+}
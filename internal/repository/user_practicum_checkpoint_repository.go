@@ -9,6 +9,7 @@ import (
 
 type UserPracticumCheckpointRepository interface {
 	CreateCheckpoint(checkpoint *model.UserPracticumCheckpoint) error
+	GetCheckpointByID(id int) (*model.UserPracticumCheckpoint, error)
 	GetCheckpointByUserAndPracticum(userID, practicumID int) (*model.UserPracticumCheckpoint, error)
 	GetCheckpointByUser(userID int) ([]model.UserPracticumCheckpoint, error)
 	UpdateCheckpoint(checkpoint *model.UserPracticumCheckpoint) error
@@ -39,6 +40,26 @@ func (r *userPracticumCheckpointRepository) CreateCheckpoint(checkpoint *model.U
 	return nil
 }
 
+// GetCheckpointByID fetches a single checkpoint by its ID
+func (r *userPracticumCheckpointRepository) GetCheckpointByID(id int) (*model.UserPracticumCheckpoint, error) {
+	query := `
+		SELECT id, id_user, id_practicum, id_module, id_content, updated_at
+		FROM user_practicum_checkpoint
+		WHERE id = $1
+	`
+	var checkpoint model.UserPracticumCheckpoint
+	err := r.db.QueryRow(query, id).
+		Scan(&checkpoint.ID, &checkpoint.UserID, &checkpoint.PracticumID, &checkpoint.ModuleID, &checkpoint.ContentID, &checkpoint.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Error().Err(err).Msg("Failed to fetch user practicum checkpoint")
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
 // GetCheckpointByUserAndPracticum fetches the checkpoint for a specific user and practicum
 func (r *userPracticumCheckpointRepository) GetCheckpointByUserAndPracticum(userID, practicumID int) (*model.UserPracticumCheckpoint, error) {
 	query := `
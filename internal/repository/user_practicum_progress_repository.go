@@ -12,6 +12,7 @@ import (
 
 type UserPracticumProgressRepository interface {
 	CreateProgress(progress *model.UserPracticumProgress) error
+	GetProgressByID(id int) (*model.UserPracticumProgress, error)
 	GetProgressByUserAndPracticum(userID, practicumID int) (*model.UserPracticumProgress, error)
 	GetProgressByPracticumIDs(pracIDs []int) ([]model.UserPracticumProgress, error)
 	UpdateProgress(progress *model.UserPracticumProgress) error
@@ -41,6 +42,22 @@ func (r *userPracticumProgressRepository) CreateProgress(progress *model.UserPra
 	return nil
 }
 
+func (r *userPracticumProgressRepository) GetProgressByID(id int) (*model.UserPracticumProgress, error) {
+	query := `
+		SELECT id, id_user, id_practicum, progress, completed_at, last_updated_at
+		FROM user_practicum_progress
+		WHERE id = $1
+	`
+	var progress model.UserPracticumProgress
+	err := r.db.QueryRow(query, id).
+		Scan(&progress.ID, &progress.UserID, &progress.PracticumID, &progress.Progress, &progress.CompletedAt, &progress.LastUpdated)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch user practicum progress")
+		return nil, err
+	}
+	return &progress, nil
+}
+
 func (r *userPracticumProgressRepository) GetProgressByUserAndPracticum(userID, practicumID int) (*model.UserPracticumProgress, error) {
 	query := `
 		SELECT id, id_user, id_practicum, progress, completed_at, last_updated_at
@@ -10,7 +10,7 @@ func CORSMiddleware(allowedOrigins []string) middleware {
                 for _, allowedOrigin := range allowedOrigins {
                     if origin == allowedOrigin {
                         w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
-                        w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+                        w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, PATCH, DELETE")
                         w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
                         w.Header().Set("Access-Control-Allow-Credentials", "true")
 
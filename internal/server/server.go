@@ -1,16 +1,24 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/egasa21/si-lab-api-go/configs"
 	"github.com/egasa21/si-lab-api-go/internal/database"
 	"github.com/egasa21/si-lab-api-go/internal/handler"
 	"github.com/egasa21/si-lab-api-go/internal/middlewares"
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/notifier"
 	"github.com/egasa21/si-lab-api-go/internal/repository"
 	"github.com/egasa21/si-lab-api-go/internal/service"
+	"github.com/egasa21/si-lab-api-go/internal/storage"
+	"github.com/egasa21/si-lab-api-go/pkg/auth"
+	"github.com/egasa21/si-lab-api-go/pkg/oidc"
 	"github.com/rs/zerolog"
 )
 
@@ -32,12 +40,52 @@ func NewServer(cfg *configs.Config, logger zerolog.Logger) *Server {
 		logger.Fatal().Err(err).Msg("Failed to connect to database")
 	}
 
+	// Tokens can't be issued or verified without a key, so refuse to start
+	keySet, err := auth.LoadKeySet(cfg.JWTKeysDir, cfg.JWTSigningKeyID, cfg.JWTPrivateKey)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to load JWT keys")
+	}
+	auth.SetKeySet(keySet)
+
+	// Attachment contents, the database only keeps their metadata
+	blobStore, err := storage.New(storage.Config{
+		Driver:            cfg.StorageDriver,
+		LocalDir:          cfg.StorageLocalDir,
+		PublicURL:         cfg.StoragePublicURL,
+		SigningKey:        cfg.StorageSigningKey,
+		S3Endpoint:        cfg.S3Endpoint,
+		S3Region:          cfg.S3Region,
+		S3Bucket:          cfg.S3Bucket,
+		S3AccessKeyID:     cfg.S3AccessKeyID,
+		S3SecretAccessKey: cfg.S3SecretAccessKey,
+		S3PathStyle:       cfg.S3PathStyle,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to set up attachment storage")
+	}
+
 	allowedOrigins := []string{"http://localhost:5173", "http://localhost:3000"}
 
 	// Initialize repositories
 	studentRepository := repository.NewStudentRepository(db)
 	authRepository := repository.NewAuthRepository(db)
+	refreshTokenRepository := repository.NewRefreshTokenRepository(db)
+	accessTokenDenylistRepository := repository.NewAccessTokenDenylistRepository(db)
+	passwordResetRepository := repository.NewPasswordResetRepository(db)
+	emailVerificationRepository := repository.NewEmailVerificationRepository(db)
+	staffProfileRepository := repository.NewStaffProfileRepository(db)
+	userIdentityRepository := repository.NewUserIdentityRepository(db)
+	personalAccessTokenRepository := repository.NewPersonalAccessTokenRepository(db)
+
+	// Failed login counters, Postgres unless configured otherwise
+	var loginAttemptRepository repository.LoginAttemptRepository
+	if cfg.LoginAttemptStore == "memory" {
+		loginAttemptRepository = repository.NewMemoryLoginAttemptRepository()
+	} else {
+		loginAttemptRepository = repository.NewLoginAttemptRepository(db)
+	}
 	practicumRepository := repository.NewPracticumRepository(db)
+	academicTermRepository := repository.NewAcademicTermRepository(db)
 	practicumModuleRepository := repository.NewPracticumModuleRepository(db)
 	practicumModuleContentRepository := repository.NewPracticumModuleContentRepository(db)
 	practicumClassRepository := repository.NewPracticumClassRepository(db)
@@ -45,107 +93,248 @@ func NewServer(cfg *configs.Config, logger zerolog.Logger) *Server {
 	studentClassEnrollmentRepository := repository.NewStudentClassEnrollmentRepository(db)
 	userPracticumProgressRepository := repository.NewUserPracticumProgressRepository(db)
 	userPracticumCheckpointRepository := repository.NewUserPracticumCheckpointRepository(db)
+	practicumStaffRepository := repository.NewPracticumStaffRepository(db)
+	attachmentRepository := repository.NewAttachmentRepository(db)
+	studyPlanRepository := repository.NewStudyPlanRepository(db)
+
+	// Delivers password reset links
+	userNotifier := notifier.New(cfg.Notifier, cfg.NotifierFile)
 
 	// Initialize services
-	studentService := service.NewStudentService(studentRepository)
-	authService := service.NewAuthService(authRepository)
-	practicumService := service.NewPracticumService(practicumRepository)
+	studentService := service.NewStudentService(studentRepository, attachmentRepository)
+	authService := service.NewAuthService(authRepository, refreshTokenRepository, accessTokenDenylistRepository, passwordResetRepository, emailVerificationRepository, staffProfileRepository, personalAccessTokenRepository, loginAttemptRepository, userNotifier, service.AuthOptions{
+		FrontendURL:              cfg.FrontendURL,
+		RequireEmailVerification: cfg.RequireEmailVerification,
+	})
+	academicTermService := service.NewAcademicTermService(academicTermRepository)
+	practicumService := service.NewPracticumService(practicumRepository, academicTermRepository)
 	practicumModuleService := service.NewPracticumModuleService(practicumModuleRepository)
-	practicumModuleContentService := service.NewPracticumModuleContentService(practicumModuleContentRepository)
+	practicumModuleContentService := service.NewPracticumModuleContentService(practicumModuleContentRepository, practicumModuleRepository, attachmentRepository)
 	practicumClassService := service.NewPracticumClassService(practicumClassRepository)
-	studentRegistrationService := service.NewStudentRegistrationService(studentRegistrationRepository)
-	studentClassEnrollmentService := service.NewStudentClassEnrollmentService(studentClassEnrollmentRepository)
-	userPracticumProgressService := service.NewUserPracticumProgressService(userPracticumProgressRepository)
-	userPracticumCheckpointService := service.NewUserPracticumCheckpointService(userPracticumCheckpointRepository)
+	studentRegistrationService := service.NewStudentRegistrationService(studentRegistrationRepository, practicumRepository, academicTermRepository, studyPlanRepository)
+	studentClassEnrollmentService := service.NewStudentClassEnrollmentService(studentClassEnrollmentRepository, practicumClassRepository, studentRegistrationRepository)
+	userPracticumProgressService := service.NewUserPracticumProgressService(userPracticumProgressRepository, studentRepository, studentRegistrationRepository)
+	userPracticumCheckpointService := service.NewUserPracticumCheckpointService(userPracticumCheckpointRepository, studentRepository, studentRegistrationRepository, practicumModuleRepository, practicumModuleContentRepository)
+	practicumStaffService := service.NewPracticumStaffService(practicumStaffRepository, authRepository)
+	roleService := service.NewRoleService(authRepository)
+	attachmentService := service.NewAttachmentService(attachmentRepository, blobStore)
+	studyPlanService := service.NewStudyPlanService(studyPlanRepository, studentRepository, attachmentRepository, practicumRepository)
+	personalAccessTokenService := service.NewPersonalAccessTokenService(personalAccessTokenRepository, authRepository)
 	studentDataService := service.NewStudentDataService(userPracticumCheckpointService, practicumService, practicumModuleService, practicumModuleContentService, studentClassEnrollmentService, practicumClassService, userPracticumProgressService, studentService)
 
+	// Revoked access tokens are rejected by auth.VerifyToken
+	auth.SetDenylist(accessTokenDenylistRepository)
+
 	// Initialize handlers
 	studentHandler := handler.NewStudentHandler(studentService, studentDataService)
 	authHandler := handler.NewAuthHandler(authService)
 	practicumHandler := handler.NewPracticumHandler(practicumService)
+	academicTermHandler := handler.NewAcademicTermHandler(academicTermService)
 	practicumModuleHandler := handler.NewPracticumModuleHandler(practicumModuleService)
 	practicumModuleContentHandler := handler.NewPracticumModuleContentHandler(practicumModuleContentService)
 	practicumClassHandler := handler.NewPracticumClassHandler(practicumClassService)
-	studentRegistrationHandler := handler.NewStudentRegistrationHandler(studentRegistrationService)
-	studentClassEnrollmentHandler := handler.NewStudentClassEnrollmentHandler(studentClassEnrollmentService)
-	userPracticumProgressHandler := handler.NewUserPracticumProgressHandler(userPracticumProgressService)
-	userPracticumCheckpointHandler := handler.NewUserPracticumCheckpointHandler(userPracticumCheckpointService)
+	studentRegistrationHandler := handler.NewStudentRegistrationHandler(studentRegistrationService, studentService)
+	studentClassEnrollmentHandler := handler.NewStudentClassEnrollmentHandler(studentClassEnrollmentService, studentService)
+	userPracticumProgressHandler := handler.NewUserPracticumProgressHandler(userPracticumProgressService, practicumStaffService)
+	userPracticumCheckpointHandler := handler.NewUserPracticumCheckpointHandler(userPracticumCheckpointService, practicumStaffService)
+	practicumStaffHandler := handler.NewPracticumStaffHandler(practicumStaffService)
+	roleHandler := handler.NewRoleHandler(roleService)
+	personalAccessTokenHandler := handler.NewPersonalAccessTokenHandler(personalAccessTokenService)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService, blobStore)
+	studyPlanHandler := handler.NewStudyPlanHandler(studyPlanService, studentService)
 
 	// Initialize main router
 	mux := http.NewServeMux()
 	v1Router := http.NewServeMux()
 
+	// Authorization
+	authMiddleware := middlewares.AuthMiddleware(authService, personalAccessTokenService)
+	// public routes that show more to signed in staff
+	optionalAuth := middlewares.OptionalAuth(authMiddleware)
+	staffOnly := middlewares.RequireRoles(model.RoleAdmin, model.RoleLecturer, model.RoleLaboratoryAssistant)
+	studentOrAdmin := middlewares.RequireRoles(model.RoleStudent, model.RoleAdmin)
+	adminOnly := middlewares.RequireRoles(model.RoleAdmin)
+	// lecturers approve what laboratory assistants submit
+	reviewersOnly := middlewares.RequireRoles(model.RoleAdmin, model.RoleLecturer)
+	// per-record ownership is checked by the progress and checkpoint handlers
+	practicumMembers := middlewares.RequireRoles(model.RoleStudent, model.RoleAdmin, model.RoleLecturer, model.RoleLaboratoryAssistant)
+
+	// Personal access tokens are only accepted on routes marked with the scope they need
+	anyAPIToken := middlewares.AcceptAPITokens()
+	studentsRead := middlewares.AcceptAPITokens(model.ScopeStudentsRead)
+	practicumsRead := middlewares.AcceptAPITokens(model.ScopePracticumsRead)
+	practicumsWrite := middlewares.AcceptAPITokens(model.ScopePracticumsWrite)
+	registrationsWrite := middlewares.AcceptAPITokens(model.ScopeRegistrationsWrite)
+	progressRead := middlewares.AcceptAPITokens(model.ScopeProgressRead)
+	progressWrite := middlewares.AcceptAPITokens(model.ScopeProgressWrite)
+
 	// student
-	v1Router.Handle("GET /students", middlewares.AuthMiddleware(authService)(http.HandlerFunc(studentHandler.GetAllStudents)))
-	v1Router.Handle("GET /students/{id}", middlewares.AuthMiddleware(authService)(http.HandlerFunc(studentHandler.GetStudentById)))
+	v1Router.Handle("GET /students", wrapMiddleware(http.HandlerFunc(studentHandler.GetAllStudents), studentsRead, authMiddleware))
+	v1Router.Handle("GET /students/{id}", wrapMiddleware(http.HandlerFunc(studentHandler.GetStudentById), studentsRead, authMiddleware))
+	// left public: a student profile is created before the account that links to it
 	v1Router.HandleFunc("POST /students", studentHandler.CreateStudent)
-	v1Router.Handle("GET /students/activities", middlewares.AuthMiddleware(authService)(http.HandlerFunc(studentHandler.GetStudentPracticumActivities)))
-	v1Router.Handle("GET /students/schedules", middlewares.AuthMiddleware(authService)(http.HandlerFunc(studentHandler.GetStudentSchedules)))
+	v1Router.Handle("GET /students/activities", wrapMiddleware(http.HandlerFunc(studentHandler.GetStudentPracticumActivities), studentsRead, authMiddleware))
+	v1Router.Handle("GET /students/schedules", wrapMiddleware(http.HandlerFunc(studentHandler.GetStudentSchedules), studentsRead, authMiddleware))
+
+	// study plans (KRS), registration only accepts practicums of an approved plan
+	v1Router.Handle("POST /students/me/study-plans", wrapMiddleware(http.HandlerFunc(studyPlanHandler.SubmitStudyPlan), authMiddleware, studentOrAdmin))
+	// kept for clients of the earlier endpoint, it only links the upload to the student record.
+	// Registration needs a plan submitted for review above.
+	v1Router.Handle("PUT /students/me/study-plan", wrapMiddleware(http.HandlerFunc(studentHandler.SetStudyPlan), authMiddleware, studentOrAdmin))
+	v1Router.Handle("GET /students/me/study-plans", wrapMiddleware(http.HandlerFunc(studyPlanHandler.GetMyStudyPlans), studentsRead, authMiddleware))
+	v1Router.Handle("GET /study-plans", wrapMiddleware(http.HandlerFunc(studyPlanHandler.GetStudyPlans), studentsRead, authMiddleware, staffOnly))
+	v1Router.Handle("GET /study-plans/{id}", wrapMiddleware(http.HandlerFunc(studyPlanHandler.GetStudyPlan), studentsRead, authMiddleware))
+	v1Router.Handle("POST /study-plans/{id}/approve", wrapMiddleware(http.HandlerFunc(studyPlanHandler.ApproveStudyPlan), authMiddleware, staffOnly))
+	v1Router.Handle("POST /study-plans/{id}/reject", wrapMiddleware(http.HandlerFunc(studyPlanHandler.RejectStudyPlan), authMiddleware, staffOnly))
 
 	// student registration
-	v1Router.HandleFunc("POST /student-registrations", studentRegistrationHandler.RegisterStudent)
+	v1Router.Handle("POST /student-registrations", wrapMiddleware(http.HandlerFunc(studentRegistrationHandler.RegisterStudent), registrationsWrite, authMiddleware, studentOrAdmin))
 	v1Router.HandleFunc("GET /students/{student_id}/registrations", studentRegistrationHandler.GetRegistrationsByStudentID)
 	v1Router.HandleFunc("GET /practicums/{practicum_id}/registrations", studentRegistrationHandler.GetRegistrationsByPracticumID)
-	v1Router.HandleFunc("DELETE /student-registrations/{id}", studentRegistrationHandler.DeleteRegistration)
+	v1Router.Handle("DELETE /student-registrations/{id}", wrapMiddleware(http.HandlerFunc(studentRegistrationHandler.DeleteRegistration), registrationsWrite, authMiddleware, studentOrAdmin))
 
 	// student class enrollment
-	v1Router.HandleFunc("POST /student-class-enrollments", studentClassEnrollmentHandler.EnrollStudent)
+	v1Router.Handle("POST /student-class-enrollments", wrapMiddleware(http.HandlerFunc(studentClassEnrollmentHandler.EnrollStudent), registrationsWrite, authMiddleware, studentOrAdmin))
 	v1Router.HandleFunc("GET /students/{student_id}/class-enrollments", studentClassEnrollmentHandler.GetEnrollmentsByStudentID)
 	v1Router.HandleFunc("GET /practicum-classes/{class_id}/enrollments", studentClassEnrollmentHandler.GetEnrollmentsByClassID)
-	v1Router.HandleFunc("DELETE /student-class-enrollments/{id}", studentClassEnrollmentHandler.UnenrollStudent)
+	v1Router.Handle("DELETE /student-class-enrollments/{id}", wrapMiddleware(http.HandlerFunc(studentClassEnrollmentHandler.UnenrollStudent), registrationsWrite, authMiddleware, studentOrAdmin))
+
+	// academic term
+	v1Router.HandleFunc("GET /academic-terms", academicTermHandler.GetAllAcademicTerms)
+	v1Router.HandleFunc("GET /academic-terms/current", academicTermHandler.GetCurrentAcademicTerm)
+	v1Router.HandleFunc("GET /academic-terms/{id}", academicTermHandler.GetAcademicTermByID)
+	v1Router.Handle("POST /academic-terms", wrapMiddleware(http.HandlerFunc(academicTermHandler.CreateAcademicTerm), authMiddleware, adminOnly))
+	v1Router.Handle("PUT /academic-terms/{id}", wrapMiddleware(http.HandlerFunc(academicTermHandler.UpdateAcademicTerm), authMiddleware, adminOnly))
+	v1Router.Handle("POST /academic-terms/{id}/activate", wrapMiddleware(http.HandlerFunc(academicTermHandler.ActivateAcademicTerm), authMiddleware, adminOnly))
 
 	// practicum
 	v1Router.HandleFunc("GET /practicums", practicumHandler.GetAllPracticums)
-	v1Router.HandleFunc("POST /practicums", practicumHandler.CreatePracticum)
+	v1Router.Handle("POST /practicums", wrapMiddleware(http.HandlerFunc(practicumHandler.CreatePracticum), practicumsWrite, authMiddleware, staffOnly))
 	v1Router.HandleFunc("GET /practicums/{id}", practicumHandler.GetPracticumByID)
-	v1Router.HandleFunc("GET /practicums/{practicum_id}/modules-with-materials", practicumHandler.GetPracticumWithMaterialContents)
+	v1Router.Handle("PUT /practicums/{id}", wrapMiddleware(http.HandlerFunc(practicumHandler.UpdatePracticum), practicumsWrite, authMiddleware, staffOnly))
+	v1Router.Handle("PATCH /practicums/{id}", wrapMiddleware(http.HandlerFunc(practicumHandler.PatchPracticum), practicumsWrite, authMiddleware, staffOnly))
+	v1Router.Handle("DELETE /practicums/{id}", wrapMiddleware(http.HandlerFunc(practicumHandler.DeletePracticum), authMiddleware, adminOnly))
+	v1Router.Handle("POST /practicums/{id}/clone", wrapMiddleware(http.HandlerFunc(practicumHandler.ClonePracticum), practicumsWrite, authMiddleware, staffOnly))
+	v1Router.Handle("POST /practicums/{id}/restore", wrapMiddleware(http.HandlerFunc(practicumHandler.RestorePracticum), authMiddleware, adminOnly))
+	v1Router.Handle("GET /practicums/{practicum_id}/modules-with-materials", wrapMiddleware(http.HandlerFunc(practicumHandler.GetPracticumWithMaterialContents), practicumsRead, optionalAuth))
 
 	// practicum module
 	v1Router.HandleFunc("GET /practicums/{practicum_id}/modules", practicumModuleHandler.GetModulesByPracticumID)
-	v1Router.HandleFunc("POST /practicum-modules", practicumModuleHandler.CreateModule)
+	v1Router.Handle("POST /practicum-modules", wrapMiddleware(http.HandlerFunc(practicumModuleHandler.CreateModule), practicumsWrite, authMiddleware, staffOnly))
 	v1Router.HandleFunc("GET /practicum-modules/{id}", practicumModuleHandler.GetModuleByID)
+	v1Router.Handle("PUT /practicum-modules/{id}", wrapMiddleware(http.HandlerFunc(practicumModuleHandler.UpdateModule), practicumsWrite, authMiddleware, staffOnly))
+	v1Router.Handle("DELETE /practicum-modules/{id}", wrapMiddleware(http.HandlerFunc(practicumModuleHandler.DeleteModule), practicumsWrite, authMiddleware, staffOnly))
+	v1Router.Handle("PUT /practicums/{practicum_id}/modules/order", wrapMiddleware(http.HandlerFunc(practicumModuleHandler.ReorderModules), practicumsWrite, authMiddleware, staffOnly))
 
 	// practicum module content
-	v1Router.HandleFunc("POST /practicum-module-contents", practicumModuleContentHandler.CreateContent)
-	v1Router.HandleFunc("GET /practicum-module-contents/{id}", practicumModuleContentHandler.GetContentByID)
-	v1Router.HandleFunc("PUT /practicum-module-contents/{id}", practicumModuleContentHandler.UpdateContentByID)
-	v1Router.HandleFunc("GET /practicum-modules/{module_id}/contents", practicumModuleContentHandler.GetContentsByModuleID)
-	v1Router.HandleFunc("DELETE /practicum-module-contents/{id}", practicumModuleContentHandler.DeleteContentByID)
+	v1Router.Handle("POST /practicum-module-contents", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.CreateContent), practicumsWrite, authMiddleware, staffOnly))
+	v1Router.Handle("GET /practicum-module-contents/{id}", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.GetContentByID), practicumsRead, optionalAuth))
+	v1Router.HandleFunc("GET /content-schema", practicumModuleContentHandler.GetContentSchema)
+	v1Router.Handle("PUT /practicum-module-contents/{id}", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.UpdateContentByID), practicumsWrite, authMiddleware, staffOnly))
+	v1Router.Handle("GET /practicum-modules/{module_id}/contents", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.GetContentsByModuleID), practicumsRead, optionalAuth))
+	v1Router.Handle("PUT /practicum-modules/{module_id}/contents/order", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.ReorderContents), practicumsWrite, authMiddleware, staffOnly))
+	v1Router.Handle("POST /practicum-module-contents/{id}/move", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.MoveContent), practicumsWrite, authMiddleware, staffOnly))
+	v1Router.Handle("DELETE /practicum-module-contents/{id}", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.DeleteContentByID), practicumsWrite, authMiddleware, staffOnly))
+	v1Router.Handle("POST /practicum-module-contents/{id}/submit", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.SubmitContent), practicumsWrite, authMiddleware, staffOnly))
+	v1Router.Handle("POST /practicum-module-contents/{id}/publish", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.PublishContent), practicumsWrite, authMiddleware, reviewersOnly))
+	v1Router.Handle("POST /practicum-module-contents/{id}/reject", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.RejectContent), practicumsWrite, authMiddleware, reviewersOnly))
+	v1Router.Handle("POST /practicum-module-contents/{id}/archive", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.ArchiveContent), practicumsWrite, authMiddleware, reviewersOnly))
+
+	// material versions
+	v1Router.Handle("GET /materials/{material_id}/versions", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.GetContentVersions), practicumsRead, authMiddleware, staffOnly))
+	v1Router.Handle("GET /materials/{material_id}/versions/diff", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.DiffContentVersions), practicumsRead, authMiddleware, staffOnly))
+	v1Router.Handle("GET /materials/{material_id}/versions/{version}", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.GetContentVersion), practicumsRead, authMiddleware, staffOnly))
+	v1Router.Handle("POST /materials/{material_id}/versions/{version}/restore", wrapMiddleware(http.HandlerFunc(practicumModuleContentHandler.RestoreContentVersion), practicumsWrite, authMiddleware, staffOnly))
+
+	// attachments, the purpose decides who may upload and read them
+	v1Router.Handle("POST /attachments", authMiddleware(http.HandlerFunc(attachmentHandler.UploadAttachment)))
+	v1Router.Handle("GET /attachments/{id}", wrapMiddleware(http.HandlerFunc(attachmentHandler.GetAttachment), practicumsRead, optionalAuth))
+	v1Router.Handle("GET /attachments/{id}/download", wrapMiddleware(http.HandlerFunc(attachmentHandler.DownloadAttachment), practicumsRead, optionalAuth))
+	v1Router.Handle("GET /attachments/{id}/download-url", wrapMiddleware(http.HandlerFunc(attachmentHandler.GetAttachmentDownloadURL), practicumsRead, optionalAuth))
+	v1Router.Handle("DELETE /attachments/{id}", authMiddleware(http.HandlerFunc(attachmentHandler.DeleteAttachment)))
+	if attachmentHandler.ServesBlobs() {
+		// signed links of the local store, see storage.LocalBlobPath
+		v1Router.HandleFunc("GET /blobs/{key...}", attachmentHandler.ServeBlob)
+	}
 
 	// practicum class
-	v1Router.HandleFunc("POST /practicum-classes", practicumClassHandler.CreateClass)
+	v1Router.Handle("POST /practicum-classes", wrapMiddleware(http.HandlerFunc(practicumClassHandler.CreateClass), practicumsWrite, authMiddleware, staffOnly))
 	v1Router.HandleFunc("GET /practicum-classes/{id}", practicumClassHandler.GetClassByID)
 	v1Router.HandleFunc("GET /practicums/{practicum_id}/classes", practicumClassHandler.GetClassesByPracticumID)
-	v1Router.HandleFunc("PUT /practicum-classes/{id}", practicumClassHandler.UpdateClass)
-	v1Router.HandleFunc("DELETE /practicum-classes/{id}", practicumClassHandler.DeleteClass)
+	v1Router.Handle("PUT /practicum-classes/{id}", wrapMiddleware(http.HandlerFunc(practicumClassHandler.UpdateClass), practicumsWrite, authMiddleware, staffOnly))
+	v1Router.Handle("DELETE /practicum-classes/{id}", wrapMiddleware(http.HandlerFunc(practicumClassHandler.DeleteClass), practicumsWrite, authMiddleware, staffOnly))
+
+	// practicum staff
+	v1Router.Handle("GET /practicums/{practicum_id}/staff", wrapMiddleware(http.HandlerFunc(practicumStaffHandler.GetStaffByPracticumID), practicumsRead, authMiddleware, staffOnly))
+	v1Router.Handle("POST /practicums/{practicum_id}/staff", wrapMiddleware(http.HandlerFunc(practicumStaffHandler.AssignStaff), authMiddleware, adminOnly))
+	v1Router.Handle("DELETE /practicums/{practicum_id}/staff/{user_id}", wrapMiddleware(http.HandlerFunc(practicumStaffHandler.RemoveStaff), authMiddleware, adminOnly))
 
 	// user practicum progress
-	v1Router.HandleFunc("POST /user-practicum-progress", userPracticumProgressHandler.CreateProgress)
-	v1Router.HandleFunc("GET /user-practicum-progress/{user_id}/{practicum_id}", userPracticumProgressHandler.GetProgress)
-	v1Router.HandleFunc("PUT /user-practicum-progress/{id}", userPracticumProgressHandler.UpdateProgress)
-	v1Router.HandleFunc("PUT /user-practicum-progress/{user_id}/{practicum_id}/complete", userPracticumProgressHandler.MarkAsCompleted)
-	v1Router.HandleFunc("DELETE /user-practicum-progress/{id}", userPracticumProgressHandler.DeleteProgress)
+	v1Router.Handle("POST /user-practicum-progress", wrapMiddleware(http.HandlerFunc(userPracticumProgressHandler.CreateProgress), progressWrite, authMiddleware, practicumMembers))
+	v1Router.Handle("GET /user-practicum-progress/{user_id}/{practicum_id}", wrapMiddleware(http.HandlerFunc(userPracticumProgressHandler.GetProgress), progressRead, authMiddleware))
+	v1Router.Handle("PUT /user-practicum-progress/{id}", wrapMiddleware(http.HandlerFunc(userPracticumProgressHandler.UpdateProgress), progressWrite, authMiddleware, practicumMembers))
+	v1Router.Handle("PUT /user-practicum-progress/{user_id}/{practicum_id}/complete", wrapMiddleware(http.HandlerFunc(userPracticumProgressHandler.MarkAsCompleted), progressWrite, authMiddleware, practicumMembers))
+	v1Router.Handle("DELETE /user-practicum-progress/{id}", wrapMiddleware(http.HandlerFunc(userPracticumProgressHandler.DeleteProgress), progressWrite, authMiddleware, practicumMembers))
 
 	// user practicum checkpoint
-	v1Router.HandleFunc("POST /user-practicum-checkpoints", userPracticumCheckpointHandler.CreateCheckpoint)
-	v1Router.HandleFunc("GET /user-practicum-checkpoints/{user_id}", userPracticumCheckpointHandler.GetCheckpointByUser)
-	v1Router.HandleFunc("GET /user-practicum-checkpoints/{user_id}/{practicum_id}", userPracticumCheckpointHandler.GetCheckpointByUserAndPracticum)
-	v1Router.HandleFunc("PUT /user-practicum-checkpoints/{id}", userPracticumCheckpointHandler.UpdateCheckpoint)
-	v1Router.HandleFunc("DELETE /user-practicum-checkpoints/{id}", userPracticumCheckpointHandler.DeleteCheckpoint)
+	v1Router.Handle("POST /user-practicum-checkpoints", wrapMiddleware(http.HandlerFunc(userPracticumCheckpointHandler.CreateCheckpoint), progressWrite, authMiddleware, practicumMembers))
+	v1Router.Handle("GET /user-practicum-checkpoints/{user_id}", wrapMiddleware(http.HandlerFunc(userPracticumCheckpointHandler.GetCheckpointByUser), progressRead, authMiddleware))
+	v1Router.Handle("GET /user-practicum-checkpoints/{user_id}/{practicum_id}", wrapMiddleware(http.HandlerFunc(userPracticumCheckpointHandler.GetCheckpointByUserAndPracticum), progressRead, authMiddleware))
+	v1Router.Handle("PUT /user-practicum-checkpoints/{id}", wrapMiddleware(http.HandlerFunc(userPracticumCheckpointHandler.UpdateCheckpoint), progressWrite, authMiddleware, practicumMembers))
+	v1Router.Handle("DELETE /user-practicum-checkpoints/{id}", wrapMiddleware(http.HandlerFunc(userPracticumCheckpointHandler.DeleteCheckpoint), progressWrite, authMiddleware, practicumMembers))
 
 	// auth
 	v1Router.HandleFunc("POST /auth/register", authHandler.Register)
 	v1Router.HandleFunc("POST /auth/login", authHandler.Login)
-	v1Router.Handle("POST /auth/refresh-token", middlewares.AuthMiddleware(authService)(http.HandlerFunc(authHandler.RefreshToken)))
-	v1Router.Handle("GET /auth/me", middlewares.AuthMiddleware(authService)(http.HandlerFunc(authHandler.GetCurrentUser)))
+	v1Router.HandleFunc("POST /auth/refresh-token", authHandler.RefreshToken)
+	v1Router.Handle("GET /auth/me", wrapMiddleware(http.HandlerFunc(authHandler.GetCurrentUser), anyAPIToken, authMiddleware))
+	v1Router.Handle("PATCH /auth/me", authMiddleware(http.HandlerFunc(authHandler.UpdateCurrentUser)))
+	v1Router.Handle("PUT /auth/password", authMiddleware(http.HandlerFunc(authHandler.ChangePassword)))
+	v1Router.Handle("POST /auth/logout", authMiddleware(http.HandlerFunc(authHandler.Logout)))
+	v1Router.Handle("POST /auth/logout-all", authMiddleware(http.HandlerFunc(authHandler.LogoutAll)))
+	v1Router.HandleFunc("POST /auth/forgot-password", authHandler.ForgotPassword)
+	v1Router.HandleFunc("POST /auth/reset-password", authHandler.ResetPassword)
+	v1Router.HandleFunc("GET /auth/verify", authHandler.VerifyEmail)
+	v1Router.HandleFunc("POST /auth/resend-verification", authHandler.ResendVerification)
+
+	// single sign-on through the campus identity provider
+	if cfg.OIDCIssuerURL != "" {
+		oidcProvider := oidc.NewProvider(oidc.Config{
+			IssuerURL:    cfg.OIDCIssuerURL,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+		})
+		ssoService := service.NewSSOService(oidcProvider, cfg.OIDCIssuerURL, cfg.OIDCStudentIDClaim, authService, authRepository, studentRepository, userIdentityRepository)
+		ssoHandler := handler.NewSSOHandler(ssoService)
+
+		v1Router.HandleFunc("GET /auth/oidc/login", ssoHandler.Login)
+		v1Router.HandleFunc("GET /auth/oidc/callback", ssoHandler.Callback)
+	}
+
+	// personal access tokens, managed from a session only
+	v1Router.Handle("POST /auth/tokens", authMiddleware(http.HandlerFunc(personalAccessTokenHandler.CreateToken)))
+	v1Router.Handle("GET /auth/tokens", authMiddleware(http.HandlerFunc(personalAccessTokenHandler.GetTokens)))
+	v1Router.Handle("DELETE /auth/tokens/{id}", authMiddleware(http.HandlerFunc(personalAccessTokenHandler.RevokeToken)))
+
+	// user and role management
+	v1Router.Handle("POST /admin/users", wrapMiddleware(http.HandlerFunc(authHandler.CreateUser), authMiddleware, adminOnly))
+	v1Router.Handle("GET /admin/roles", wrapMiddleware(http.HandlerFunc(roleHandler.GetAllRoles), authMiddleware, adminOnly))
+	v1Router.Handle("GET /admin/users/{user_id}/roles", wrapMiddleware(http.HandlerFunc(roleHandler.GetUserRoles), authMiddleware, adminOnly))
+	v1Router.Handle("POST /admin/users/{user_id}/roles", wrapMiddleware(http.HandlerFunc(roleHandler.GrantRole), authMiddleware, adminOnly))
+	v1Router.Handle("DELETE /admin/users/{user_id}/roles/{role}", wrapMiddleware(http.HandlerFunc(roleHandler.RevokeRole), authMiddleware, adminOnly))
 
 	v1Router.HandleFunc("/health", healthCheckHandler)
 
 	// v1
 	mux.Handle("/v1/", http.StripPrefix("/v1", v1Router))
 
+	// Public keys for services verifying our tokens
+	mux.HandleFunc("GET /.well-known/jwks.json", jwksHandler)
+
 	// Wrap the router with middleware
-	handlerWithMiddleware := wrapMiddleware(mux, CORSMiddleware(allowedOrigins), Logger(logger))
+	handlerWithMiddleware := wrapMiddleware(mux, RealIP(cfg.TrustProxyHeaders), CORSMiddleware(allowedOrigins), Logger(logger))
 
 	// Setup HTTP server
 	server := &http.Server{
@@ -197,6 +386,12 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("OK"))
 }
 
+func jwksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(auth.JWKS())
+}
+
 // Middleware chaining function
 func wrapMiddleware(handler http.Handler, middlewares ...middleware) http.Handler {
 	// Apply middlewares in reverse order
@@ -205,3 +400,23 @@ func wrapMiddleware(handler http.Handler, middlewares ...middleware) http.Handle
 	}
 	return handler
 }
+
+// RealIP replaces the remote address with the client address reported by the proxy in front
+// of the server. The last X-Forwarded-For entry is used since it is the one the proxy appended,
+// earlier entries come from the client and can be forged.
+func RealIP(trustProxyHeaders bool) middleware {
+	return func(next http.Handler) http.Handler {
+		if !trustProxyHeaders {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+				addresses := strings.Split(forwarded, ",")
+				if ip := net.ParseIP(strings.TrimSpace(addresses[len(addresses)-1])); ip != nil {
+					r.RemoteAddr = net.JoinHostPort(ip.String(), "0")
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/repository"
+)
+
+var (
+	ErrAcademicTermNotFound  = errors.New("academic term not found")
+	ErrNoActiveAcademicTerm  = errors.New("no academic term is active")
+	ErrAcademicTermCodeTaken = errors.New("an academic term with this code already exists")
+	ErrAcademicTermInvalid   = errors.New("code, name, start and end date are required, the term and its registration window have to end after they start")
+	ErrRegistrationClosed    = errors.New("registration for this academic term is closed")
+)
+
+type AcademicTermService interface {
+	CreateAcademicTerm(term *model.AcademicTerm) error
+	GetAcademicTermByID(id int) (*model.AcademicTerm, error)
+	GetCurrentAcademicTerm() (*model.AcademicTerm, error)
+	GetAllAcademicTerms() ([]model.AcademicTerm, error)
+	UpdateAcademicTerm(term *model.AcademicTerm) error
+	// ActivateAcademicTerm makes the term the one list endpoints default to
+	ActivateAcademicTerm(id int) (*model.AcademicTerm, error)
+}
+
+type academicTermService struct {
+	repo repository.AcademicTermRepository
+}
+
+func NewAcademicTermService(repo repository.AcademicTermRepository) AcademicTermService {
+	return &academicTermService{repo: repo}
+}
+
+func (s *academicTermService) CreateAcademicTerm(term *model.AcademicTerm) error {
+	if err := validateAcademicTerm(term); err != nil {
+		return err
+	}
+	if err := s.checkCodeAvailable(term.Code, 0); err != nil {
+		return err
+	}
+	return s.repo.CreateAcademicTerm(term)
+}
+
+func (s *academicTermService) GetAcademicTermByID(id int) (*model.AcademicTerm, error) {
+	term, err := s.repo.GetAcademicTermByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if term == nil {
+		return nil, ErrAcademicTermNotFound
+	}
+	return term, nil
+}
+
+func (s *academicTermService) GetCurrentAcademicTerm() (*model.AcademicTerm, error) {
+	term, err := s.repo.GetActiveAcademicTerm()
+	if err != nil {
+		return nil, err
+	}
+	if term == nil {
+		return nil, ErrNoActiveAcademicTerm
+	}
+	return term, nil
+}
+
+func (s *academicTermService) GetAllAcademicTerms() ([]model.AcademicTerm, error) {
+	return s.repo.GetAllAcademicTerms()
+}
+
+func (s *academicTermService) UpdateAcademicTerm(term *model.AcademicTerm) error {
+	if err := validateAcademicTerm(term); err != nil {
+		return err
+	}
+	if err := s.checkCodeAvailable(term.Code, term.ID); err != nil {
+		return err
+	}
+
+	updated, err := s.repo.UpdateAcademicTerm(term)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrAcademicTermNotFound
+	}
+	return nil
+}
+
+func (s *academicTermService) ActivateAcademicTerm(id int) (*model.AcademicTerm, error) {
+	activated, err := s.repo.ActivateAcademicTerm(id)
+	if err != nil {
+		return nil, err
+	}
+	if !activated {
+		return nil, ErrAcademicTermNotFound
+	}
+	return s.GetAcademicTermByID(id)
+}
+
+func (s *academicTermService) checkCodeAvailable(code string, id int) error {
+	existing, err := s.repo.GetAcademicTermByCode(code)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != id {
+		return ErrAcademicTermCodeTaken
+	}
+	return nil
+}
+
+func validateAcademicTerm(term *model.AcademicTerm) error {
+	term.Code = strings.TrimSpace(term.Code)
+	term.Name = strings.TrimSpace(term.Name)
+	if term.Code == "" || term.Name == "" || term.StartDate.IsZero() || term.EndDate.IsZero() || !term.EndDate.After(term.StartDate) {
+		return ErrAcademicTermInvalid
+	}
+	if term.RegistrationOpensAt != nil && term.RegistrationClosesAt != nil && !term.RegistrationClosesAt.After(*term.RegistrationOpensAt) {
+		return ErrAcademicTermInvalid
+	}
+	return nil
+}
+
+// resolveAcademicTermID picks the term a list is scoped to: the requested one, every term when
+// allTerms is set, and otherwise the active term. 0 means no scoping, which is also what you get
+// while no term is active. withoutTerm is set when falling back to the active term, rows that
+// predate terms have none and would otherwise drop out of the default lists.
+func resolveAcademicTermID(repo repository.AcademicTermRepository, requested int, allTerms bool) (termID int, withoutTerm bool, err error) {
+	if allTerms {
+		return 0, false, nil
+	}
+	if requested > 0 {
+		return requested, false, nil
+	}
+
+	term, err := repo.GetActiveAcademicTerm()
+	if err != nil {
+		return 0, false, err
+	}
+	if term == nil {
+		return 0, false, nil
+	}
+	return term.ID, true, nil
+}
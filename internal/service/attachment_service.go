@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/repository"
+	"github.com/egasa21/si-lab-api-go/internal/storage"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrAttachmentNotFound       = errors.New("attachment not found")
+	ErrAttachmentPurposeInvalid = errors.New("purpose must be material or study_plan")
+	ErrAttachmentTooLarge       = errors.New("the file is larger than allowed for this purpose")
+	ErrAttachmentTypeNotAllowed = errors.New("this file type is not allowed for this purpose")
+	ErrAttachmentEmpty          = errors.New("the file is empty")
+	ErrAttachmentInUse          = errors.New("the attachment is used by a study plan or module content and can't be deleted")
+)
+
+// DownloadURLExpiry is how long signed download links stay valid
+const DownloadURLExpiry = 15 * time.Minute
+
+// attachmentPolicy limits what can be uploaded for a purpose
+type attachmentPolicy struct {
+	maxSize      int64
+	contentTypes []string
+}
+
+var attachmentPolicies = map[model.AttachmentPurpose]attachmentPolicy{
+	model.AttachmentMaterial: {
+		maxSize: 50 << 20,
+		contentTypes: []string{
+			"application/pdf", "application/zip", "application/x-gzip", "text/plain",
+			"image/png", "image/jpeg", "image/gif", "image/webp", "video/mp4",
+		},
+	},
+	model.AttachmentStudyPlan: {
+		maxSize:      5 << 20,
+		contentTypes: []string{"application/pdf", "image/png", "image/jpeg"},
+	},
+}
+
+// MaxAttachmentSize is the largest upload of any purpose, for limiting request bodies
+func MaxAttachmentSize() int64 {
+	var max int64
+	for _, policy := range attachmentPolicies {
+		if policy.maxSize > max {
+			max = policy.maxSize
+		}
+	}
+	return max
+}
+
+type AttachmentService interface {
+	Upload(ctx context.Context, purpose model.AttachmentPurpose, filename string, body io.Reader, uploaderID int) (*model.Attachment, error)
+	GetAttachment(id uuid.UUID) (*model.Attachment, error)
+	DownloadURL(ctx context.Context, attachment *model.Attachment) (*model.AttachmentDownload, error)
+	DeleteAttachment(ctx context.Context, id uuid.UUID) error
+}
+
+type attachmentService struct {
+	repo  repository.AttachmentRepository
+	store storage.BlobStore
+}
+
+func NewAttachmentService(repo repository.AttachmentRepository, store storage.BlobStore) AttachmentService {
+	return &attachmentService{repo: repo, store: store}
+}
+
+// Upload spools the body to a temporary file while hashing it, checks size and type against
+// the purpose and only then hands it to the store with its checksum
+func (s *attachmentService) Upload(ctx context.Context, purpose model.AttachmentPurpose, filename string, body io.Reader, uploaderID int) (*model.Attachment, error) {
+	policy, ok := attachmentPolicies[purpose]
+	if !ok {
+		return nil, ErrAttachmentPurposeInvalid
+	}
+
+	spool, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}()
+
+	hash := sha256.New()
+	// One byte over the limit is enough to tell the file is too large
+	size, err := io.Copy(io.MultiWriter(spool, hash), io.LimitReader(body, policy.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > policy.maxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+	if size == 0 {
+		return nil, ErrAttachmentEmpty
+	}
+
+	// The type is sniffed from the content, the client's Content-Type isn't trusted
+	head := make([]byte, 512)
+	n, err := spool.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if !containsString(policy.contentTypes, contentType) {
+		return nil, ErrAttachmentTypeNotAllowed
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	id := uuid.New()
+	attachment := &model.Attachment{
+		ID:             id,
+		Purpose:        purpose,
+		StorageKey:     string(purpose) + "/" + id.String(),
+		Filename:       cleanFilename(filename),
+		ContentType:    contentType,
+		Size:           size,
+		ChecksumSHA256: hex.EncodeToString(hash.Sum(nil)),
+		UploadedBy:     &uploaderID,
+	}
+
+	object := storage.Object{Key: attachment.StorageKey, ContentType: contentType, Size: size, SHA256: attachment.ChecksumSHA256}
+	if err := s.store.Put(ctx, object, spool); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateAttachment(attachment); err != nil {
+		// Don't leave a blob nothing points at
+		if deleteErr := s.store.Delete(ctx, attachment.StorageKey); deleteErr != nil {
+			log.Error().Err(deleteErr).Str("key", attachment.StorageKey).Msg("Failed to remove orphaned blob")
+		}
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) GetAttachment(id uuid.UUID) (*model.Attachment, error) {
+	attachment, err := s.repo.GetAttachmentByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil {
+		return nil, ErrAttachmentNotFound
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) DownloadURL(ctx context.Context, attachment *model.Attachment) (*model.AttachmentDownload, error) {
+	expiresAt := time.Now().Add(DownloadURLExpiry)
+	url, err := s.store.SignedURL(ctx, attachment.StorageKey, DownloadURLExpiry, attachment.Filename)
+	if err != nil {
+		return nil, err
+	}
+	return &model.AttachmentDownload{URL: url, ExpiresAt: expiresAt}, nil
+}
+
+func (s *attachmentService) DeleteAttachment(ctx context.Context, id uuid.UUID) error {
+	attachment, err := s.GetAttachment(id)
+	if err != nil {
+		return err
+	}
+
+	// Submitted study plans keep their file for the review record, content keeps its download
+	// links working and every version of it restorable
+	inUse, err := s.repo.AttachmentInUse(id)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrAttachmentInUse
+	}
+
+	deleted, err := s.repo.DeleteAttachment(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAttachmentNotFound
+	}
+
+	// The row is gone, a blob left behind only costs space
+	if err := s.store.Delete(ctx, attachment.StorageKey); err != nil {
+		log.Error().Err(err).Str("key", attachment.StorageKey).Msg("Failed to delete blob")
+	}
+	return nil
+}
+
+// cleanFilename keeps the base name without control characters, it ends up in headers
+func cleanFilename(filename string) string {
+	filename = filepath.Base(strings.ReplaceAll(filename, "\\", "/"))
+	filename = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, filename)
+	if filename == "" || filename == "." || filename == "/" {
+		return "file"
+	}
+	return filename
+}
@@ -1,144 +1,586 @@
 package service
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
+	"net/mail"
+	"strings"
+	"time"
 
 	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/notifier"
 	"github.com/egasa21/si-lab-api-go/internal/repository"
 	"github.com/egasa21/si-lab-api-go/pkg/auth"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrResetTokenInvalid   = errors.New("invalid or expired reset token")
+	ErrInvalidPassword     = errors.New("current password is incorrect")
+	ErrEmailTaken          = errors.New("email already registered")
+	ErrInvalidEmail        = errors.New("invalid email address")
+	ErrEmailNotVerified    = errors.New("email address has not been verified")
+	ErrVerifyTokenInvalid  = errors.New("invalid or expired verification token")
+	ErrRolesRequired       = errors.New("at least one role is required")
+	ErrStaffProfileInvalid = errors.New("staff profile requires a name and an employee ID number")
+	ErrEmployeeIDTaken     = errors.New("employee ID number already registered")
+	ErrStudentLinked       = errors.New("this student record already belongs to an account")
+)
+
+const (
+	passwordHashCost      = 14
+	passwordResetTokenTTL = 30 * time.Minute
+	emailVerifyTokenTTL   = 24 * time.Hour
+	// dummyPasswordHash is a hash of a random password at passwordHashCost, compared against on
+	// logins of unknown emails so they take as long as logins with a wrong password
+	dummyPasswordHash = "$2a$14$FApnLBbt/4bu1gN4gcs/8ONyVP.ATNYu9DidUd6JFHxoUy3eslswe"
+)
+
 type AuthService interface {
-	Register(user *model.User, roles []string) error
-	Login(email, password string) (*auth.TokenDetails, error)
+	Register(user *model.User) error
+	CreateUser(user *model.User, roles []string, staffProfile *model.StaffProfile) (*model.User, error)
+	Login(email, password, clientIP string) (*auth.TokenDetails, error)
 	GetUserByID(id int) (*model.User, error)
+	StartSession(userID int) (*auth.TokenDetails, error)
 	RefreshToken(oldToken string) (*auth.TokenDetails, error)
+	Logout(userID int, accessTokenID string, accessTokenExpiresAt time.Time, refreshToken string) error
+	LogoutAll(userID int, accessTokenID string, accessTokenExpiresAt time.Time) error
+	ForgotPassword(email string) error
+	ResetPassword(token, newPassword string) error
+	ChangePassword(userID int, currentPassword, newPassword string) (*auth.TokenDetails, error)
+	UpdateEmail(userID int, currentPassword, newEmail string) (*model.User, error)
+	VerifyEmail(token string) error
+	ResendVerification(email string) error
+}
+
+// AuthOptions holds the configurable behaviour of the auth service
+type AuthOptions struct {
+	// FrontendURL is the base of the links sent to users
+	FrontendURL string
+	// RequireEmailVerification rejects logins of accounts whose email is not verified yet
+	RequireEmailVerification bool
 }
 
 type authService struct {
-	repo repository.AuthRepository
+	repo                  repository.AuthRepository
+	refreshTokenRepo      repository.RefreshTokenRepository
+	denylistRepo          repository.AccessTokenDenylistRepository
+	passwordResetRepo     repository.PasswordResetRepository
+	emailVerificationRepo repository.EmailVerificationRepository
+	staffProfileRepo      repository.StaffProfileRepository
+	patRepo               repository.PersonalAccessTokenRepository
+	loginThrottle         *loginThrottle
+	notifier              notifier.Notifier
+	options               AuthOptions
 }
 
-func NewAuthService(repo repository.AuthRepository) AuthService {
-	return &authService{repo: repo}
+func NewAuthService(repo repository.AuthRepository, refreshTokenRepo repository.RefreshTokenRepository, denylistRepo repository.AccessTokenDenylistRepository, passwordResetRepo repository.PasswordResetRepository, emailVerificationRepo repository.EmailVerificationRepository, staffProfileRepo repository.StaffProfileRepository, patRepo repository.PersonalAccessTokenRepository, loginAttemptRepo repository.LoginAttemptRepository, userNotifier notifier.Notifier, options AuthOptions) AuthService {
+	return &authService{
+		repo:                  repo,
+		refreshTokenRepo:      refreshTokenRepo,
+		denylistRepo:          denylistRepo,
+		passwordResetRepo:     passwordResetRepo,
+		emailVerificationRepo: emailVerificationRepo,
+		staffProfileRepo:      staffProfileRepo,
+		patRepo:               patRepo,
+		loginThrottle:         &loginThrottle{repo: loginAttemptRepo},
+		notifier:              userNotifier,
+		options:               options,
+	}
 }
 
-func (s *authService) Register(user *model.User, roles []string) error {
-	// Check if the email is already registered
-	existingUser, _ := s.repo.GetUserByEmail(user.Email)
-	if existingUser != nil {
-		return errors.New("email already registered")
+// Register creates a student account, privileged accounts can only be made through CreateUser
+func (s *authService) Register(user *model.User) error {
+	// Otherwise anyone could sign up as a student who already has an account
+	if user.IDStudent != 0 {
+		linked, err := s.repo.IsStudentLinked(user.IDStudent)
+		if err != nil {
+			return err
+		}
+		if linked {
+			return ErrStudentLinked
+		}
 	}
+	return s.createUser(user, []string{string(model.RoleStudent)}, nil)
+}
 
-	if user.Password == "" {
-		return errors.New("password cannot be empty")
+// CreateUser creates an account with the given roles on behalf of an admin, optionally
+// with the staff profile of a lecturer or laboratory assistant
+func (s *authService) CreateUser(user *model.User, roles []string, staffProfile *model.StaffProfile) (*model.User, error) {
+	if len(roles) == 0 {
+		return nil, ErrRolesRequired
 	}
 
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), 14)
+	if staffProfile != nil {
+		staffProfile.Name = strings.TrimSpace(staffProfile.Name)
+		staffProfile.EmployeeIDNumber = strings.TrimSpace(staffProfile.EmployeeIDNumber)
+		if staffProfile.Name == "" || staffProfile.EmployeeIDNumber == "" {
+			return nil, ErrStaffProfileInvalid
+		}
+
+		existing, err := s.staffProfileRepo.GetStaffProfileByEmployeeIDNumber(staffProfile.EmployeeIDNumber)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, ErrEmployeeIDTaken
+		}
+	}
+
+	if err := s.createUser(user, roles, staffProfile); err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.GetUserByID(user.IDUser)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	user.Password = string(hashedPassword)
+	created.StaffProfile = staffProfile
 
-	// Create the user and get the ID
-	if err := s.repo.Register(user); err != nil {
-		return fmt.Errorf("failed to register user: %w", err)
+	return created, nil
+}
+
+// createUser resolves the roles before anything is written, then stores the account with its
+// roles and staff profile in one transaction so a failure leaves no partial account behind
+func (s *authService) createUser(user *model.User, roles []string, staffProfile *model.StaffProfile) error {
+	// Emails are stored lowercase, users.email has a unique index on LOWER(email)
+	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+	taken, err := s.repo.IsEmailTaken(user.Email, 0)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrEmailTaken
 	}
 
-	// Assign default role of "student" if no roles are provided
-	if len(roles) == 0 {
-		roles = []string{"student"}
+	if user.Password == "" {
+		return errors.New("password cannot be empty")
 	}
 
-	// Assign roles to the user
+	// Look up the roles by name, ids differ between databases
+	roleIDs := make([]int, 0, len(roles))
 	for _, role := range roles {
-		// Get the role ID from the role name
-		roleID, err := s.getRoleIDByName(role)
+		roleModel, err := s.repo.GetRoleByName(role)
 		if err != nil {
 			return fmt.Errorf("failed to get role ID for role %s: %w", role, err)
 		}
+		if roleModel == nil {
+			return fmt.Errorf("%w: %s", ErrRoleNotFound, role)
+		}
+		roleIDs = append(roleIDs, roleModel.ID)
+	}
+
+	// Hash password
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), passwordHashCost)
+	if err != nil {
+		return err
+	}
+	user.Password = string(hashedPassword)
 
-		// Insert the role into the user_roles table
-		if err := s.repo.AddRoleToUser(user.IDUser, roleID); err != nil {
-			return fmt.Errorf("failed to assign role %s to user: %w", role, err)
+	if err := s.repo.CreateUser(user, roleIDs, staffProfile); err != nil {
+		// Another request may have registered the email since the check above
+		if taken, takenErr := s.repo.IsEmailTaken(user.Email, 0); takenErr == nil && taken {
+			return ErrEmailTaken
 		}
+		return err
+	}
+
+	// The account exists at this point, a failed email can be retried through resend-verification
+	if err := s.sendVerification(user.IDUser, user.Email); err != nil {
+		log.Error().Err(err).Int("user_id", user.IDUser).Msg("Failed to send verification email")
 	}
 
 	return nil
 }
 
-func (s *authService) Login(email, password string) (*auth.TokenDetails, error) {
+// Login checks the lockout before doing any bcrypt work, failed attempts are counted per
+// email and per client IP
+func (s *authService) Login(email, password, clientIP string) (*auth.TokenDetails, error) {
+	if err := s.loginThrottle.check(email, clientIP); err != nil {
+		return nil, err
+	}
+
 	// Get user by email
 	user, err := s.repo.GetUserByEmail(email)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Spend the same bcrypt work, otherwise the response time tells which emails are registered
+		bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(password))
+		s.loginThrottle.recordFailure(email, clientIP)
+		return nil, ErrInvalidCredentials
+	}
+	// A failing lookup says nothing about the credentials and doesn't count as an attempt
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		return nil, err
 	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		// fmt.Println("Stored Password:", user.Password)
-		// fmt.Println("Input Password:", password)
-		// fmt.Printf("Password comparison failed: %v\n", err)
-		return nil, errors.New("invalid email or password")
+		s.loginThrottle.recordFailure(email, clientIP)
+		return nil, ErrInvalidCredentials
 	}
 
+	s.loginThrottle.reset(email)
+
+	if s.options.RequireEmailVerification && user.VerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	// Every login starts a new refresh token family
+	return s.issueTokens(user, uuid.New())
+}
+
+func (s *authService) GetUserByID(id int) (*model.User, error) {
+	return s.repo.GetUserByID(id)
+}
+
+// StartSession issues tokens for a user that was authenticated elsewhere, e.g. through single sign-on
+func (s *authService) StartSession(userID int) (*auth.TokenDetails, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return s.issueTokens(user, uuid.New())
+}
+
+func (s *authService) RefreshToken(oldToken string) (*auth.TokenDetails, error) {
+	if _, err := auth.VerifyRefreshToken(oldToken); err != nil {
+		return nil, err
+	}
+
+	stored, err := s.refreshTokenRepo.GetRefreshTokenByHash(auth.HashToken(oldToken))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	// A rotated token being presented again means it leaked, so the whole family is revoked
+	if stored.RevokedAt != nil {
+		return nil, s.revokeReusedFamily(stored)
+	}
+
+	rotated, err := s.refreshTokenRepo.RevokeRefreshToken(stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// another request rotated the same token first
+		return nil, s.revokeReusedFamily(stored)
+	}
+
+	// Get user roles
+	user, err := s.repo.GetUserByID(stored.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	return s.issueTokens(user, stored.FamilyID)
+}
+
+// issueTokens generates a new token pair and stores the refresh token in the given family
+func (s *authService) issueTokens(user *model.User, familyID uuid.UUID) (*auth.TokenDetails, error) {
 	// Generate JWT (access and refresh tokens)
 	tokens, err := auth.GenerateJWT(user.IDUser, user.Roles)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate tokens: %w", err)
 	}
 
+	accessJTI, err := uuid.Parse(tokens.AccessTokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tokens: %w", err)
+	}
+
+	refreshToken := &model.RefreshToken{
+		UserID:    user.IDUser,
+		TokenHash: auth.HashToken(tokens.RefreshToken),
+		FamilyID:  familyID,
+		AccessJTI: &accessJTI,
+		ExpiresAt: tokens.RefreshTokenExpiresAt,
+	}
+	if err := s.refreshTokenRepo.CreateRefreshToken(refreshToken); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
 	return tokens, nil
 }
 
-func (s *authService) GetUserByID(id int) (*model.User, error) {
-	return s.repo.GetUserByID(id)
+func (s *authService) revokeReusedFamily(token *model.RefreshToken) error {
+	log.Warn().Int("user_id", token.UserID).Str("family_id", token.FamilyID.String()).Msg("Refresh token reuse detected, revoking family")
+	if err := s.refreshTokenRepo.RevokeFamily(token.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
 }
 
-func (s *authService) getRoleIDByName(name string) (int, error) {
-	roleMapping := map[string]int{
-		"admin":                1,
-		"student":              2,
-		"lecturer":             3,
-		"laboratory_assistant": 4,
+// Logout denies the current access token and ends the session the refresh token belongs to
+func (s *authService) Logout(userID int, accessTokenID string, accessTokenExpiresAt time.Time, refreshToken string) error {
+	if accessTokenID != "" {
+		if err := s.denylistRepo.DenyAccessToken(accessTokenID, accessTokenExpiresAt); err != nil {
+			return err
+		}
 	}
-	id, exists := roleMapping[name]
-	if !exists {
-		return 0, errors.New("invalid role")
+
+	if refreshToken == "" {
+		return nil
 	}
-	return id, nil
+
+	stored, err := s.refreshTokenRepo.GetRefreshTokenByHash(auth.HashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if stored == nil || stored.UserID != userID {
+		return ErrRefreshTokenInvalid
+	}
+
+	return s.refreshTokenRepo.RevokeFamily(stored.FamilyID)
 }
 
-func (s *authService) RefreshToken(oldToken string) (*auth.TokenDetails, error) {
-	claims, err := auth.VerifyToken(oldToken)
+// LogoutAll ends every session of the user, including the access tokens still in flight
+func (s *authService) LogoutAll(userID int, accessTokenID string, accessTokenExpiresAt time.Time) error {
+	if accessTokenID != "" {
+		if err := s.denylistRepo.DenyAccessToken(accessTokenID, accessTokenExpiresAt); err != nil {
+			return err
+		}
+	}
+
+	return s.revokeAllSessions(userID)
+}
+
+// revokeAllSessions revokes every refresh token and personal access token of the user and denies
+// the access tokens issued with the refresh tokens
+func (s *authService) revokeAllSessions(userID int) error {
+	// Every access token is issued with a refresh token, so the refresh tokens created within the
+	// access token lifetime cover every access token that may still be valid, rotated ones included
+	issued, err := s.refreshTokenRepo.GetRefreshTokensIssuedSince(userID, time.Now().Add(-auth.AccessTokenExpiration))
+	if err != nil {
+		return err
+	}
+
+	for _, token := range issued {
+		if token.AccessJTI == nil {
+			continue
+		}
+		if err := s.denylistRepo.DenyAccessToken(token.AccessJTI.String(), token.CreatedAt.Add(auth.AccessTokenExpiration)); err != nil {
+			return err
+		}
+	}
+
+	if err := s.refreshTokenRepo.RevokeAllByUserID(userID); err != nil {
+		return err
+	}
+	// A leaked password may have been used to mint tokens that outlive the sessions
+	return s.patRepo.RevokeAllByUserID(userID)
+}
+
+// ForgotPassword sends a single-use reset link. Unknown emails are ignored so callers
+// can't tell which addresses are registered.
+func (s *authService) ForgotPassword(email string) error {
+	user, err := s.repo.GetUserByEmail(email)
+	if err != nil {
+		log.Info().Msg("Password reset requested for unknown email")
+		return nil
+	}
+
+	// Only the most recent link stays usable
+	if err := s.passwordResetRepo.InvalidatePasswordResetTokensByUserID(user.IDUser); err != nil {
+		return err
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	resetToken := &model.PasswordResetToken{
+		UserID:    user.IDUser,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTokenTTL),
+	}
+	if err := s.passwordResetRepo.CreatePasswordResetToken(resetToken); err != nil {
+		return err
+	}
+
+	return s.notifier.Notify(notifier.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Use the link below to reset your password. It expires in %d minutes.\n\n%s/reset-password?token=%s",
+			int(passwordResetTokenTTL.Minutes()), s.options.FrontendURL, token),
+	})
+}
+
+// ResetPassword consumes a reset token, sets the new password and ends every existing session
+func (s *authService) ResetPassword(token, newPassword string) error {
+	if newPassword == "" {
+		return errors.New("password cannot be empty")
+	}
+
+	resetToken, err := s.passwordResetRepo.GetPasswordResetTokenByHash(auth.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if resetToken == nil || resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		return ErrResetTokenInvalid
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), passwordHashCost)
+	if err != nil {
+		return err
+	}
+
+	// A failed update leaves the token usable, a token used concurrently changes nothing
+	consumed, err := s.passwordResetRepo.ResetPassword(resetToken.ID, string(hashedPassword))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrResetTokenInvalid
+	}
+
+	return s.revokeAllSessions(resetToken.UserID)
+}
+
+// ChangePassword replaces the password after checking the current one. Every session is ended
+// and a fresh token pair is returned for the caller.
+func (s *authService) ChangePassword(userID int, currentPassword, newPassword string) (*auth.TokenDetails, error) {
+	if newPassword == "" {
+		return nil, errors.New("password cannot be empty")
+	}
+
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), passwordHashCost)
 	if err != nil {
 		return nil, err
 	}
 
-	userIDFloat, ok := claims["user_id"].(float64)
-	if !ok {
-		return nil, errors.New("invalid token payload")
+	if err := s.repo.UpdatePassword(user.IDUser, string(hashedPassword)); err != nil {
+		return nil, err
+	}
+
+	if err := s.revokeAllSessions(user.IDUser); err != nil {
+		return nil, err
 	}
-	userID := int(userIDFloat)
 
-	// Token reuse detection logic could go here if storing refresh tokens in DB
+	return s.issueTokens(user, uuid.New())
+}
+
+// UpdateEmail changes the login email, the current password is required to confirm the change
+func (s *authService) UpdateEmail(userID int, currentPassword, newEmail string) (*model.User, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(newEmail))
+	if err != nil {
+		return nil, ErrInvalidEmail
+	}
+	// Only the address is stored, without a display name and in lower case
+	newEmail = strings.ToLower(addr.Address)
 
-	// Get user roles
 	user, err := s.repo.GetUserByID(userID)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
 
-	// Generate new access + refresh tokens
-	newTokens, err := auth.GenerateJWT(user.IDUser, user.Roles)
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	if strings.EqualFold(user.Email, newEmail) {
+		return user, nil
+	}
+
+	taken, err := s.repo.IsEmailTaken(newEmail, user.IDUser)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate new tokens: %w", err)
+		return nil, err
+	}
+	if taken {
+		return nil, ErrEmailTaken
+	}
+
+	if err := s.repo.UpdateEmail(user.IDUser, newEmail); err != nil {
+		return nil, err
+	}
+
+	// The new address starts unverified
+	if err := s.sendVerification(user.IDUser, newEmail); err != nil {
+		log.Error().Err(err).Int("user_id", user.IDUser).Msg("Failed to send verification email")
 	}
 
-	// TODO: mark old refresh token as used/revoked if stored
+	return s.repo.GetUserByID(user.IDUser)
+}
+
+// sendVerification issues a verification token bound to the given address and mails the link to it
+func (s *authService) sendVerification(userID int, email string) error {
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	verifyToken := &model.EmailVerificationToken{
+		UserID:    userID,
+		Email:     email,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: time.Now().Add(emailVerifyTokenTTL),
+	}
+	if err := s.emailVerificationRepo.CreateEmailVerificationToken(verifyToken); err != nil {
+		return err
+	}
+
+	return s.notifier.Notify(notifier.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Use the link below to verify your email address. It expires in %d hours.\n\n%s/verify-email?token=%s",
+			int(emailVerifyTokenTTL.Hours()), s.options.FrontendURL, token),
+	})
+}
+
+// VerifyEmail consumes a verification token. Tokens sent to an address the user has since
+// replaced are rejected.
+func (s *authService) VerifyEmail(token string) error {
+	verifyToken, err := s.emailVerificationRepo.GetEmailVerificationTokenByHash(auth.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if verifyToken == nil || verifyToken.UsedAt != nil || time.Now().After(verifyToken.ExpiresAt) {
+		return ErrVerifyTokenInvalid
+	}
+
+	consumed, err := s.emailVerificationRepo.MarkEmailVerificationTokenUsed(verifyToken.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrVerifyTokenInvalid
+	}
+
+	verified, err := s.repo.MarkEmailVerified(verifyToken.UserID, verifyToken.Email)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return ErrVerifyTokenInvalid
+	}
+	return nil
+}
+
+// ResendVerification sends a new link to an unverified account. Unknown and already verified
+// emails are ignored so callers can't tell which addresses are registered.
+func (s *authService) ResendVerification(email string) error {
+	user, err := s.repo.GetUserByEmail(email)
+	if err != nil || user.VerifiedAt != nil {
+		return nil
+	}
 
-	return newTokens, nil
+	return s.sendVerification(user.IDUser, user.Email)
 }
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/google/uuid"
+)
+
+// renderCacheSize bounds the rendered bodies kept in memory, each content has at most one per format
+// for the latest revision and one for the published version
+const renderCacheSize = 1000
+
+type renderCacheKey struct {
+	contentID int
+	format    model.ContentFormat
+	published bool
+}
+
+type renderCacheEntry struct {
+	updatedAt time.Time
+	body      string
+}
+
+// renderCache keeps rendered content per process. Entries are tagged with the updated_at of what
+// was rendered, so a change made through another instance is never served stale.
+type renderCache struct {
+	mu      sync.RWMutex
+	entries map[renderCacheKey]renderCacheEntry
+}
+
+func newRenderCache() *renderCache {
+	return &renderCache{entries: map[renderCacheKey]renderCacheEntry{}}
+}
+
+func (c *renderCache) get(contentID int, format model.ContentFormat, published bool, updatedAt time.Time) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[renderCacheKey{contentID, format, published}]
+	if !ok || !entry.updatedAt.Equal(updatedAt) {
+		return "", false
+	}
+	return entry.body, true
+}
+
+func (c *renderCache) put(contentID int, format model.ContentFormat, published bool, updatedAt time.Time, body string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= renderCacheSize {
+		// Dropping everything is crude but keeps the cache bounded, it refills on the next reads
+		c.entries = map[renderCacheKey]renderCacheEntry{}
+	}
+	c.entries[renderCacheKey{contentID, format, published}] = renderCacheEntry{updatedAt: updatedAt, body: body}
+}
+
+func (c *renderCache) invalidate(contentID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, format := range []model.ContentFormat{model.ContentFormatHTML, model.ContentFormatMarkdown} {
+		delete(c.entries, renderCacheKey{contentID, format, false})
+		delete(c.entries, renderCacheKey{contentID, format, true})
+	}
+}
+
+// renderContent renders the stored blocks to HTML or Markdown. Blocks of an unknown type and
+// media with a non http(s) address are left out, older content may predate the block schema.
+func renderContent(content json.RawMessage, format model.ContentFormat) (string, error) {
+	var document model.ContentDocument
+	if err := json.Unmarshal(content, &document); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrContentNotRenderable, err)
+	}
+
+	var out strings.Builder
+	for _, block := range document.Blocks {
+		switch format {
+		case model.ContentFormatHTML:
+			renderBlockHTML(&out, block)
+		case model.ContentFormatMarkdown:
+			renderBlockMarkdown(&out, block)
+		}
+	}
+	return out.String(), nil
+}
+
+// Every value is escaped and no markup is taken from the content, which is what keeps the HTML safe
+func renderBlockHTML(out *strings.Builder, block model.ContentBlock) {
+	switch block.Type {
+	case model.BlockHeading:
+		level := block.Level
+		if level < 1 || level > 6 {
+			level = 2
+		}
+		fmt.Fprintf(out, "<h%d>%s</h%d>\n", level, htmlText(block.Text), level)
+	case model.BlockParagraph:
+		fmt.Fprintf(out, "<p>%s</p>\n", htmlText(block.Text))
+	case model.BlockCode:
+		if language := codeLanguage(block.Language); language != "" {
+			fmt.Fprintf(out, "<pre data-language=\"%s\"><code class=\"language-%s\">%s</code></pre>\n", language, language, html.EscapeString(block.Code))
+		} else {
+			fmt.Fprintf(out, "<pre><code>%s</code></pre>\n", html.EscapeString(block.Code))
+		}
+	case model.BlockImage:
+		if !isWebURL(block.URL) {
+			return
+		}
+		out.WriteString("<figure>")
+		fmt.Fprintf(out, "<img src=\"%s\" alt=\"%s\" loading=\"lazy\">", html.EscapeString(block.URL), html.EscapeString(block.Alt))
+		writeCaptionHTML(out, block.Caption)
+		out.WriteString("</figure>\n")
+	case model.BlockVideo:
+		if !isWebURL(block.URL) {
+			return
+		}
+		out.WriteString("<figure>")
+		fmt.Fprintf(out, "<video controls preload=\"metadata\" src=\"%s\"></video>", html.EscapeString(block.URL))
+		writeCaptionHTML(out, block.Caption)
+		out.WriteString("</figure>\n")
+	case model.BlockQuiz:
+		// The answer and explanation stay out of the rendering, it is read by students
+		out.WriteString("<section class=\"quiz\">")
+		fmt.Fprintf(out, "<p class=\"quiz-question\">%s</p><ol class=\"quiz-options\">", htmlText(block.Question))
+		for _, option := range block.Options {
+			fmt.Fprintf(out, "<li>%s</li>", html.EscapeString(option))
+		}
+		out.WriteString("</ol></section>\n")
+	case model.BlockCallout:
+		fmt.Fprintf(out, "<aside class=\"callout callout-%s\">", calloutVariant(block.Variant))
+		if block.Title != "" {
+			fmt.Fprintf(out, "<p class=\"callout-title\">%s</p>", html.EscapeString(block.Title))
+		}
+		fmt.Fprintf(out, "<p>%s</p></aside>\n", htmlText(block.Text))
+	case model.BlockAttachment:
+		href, ok := attachmentDownloadPath(block.AttachmentID)
+		if !ok {
+			return
+		}
+		fmt.Fprintf(out, "<figure class=\"attachment\"><a href=\"%s\" download>%s</a>", href, html.EscapeString(attachmentLabel(block.Title)))
+		writeCaptionHTML(out, block.Caption)
+		out.WriteString("</figure>\n")
+	}
+}
+
+// attachmentDownloadPath is the API route that redirects to a signed download link
+func attachmentDownloadPath(attachmentID string) (string, bool) {
+	id, err := uuid.Parse(attachmentID)
+	if err != nil {
+		return "", false
+	}
+	return "/v1/attachments/" + id.String() + "/download", true
+}
+
+func attachmentLabel(title string) string {
+	if strings.TrimSpace(title) == "" {
+		return "Download attachment"
+	}
+	return title
+}
+
+func writeCaptionHTML(out *strings.Builder, caption string) {
+	if caption != "" {
+		fmt.Fprintf(out, "<figcaption>%s</figcaption>", html.EscapeString(caption))
+	}
+}
+
+// htmlText escapes text and keeps its line breaks
+func htmlText(text string) string {
+	return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>")
+}
+
+var markdownCalloutAlerts = map[string]string{
+	"info":    "NOTE",
+	"tip":     "TIP",
+	"warning": "WARNING",
+	"danger":  "CAUTION",
+}
+
+func renderBlockMarkdown(out *strings.Builder, block model.ContentBlock) {
+	switch block.Type {
+	case model.BlockHeading:
+		level := block.Level
+		if level < 1 || level > 6 {
+			level = 2
+		}
+		fmt.Fprintf(out, "%s %s\n\n", strings.Repeat("#", level), markdownLine(block.Text))
+	case model.BlockParagraph:
+		fmt.Fprintf(out, "%s\n\n", markdownText(block.Text))
+	case model.BlockCode:
+		// The fence has to be longer than any run of backticks inside the code
+		fence := strings.Repeat("`", max(3, longestRun(block.Code, '`')+1))
+		fmt.Fprintf(out, "%s%s\n%s\n%s\n\n", fence, codeLanguage(block.Language), strings.TrimRight(block.Code, "\n"), fence)
+	case model.BlockImage:
+		if !isWebURL(block.URL) {
+			return
+		}
+		fmt.Fprintf(out, "![%s](<%s>)\n", markdownLine(block.Alt), markdownURL(block.URL))
+		writeCaptionMarkdown(out, block.Caption)
+	case model.BlockVideo:
+		if !isWebURL(block.URL) {
+			return
+		}
+		label := block.Caption
+		if label == "" {
+			label = "Video"
+		}
+		fmt.Fprintf(out, "[%s](<%s>)\n\n", markdownLine(label), markdownURL(block.URL))
+	case model.BlockQuiz:
+		fmt.Fprintf(out, "**%s**\n\n", markdownLine(block.Question))
+		for i, option := range block.Options {
+			fmt.Fprintf(out, "%d. %s\n", i+1, markdownLine(option))
+		}
+		out.WriteString("\n")
+	case model.BlockCallout:
+		alert, ok := markdownCalloutAlerts[block.Variant]
+		if !ok {
+			alert = "NOTE"
+		}
+		fmt.Fprintf(out, "> [!%s]\n", alert)
+		if block.Title != "" {
+			fmt.Fprintf(out, "> **%s**\n>\n", markdownLine(block.Title))
+		}
+		for _, line := range strings.Split(markdownText(block.Text), "\n") {
+			fmt.Fprintf(out, "> %s\n", line)
+		}
+		out.WriteString("\n")
+	case model.BlockAttachment:
+		href, ok := attachmentDownloadPath(block.AttachmentID)
+		if !ok {
+			return
+		}
+		fmt.Fprintf(out, "[%s](<%s>)\n", markdownLine(attachmentLabel(block.Title)), href)
+		writeCaptionMarkdown(out, block.Caption)
+	}
+}
+
+func writeCaptionMarkdown(out *strings.Builder, caption string) {
+	if caption != "" {
+		fmt.Fprintf(out, "*%s*\n", markdownLine(caption))
+	}
+	out.WriteString("\n")
+}
+
+var markdownSpecial = regexp.MustCompile("([\\\\`*_{}\\[\\]<>()#+\\-.!|~])")
+
+// markdownLine escapes text so it renders literally on a single line
+func markdownLine(text string) string {
+	return markdownSpecial.ReplaceAllString(strings.Join(strings.Fields(text), " "), "\\$1")
+}
+
+// markdownText escapes text and keeps its line breaks as hard breaks
+func markdownText(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = markdownSpecial.ReplaceAllString(strings.TrimSpace(line), "\\$1")
+	}
+	return strings.Join(lines, "\\\n")
+}
+
+// markdownURL keeps the address from closing the <...> it is written in
+func markdownURL(raw string) string {
+	return strings.NewReplacer("<", "%3C", ">", "%3E", " ", "%20", "\n", "").Replace(raw)
+}
+
+var codeLanguagePattern = regexp.MustCompile(`^[A-Za-z0-9+#._-]{1,32}$`)
+
+// codeLanguage returns the language for annotations, empty when it isn't a plain identifier
+func codeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if !codeLanguagePattern.MatchString(language) {
+		return ""
+	}
+	return language
+}
+
+func calloutVariant(variant string) string {
+	if _, ok := markdownCalloutAlerts[variant]; ok {
+		return variant
+	}
+	return "info"
+}
+
+func longestRun(text string, char rune) int {
+	longest, current := 0, 0
+	for _, r := range text {
+		if r == char {
+			current++
+			longest = max(longest, current)
+		} else {
+			current = 0
+		}
+	}
+	return longest
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/google/uuid"
+)
+
+// ContentValidationError lists every field of the content that breaks the block schema
+type ContentValidationError struct {
+	Errors []model.ContentFieldError
+}
+
+func (e *ContentValidationError) Error() string {
+	return "content does not match the block schema"
+}
+
+func (e *ContentValidationError) add(path, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, model.ContentFieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// validateContent checks content against model.ContentBlockSchemas, it returns a
+// *ContentValidationError reporting every offending field
+func validateContent(content json.RawMessage) error {
+	verr := &ContentValidationError{}
+
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		verr.add("$", "must be valid JSON")
+		return verr
+	}
+
+	root, ok := document.(map[string]interface{})
+	if !ok {
+		verr.add("$", "must be an object with a blocks array")
+		return verr
+	}
+	for _, key := range sortedKeys(root) {
+		if key != "blocks" {
+			verr.add("$."+key, "unknown field")
+		}
+	}
+
+	blocks, ok := root["blocks"].([]interface{})
+	if !ok {
+		verr.add("$.blocks", "must be an array")
+		return verr
+	}
+	for i, raw := range blocks {
+		validateBlock(verr, fmt.Sprintf("$.blocks[%d]", i), raw)
+	}
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+func validateBlock(verr *ContentValidationError, path string, raw interface{}) {
+	block, ok := raw.(map[string]interface{})
+	if !ok {
+		verr.add(path, "must be an object")
+		return
+	}
+
+	blockType, _ := block["type"].(string)
+	schema := findBlockSchema(model.ContentBlockType(blockType))
+	if schema == nil {
+		verr.add(path+".type", "must be one of %s", strings.Join(blockTypeNames(), ", "))
+		return
+	}
+
+	if id, ok := block["id"]; ok {
+		if _, isString := id.(string); !isString {
+			verr.add(path+".id", "must be a string")
+		}
+	}
+
+	known := map[string]bool{"type": true, "id": true}
+	for _, field := range schema.Fields {
+		known[field.Name] = true
+		value, present := block[field.Name]
+		if !present || value == nil {
+			if field.Required {
+				verr.add(path+"."+field.Name, "is required")
+			}
+			continue
+		}
+		validateField(verr, path+"."+field.Name, field, value)
+	}
+	for _, key := range sortedKeys(block) {
+		if !known[key] {
+			verr.add(path+"."+key, "unknown field for %s blocks", schema.Type)
+		}
+	}
+
+	// The answer has to point at one of the options
+	if schema.Type == model.BlockQuiz {
+		options, optionsOK := block["options"].([]interface{})
+		answer, answerOK := block["answer"].(json.Number)
+		if index, err := answer.Int64(); optionsOK && answerOK && err == nil && int(index) >= len(options) {
+			verr.add(path+".answer", "must be the index of one of the %d options", len(options))
+		}
+	}
+}
+
+func validateField(verr *ContentValidationError, path string, field model.ContentBlockField, value interface{}) {
+	switch field.Kind {
+	case model.FieldString, model.FieldURL:
+		text, ok := value.(string)
+		if !ok {
+			verr.add(path, "must be a string")
+			return
+		}
+		if field.Required && strings.TrimSpace(text) == "" {
+			verr.add(path, "must not be empty")
+			return
+		}
+		if len(field.Enum) > 0 && !containsString(field.Enum, text) {
+			verr.add(path, "must be one of %s", strings.Join(field.Enum, ", "))
+		}
+		if field.Kind == model.FieldURL && !isWebURL(text) {
+			verr.add(path, "must be an http or https URL")
+		}
+	case model.FieldUUID:
+		text, ok := value.(string)
+		if _, err := uuid.Parse(text); !ok || err != nil {
+			verr.add(path, "must be a UUID")
+		}
+	case model.FieldInteger:
+		number, ok := value.(json.Number)
+		integer, err := number.Int64()
+		if !ok || err != nil {
+			verr.add(path, "must be an integer")
+			return
+		}
+		if field.Min != nil && int(integer) < *field.Min {
+			verr.add(path, "must be at least %d", *field.Min)
+		}
+		if field.Max != nil && int(integer) > *field.Max {
+			verr.add(path, "must be at most %d", *field.Max)
+		}
+	case model.FieldStringArray:
+		items, ok := value.([]interface{})
+		if !ok {
+			verr.add(path, "must be an array of strings")
+			return
+		}
+		if field.Min != nil && len(items) < *field.Min {
+			verr.add(path, "must have at least %d items", *field.Min)
+		}
+		if field.Max != nil && len(items) > *field.Max {
+			verr.add(path, "must have at most %d items", *field.Max)
+		}
+		for i, item := range items {
+			if text, ok := item.(string); !ok || strings.TrimSpace(text) == "" {
+				verr.add(fmt.Sprintf("%s[%d]", path, i), "must be a non-empty string")
+			}
+		}
+	}
+}
+
+// ContentJSONSchema renders model.ContentBlockSchemas as a JSON Schema document
+func ContentJSONSchema() map[string]interface{} {
+	defs := map[string]interface{}{}
+	refs := make([]interface{}, 0, len(model.ContentBlockSchemas))
+
+	for _, schema := range model.ContentBlockSchemas {
+		properties := map[string]interface{}{
+			"type": map[string]interface{}{"const": schema.Type},
+			"id":   map[string]interface{}{"type": "string"},
+		}
+		required := []string{"type"}
+		for _, field := range schema.Fields {
+			properties[field.Name] = fieldJSONSchema(field)
+			if field.Required {
+				required = append(required, field.Name)
+			}
+		}
+
+		defs[string(schema.Type)] = map[string]interface{}{
+			"description":          schema.Description,
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+		refs = append(refs, map[string]interface{}{"$ref": "#/$defs/" + string(schema.Type)})
+	}
+
+	return map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                "Practicum module content",
+		"type":                 "object",
+		"required":             []string{"blocks"},
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"blocks": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"oneOf": refs},
+			},
+		},
+		"$defs": defs,
+	}
+}
+
+func fieldJSONSchema(field model.ContentBlockField) map[string]interface{} {
+	property := map[string]interface{}{"description": field.Description}
+	switch field.Kind {
+	case model.FieldString:
+		property["type"] = "string"
+		if field.Required {
+			property["minLength"] = 1
+		}
+		if len(field.Enum) > 0 {
+			property["enum"] = field.Enum
+		}
+	case model.FieldURL:
+		property["type"] = "string"
+		property["format"] = "uri"
+		property["pattern"] = "^https?://"
+	case model.FieldUUID:
+		property["type"] = "string"
+		property["format"] = "uuid"
+	case model.FieldInteger:
+		property["type"] = "integer"
+		if field.Min != nil {
+			property["minimum"] = *field.Min
+		}
+		if field.Max != nil {
+			property["maximum"] = *field.Max
+		}
+	case model.FieldStringArray:
+		property["type"] = "array"
+		property["items"] = map[string]interface{}{"type": "string", "minLength": 1}
+		if field.Min != nil {
+			property["minItems"] = *field.Min
+		}
+		if field.Max != nil {
+			property["maxItems"] = *field.Max
+		}
+	}
+	return property
+}
+
+func findBlockSchema(blockType model.ContentBlockType) *model.ContentBlockSchema {
+	for i := range model.ContentBlockSchemas {
+		if model.ContentBlockSchemas[i].Type == blockType {
+			return &model.ContentBlockSchemas[i]
+		}
+	}
+	return nil
+}
+
+func blockTypeNames() []string {
+	names := make([]string, len(model.ContentBlockSchemas))
+	for i, schema := range model.ContentBlockSchemas {
+		names[i] = string(schema.Type)
+	}
+	return names
+}
+
+func isWebURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys keeps the reported errors in a stable order
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
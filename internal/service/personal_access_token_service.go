@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/repository"
+	"github.com/egasa21/si-lab-api-go/pkg/auth"
+	"github.com/rs/zerolog/log"
+)
+
+// PersonalAccessTokenPrefix tells personal access tokens apart from JWTs in the Authorization header
+const PersonalAccessTokenPrefix = "silpat_"
+
+const (
+	defaultTokenLifetime = 90 * 24 * time.Hour
+	maxTokenLifetime     = 365 * 24 * time.Hour
+)
+
+var (
+	ErrTokenNameRequired   = errors.New("token name is required")
+	ErrTokenScopesRequired = errors.New("at least one scope is required")
+	ErrTokenScopeInvalid   = errors.New("unknown scope")
+	ErrTokenExpiryInvalid  = errors.New("expiry must be in the future and at most one year away")
+	ErrTokenNotFound       = errors.New("token not found")
+	ErrAPITokenInvalid     = errors.New("invalid, expired or revoked api token")
+)
+
+type PersonalAccessTokenService interface {
+	// CreateToken returns the stored token together with its plain text value, which is only shown once
+	CreateToken(userID int, name string, scopes []model.Scope, expiresAt *time.Time) (*model.PersonalAccessToken, string, error)
+	GetTokensByUserID(userID int) ([]model.PersonalAccessToken, error)
+	RevokeToken(userID, tokenID int) error
+	// Authenticate resolves a plain text token to the token and the current roles of its owner
+	Authenticate(plainToken string) (*model.PersonalAccessToken, []model.RoleModel, error)
+}
+
+type personalAccessTokenService struct {
+	repo     repository.PersonalAccessTokenRepository
+	authRepo repository.AuthRepository
+}
+
+func NewPersonalAccessTokenService(repo repository.PersonalAccessTokenRepository, authRepo repository.AuthRepository) PersonalAccessTokenService {
+	return &personalAccessTokenService{repo: repo, authRepo: authRepo}
+}
+
+func (s *personalAccessTokenService) CreateToken(userID int, name string, scopes []model.Scope, expiresAt *time.Time) (*model.PersonalAccessToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", ErrTokenNameRequired
+	}
+
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	expiry := now.Add(defaultTokenLifetime)
+	if expiresAt != nil {
+		if !expiresAt.After(now) || expiresAt.After(now.Add(maxTokenLifetime)) {
+			return nil, "", ErrTokenExpiryInvalid
+		}
+		expiry = *expiresAt
+	}
+
+	secret, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	plainToken := PersonalAccessTokenPrefix + secret
+
+	token := &model.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: auth.HashToken(plainToken),
+		Scopes:    scopes,
+		ExpiresAt: expiry,
+	}
+	if err := s.repo.CreatePersonalAccessToken(token); err != nil {
+		return nil, "", err
+	}
+
+	return token, plainToken, nil
+}
+
+func (s *personalAccessTokenService) GetTokensByUserID(userID int) ([]model.PersonalAccessToken, error) {
+	return s.repo.GetPersonalAccessTokensByUserID(userID)
+}
+
+func (s *personalAccessTokenService) RevokeToken(userID, tokenID int) error {
+	revoked, err := s.repo.RevokePersonalAccessToken(tokenID, userID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+func (s *personalAccessTokenService) Authenticate(plainToken string) (*model.PersonalAccessToken, []model.RoleModel, error) {
+	token, err := s.repo.GetPersonalAccessTokenByHash(auth.HashToken(plainToken))
+	if err != nil {
+		return nil, nil, err
+	}
+	if token == nil || token.RevokedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, nil, ErrAPITokenInvalid
+	}
+
+	// Roles are read on every request so a revoked role takes effect right away
+	roles, err := s.authRepo.GetRolesByUserID(token.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.repo.TouchPersonalAccessToken(token.ID); err != nil {
+		log.Warn().Err(err).Int("token_id", token.ID).Msg("Failed to record api token usage")
+	}
+
+	return token, roles, nil
+}
+
+// normalizeScopes rejects unknown scopes and drops duplicates
+func normalizeScopes(scopes []model.Scope) ([]model.Scope, error) {
+	if len(scopes) == 0 {
+		return nil, ErrTokenScopesRequired
+	}
+
+	known := make(map[model.Scope]bool, len(model.Scopes))
+	for _, scope := range model.Scopes {
+		known[scope] = true
+	}
+
+	seen := make(map[model.Scope]bool, len(scopes))
+	normalized := make([]model.Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		if !known[scope] {
+			return nil, ErrTokenScopeInvalid
+		}
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		normalized = append(normalized, scope)
+	}
+	return normalized, nil
+}
@@ -1,47 +1,497 @@
 package service
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/pkg/jsondiff"
 	"github.com/egasa21/si-lab-api-go/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrContentNotFound        = errors.New("content not found")
+	ErrContentOrderInvalid    = errors.New("the order has to list every content of the module exactly once")
+	ErrContentMoveInvalid     = errors.New("content can only be moved to a module of the same practicum")
+	ErrContentVersionNotFound = errors.New("content version not found")
+	ErrContentFormatInvalid   = errors.New("format must be json, html or markdown")
+	ErrContentNotRenderable   = errors.New("content is not made of blocks and can't be rendered")
+	ErrContentStatusInvalid   = errors.New("the content is not in a status this change applies to")
+	ErrContentStatusFilter    = errors.New("status must be draft, in_review, published or archived")
 )
 
 type PracticumModuleContentService interface {
-	CreateContent(content *model.PracticumModuleContent) (*model.PracticumModuleContent, error)
+	CreateContent(content *model.PracticumModuleContent, authorID int) (*model.PracticumModuleContent, error)
 	GetContentByID(id int) (*model.PracticumModuleContent, error)
+	// GetPublishedContentByID returns the version students see, quiz answers left out
+	GetPublishedContentByID(id int) (*model.PracticumModuleContent, error)
+	// RenderContent renders the content to HTML or Markdown, results are cached until the content changes.
+	// publishedOnly renders the version students see instead of the latest revision.
+	RenderContent(id int, format model.ContentFormat, publishedOnly bool) (*model.RenderedContent, error)
 	GetContentByIDs(ids []int) ([]model.PracticumModuleContent, error)
-	GetContentsByModuleID(moduleID, page, limit int) ([]model.PracticumModuleContent, int, error)
-	UpdateContentByID(id int, updatedContent *model.PracticumModuleContent) error
+	// GetPublishedContentByIDs returns the versions students see, contents not published yet are left out
+	GetPublishedContentByIDs(ids []int) ([]model.PracticumModuleContent, error)
+	// GetContentsByModuleID lists the latest revisions, an empty status lists every status
+	GetContentsByModuleID(moduleID int, status model.ContentStatus, page, limit int) ([]model.PracticumModuleContent, int, error)
+	GetPublishedContentsByModuleID(moduleID, page, limit int) ([]model.PracticumModuleContent, int, error)
+	UpdateContentByID(id int, updatedContent *model.PracticumModuleContent, authorID int) error
 	DeleteContentByID(id int) error
+	// ReorderContents rewrites the order of every content of the module at once
+	ReorderContents(moduleID int, contentIDs []int) error
+	// MoveContent moves content to position in another module of the same practicum, 0 appends it
+	MoveContent(id, moduleID, position int) (*model.PracticumModuleContent, error)
+	GetContentVersions(materialID uuid.UUID) ([]model.PracticumModuleContentVersion, error)
+	GetContentVersion(materialID uuid.UUID, version int) (*model.PracticumModuleContentVersion, error)
+	// DiffContentVersions compares two versions of a material, from may be newer than to
+	DiffContentVersions(materialID uuid.UUID, from, to int) (*model.PracticumModuleContentDiff, error)
+	// RestoreContentVersion makes an older version current again, recorded as a new version
+	RestoreContentVersion(materialID uuid.UUID, version, authorID int) (*model.PracticumModuleContentVersion, error)
+	// GetContentSchema returns the JSON Schema content is validated against
+	GetContentSchema() map[string]interface{}
+	// SubmitContent sends a draft to a lecturer for review
+	SubmitContent(id, userID int) (*model.PracticumModuleContent, error)
+	// PublishContent approves a draft or submitted content, it becomes visible at publishAt or right away when nil
+	PublishContent(id, reviewerID int, publishAt *time.Time) (*model.PracticumModuleContent, error)
+	// RejectContent sends submitted content back to draft with a note for its author
+	RejectContent(id, reviewerID int, note string) (*model.PracticumModuleContent, error)
+	// ArchiveContent hides the content from students
+	ArchiveContent(id, userID int) (*model.PracticumModuleContent, error)
 }
 
 type practicumModuleContentService struct {
-	repo repository.PracticumModuleContentRepository
+	repo           repository.PracticumModuleContentRepository
+	moduleRepo     repository.PracticumModuleRepository
+	attachmentRepo repository.AttachmentRepository
+	renderCache    *renderCache
 }
 
-func NewPracticumModuleContentService(repo repository.PracticumModuleContentRepository) PracticumModuleContentService {
-	return &practicumModuleContentService{repo: repo}
+func NewPracticumModuleContentService(repo repository.PracticumModuleContentRepository, moduleRepo repository.PracticumModuleRepository, attachmentRepo repository.AttachmentRepository) PracticumModuleContentService {
+	return &practicumModuleContentService{repo: repo, moduleRepo: moduleRepo, attachmentRepo: attachmentRepo, renderCache: newRenderCache()}
 }
 
-func (s *practicumModuleContentService) CreateContent(content *model.PracticumModuleContent) (*model.PracticumModuleContent, error) {
-	return s.repo.CreateContent(content)
+// validateContent checks content against the block schema, then that the attachments its
+// blocks reference exist and were uploaded as material
+func (s *practicumModuleContentService) validateContent(content json.RawMessage) error {
+	if err := validateContent(content); err != nil {
+		return err
+	}
+
+	var document model.ContentDocument
+	if err := json.Unmarshal(content, &document); err != nil {
+		return err
+	}
+
+	type reference struct {
+		id   uuid.UUID
+		path string
+	}
+	var references []reference
+	var ids []uuid.UUID
+	for i, block := range document.Blocks {
+		if block.Type != model.BlockAttachment {
+			continue
+		}
+		// validateContent already checked it parses
+		id := uuid.MustParse(block.AttachmentID)
+		references = append(references, reference{id: id, path: fmt.Sprintf("$.blocks[%d].attachment_id", i)})
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	attachments, err := s.attachmentRepo.GetAttachmentsByIDs(ids)
+	if err != nil {
+		return err
+	}
+	found := make(map[uuid.UUID]model.Attachment, len(attachments))
+	for _, attachment := range attachments {
+		found[attachment.ID] = attachment
+	}
+
+	verr := &ContentValidationError{}
+	for _, ref := range references {
+		attachment, ok := found[ref.id]
+		if !ok {
+			verr.add(ref.path, "no attachment with this ID exists")
+		} else if attachment.Purpose != model.AttachmentMaterial {
+			verr.add(ref.path, "must be an attachment uploaded as material")
+		}
+	}
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// CreateContent appends the content to its module, the sequence is assigned by the server
+func (s *practicumModuleContentService) CreateContent(content *model.PracticumModuleContent, authorID int) (*model.PracticumModuleContent, error) {
+	if err := s.validateContent(content.Content); err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.CreateContent(content, authorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrModuleNotFound
+	}
+	return created, err
 }
 
 func (s *practicumModuleContentService) GetContentByID(id int) (*model.PracticumModuleContent, error) {
 	return s.repo.GetContentByID(id)
 }
 
-func (s *practicumModuleContentService) GetContentsByModuleID(moduleID, page, limit int) ([]model.PracticumModuleContent, int, error) {
-	return s.repo.GetContentsByModuleID(moduleID, page, limit)
+func (s *practicumModuleContentService) GetPublishedContentByID(id int) (*model.PracticumModuleContent, error) {
+	content, err := s.repo.GetPublishedContentByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		return nil, ErrContentNotFound
+	}
+	hideQuizAnswers(content)
+	return content, nil
+}
+
+func (s *practicumModuleContentService) RenderContent(id int, format model.ContentFormat, publishedOnly bool) (*model.RenderedContent, error) {
+	if format != model.ContentFormatHTML && format != model.ContentFormatMarkdown {
+		return nil, ErrContentFormatInvalid
+	}
+
+	var content *model.PracticumModuleContent
+	var err error
+	if publishedOnly {
+		content, err = s.GetPublishedContentByID(id)
+	} else {
+		content, err = s.repo.GetContentByID(id)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrContentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	body, ok := s.renderCache.get(id, format, publishedOnly, content.UpdatedAt)
+	if !ok {
+		body, err = renderContent(content.Content, format)
+		if err != nil {
+			return nil, err
+		}
+		s.renderCache.put(id, format, publishedOnly, content.UpdatedAt, body)
+	}
+
+	return &model.RenderedContent{
+		IDContent:  content.IDContent,
+		MaterialID: content.MaterialID,
+		Title:      content.Title,
+		Format:     format,
+		Body:       body,
+		UpdatedAt:  content.UpdatedAt,
+	}, nil
+}
+
+func (s *practicumModuleContentService) GetContentsByModuleID(moduleID int, status model.ContentStatus, page, limit int) ([]model.PracticumModuleContent, int, error) {
+	switch status {
+	case "", model.ContentDraft, model.ContentInReview, model.ContentPublished, model.ContentArchived:
+	default:
+		return nil, 0, ErrContentStatusFilter
+	}
+	return s.repo.GetContentsByModuleID(moduleID, status, page, limit)
+}
+
+func (s *practicumModuleContentService) GetPublishedContentsByModuleID(moduleID, page, limit int) ([]model.PracticumModuleContent, int, error) {
+	contents, total, err := s.repo.GetPublishedContentsByModuleID(moduleID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range contents {
+		hideQuizAnswers(&contents[i])
+	}
+	return contents, total, nil
 }
 
 func (s *practicumModuleContentService) GetContentByIDs(ids []int) ([]model.PracticumModuleContent, error) {
 	return s.repo.GetContentByIDs(ids)
 }
 
-func (s *practicumModuleContentService) UpdateContentByID(id int, updatedContent *model.PracticumModuleContent) error {
-	return s.repo.UpdateContentByID(id, updatedContent)
+func (s *practicumModuleContentService) GetPublishedContentByIDs(ids []int) ([]model.PracticumModuleContent, error) {
+	contents, err := s.repo.GetPublishedContentByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range contents {
+		hideQuizAnswers(&contents[i])
+	}
+	return contents, nil
+}
+
+func (s *practicumModuleContentService) UpdateContentByID(id int, updatedContent *model.PracticumModuleContent, authorID int) error {
+	if err := s.validateContent(updatedContent.Content); err != nil {
+		return err
+	}
+	defer s.renderCache.invalidate(id)
+	return s.repo.UpdateContentByID(id, updatedContent, authorID)
 }
 
 func (s *practicumModuleContentService) DeleteContentByID(id int) error {
+	defer s.renderCache.invalidate(id)
 	return s.repo.DeleteContentByID(id)
 }
+
+func (s *practicumModuleContentService) ReorderContents(moduleID int, contentIDs []int) error {
+	reordered, err := s.repo.ReorderContents(moduleID, contentIDs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrModuleNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !reordered {
+		return ErrContentOrderInvalid
+	}
+	return nil
+}
+
+func (s *practicumModuleContentService) MoveContent(id, moduleID, position int) (*model.PracticumModuleContent, error) {
+	content, err := s.repo.GetContentByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrContentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := s.moduleRepo.GetModuleByID(content.IDModule)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.moduleRepo.GetModuleByID(moduleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrModuleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Checkpoints and progress are kept per practicum, so content stays inside its practicum
+	if source.PracticumID != target.PracticumID {
+		return nil, ErrContentMoveInvalid
+	}
+
+	moved, err := s.repo.MoveContent(id, moduleID, position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrModuleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !moved {
+		return nil, ErrContentNotFound
+	}
+	return s.repo.GetContentByID(id)
+}
+
+func (s *practicumModuleContentService) GetContentVersions(materialID uuid.UUID) ([]model.PracticumModuleContentVersion, error) {
+	if err := s.checkMaterialExists(materialID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetContentVersions(materialID)
+}
+
+func (s *practicumModuleContentService) GetContentVersion(materialID uuid.UUID, version int) (*model.PracticumModuleContentVersion, error) {
+	if err := s.checkMaterialExists(materialID); err != nil {
+		return nil, err
+	}
+
+	found, err := s.repo.GetContentVersion(materialID, version)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, ErrContentVersionNotFound
+	}
+	return found, nil
+}
+
+func (s *practicumModuleContentService) DiffContentVersions(materialID uuid.UUID, from, to int) (*model.PracticumModuleContentDiff, error) {
+	fromVersion, err := s.GetContentVersion(materialID, from)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := s.GetContentVersion(materialID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	fromDocument, err := versionDocument(fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	toDocument, err := versionDocument(toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := jsondiff.Diff(fromDocument, toDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.PracticumModuleContentDiff{
+		MaterialID:  materialID,
+		FromVersion: from,
+		ToVersion:   to,
+		Changes:     changes,
+	}, nil
+}
+
+func (s *practicumModuleContentService) RestoreContentVersion(materialID uuid.UUID, version, authorID int) (*model.PracticumModuleContentVersion, error) {
+	// Versions recorded before the schema existed may not match it
+	old, err := s.GetContentVersion(materialID, version)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateContent(old.Content); err != nil {
+		return nil, err
+	}
+
+	restored, err := s.repo.RestoreContentVersion(materialID, version, authorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrModuleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if restored == nil {
+		return nil, ErrContentVersionNotFound
+	}
+
+	if content, err := s.repo.GetContentByMaterialID(materialID); err == nil && content != nil {
+		s.renderCache.invalidate(content.IDContent)
+	}
+	return restored, nil
+}
+
+func (s *practicumModuleContentService) GetContentSchema() map[string]interface{} {
+	return ContentJSONSchema()
+}
+
+func (s *practicumModuleContentService) SubmitContent(id, userID int) (*model.PracticumModuleContent, error) {
+	return s.changeStatus(id, func() (bool, error) {
+		return s.repo.SubmitContent(id, userID)
+	})
+}
+
+func (s *practicumModuleContentService) PublishContent(id, reviewerID int, publishAt *time.Time) (*model.PracticumModuleContent, error) {
+	// A publish_at that already passed publishes right away
+	if publishAt != nil && !publishAt.After(time.Now()) {
+		publishAt = nil
+	}
+
+	return s.changeStatus(id, func() (bool, error) {
+		return s.repo.PublishContent(id, reviewerID, publishAt)
+	})
+}
+
+func (s *practicumModuleContentService) RejectContent(id, reviewerID int, note string) (*model.PracticumModuleContent, error) {
+	return s.changeStatus(id, func() (bool, error) {
+		return s.repo.RejectContent(id, reviewerID, strings.TrimSpace(note))
+	})
+}
+
+func (s *practicumModuleContentService) ArchiveContent(id, userID int) (*model.PracticumModuleContent, error) {
+	return s.changeStatus(id, func() (bool, error) {
+		return s.repo.ArchiveContent(id, userID)
+	})
+}
+
+// changeStatus applies a workflow change, telling missing content apart from content in the wrong status
+func (s *practicumModuleContentService) changeStatus(id int, change func() (bool, error)) (*model.PracticumModuleContent, error) {
+	changed, err := change()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := s.repo.GetContentByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrContentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, ErrContentStatusInvalid
+	}
+
+	s.renderCache.invalidate(id)
+	return content, nil
+}
+
+// checkMaterialExists passes for deleted content too, its versions are kept
+func (s *practicumModuleContentService) checkMaterialExists(materialID uuid.UUID) error {
+	content, err := s.repo.GetContentByMaterialID(materialID)
+	if err != nil {
+		return err
+	}
+	if content != nil {
+		return nil
+	}
+
+	exists, err := s.repo.ContentVersionsExist(materialID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrContentNotFound
+	}
+	return nil
+}
+
+// hideQuizAnswers drops the answer and explanation of quiz blocks from content read by students,
+// the same as the rendering does. Content that isn't made of blocks is left as it is.
+func hideQuizAnswers(content *model.PracticumModuleContent) {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(content.Content, &document); err != nil {
+		return
+	}
+	var blocks []map[string]json.RawMessage
+	if err := json.Unmarshal(document["blocks"], &blocks); err != nil {
+		return
+	}
+
+	hidden := false
+	for _, block := range blocks {
+		var blockType model.ContentBlockType
+		if json.Unmarshal(block["type"], &blockType) != nil || blockType != model.BlockQuiz {
+			continue
+		}
+		delete(block, "answer")
+		delete(block, "explanation")
+		hidden = true
+	}
+	if !hidden {
+		return
+	}
+
+	rawBlocks, err := json.Marshal(blocks)
+	if err != nil {
+		return
+	}
+	document["blocks"] = rawBlocks
+	if stripped, err := json.Marshal(document); err == nil {
+		content.Content = stripped
+	}
+}
+
+// versionDocument wraps the version's title and content in one document so both are diffed
+func versionDocument(version *model.PracticumModuleContentVersion) (json.RawMessage, error) {
+	return json.Marshal(map[string]interface{}{
+		"title":   version.Title,
+		"content": version.Content,
+	})
+}
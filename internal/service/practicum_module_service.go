@@ -1,15 +1,30 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
+	"strings"
+
 	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/egasa21/si-lab-api-go/internal/repository"
 )
 
+var (
+	ErrModuleNotFound     = errors.New("module not found")
+	ErrModuleTitleInvalid = errors.New("module title is required")
+	ErrModuleOrderInvalid = errors.New("the order has to list every module of the practicum exactly once")
+	ErrModuleInUse        = errors.New("students have progress in the module, it can't be deleted")
+)
+
 type PracticumModuleService interface {
 	CreateModule(module *model.PracticumModule) (*model.PracticumModule, error)
 	GetModuleByID(id int) (*model.PracticumModule, error)
 	GetModuleByIDs(ids []int) ([]model.PracticumModule, error)
 	GetModulesByPracticumID(practicumID, page, limit int) ([]model.PracticumModule, int, error)
+	UpdateModule(module *model.PracticumModule) error
+	DeleteModule(id int) error
+	// ReorderModules rewrites the order of every module of the practicum at once
+	ReorderModules(practicumID int, moduleIDs []int) error
 }
 
 type practicumModuleService struct {
@@ -21,7 +36,11 @@ func NewPracticumModuleService(repo repository.PracticumModuleRepository) Practi
 }
 
 func (s *practicumModuleService) CreateModule(module *model.PracticumModule) (*model.PracticumModule, error) {
-	return s.repo.CreateModule(module)
+	created, err := s.repo.CreateModule(module)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPracticumNotFound
+	}
+	return created, err
 }
 
 func (s *practicumModuleService) GetModuleByID(id int) (*model.PracticumModule, error) {
@@ -35,3 +54,51 @@ func (s *practicumModuleService) GetModulesByPracticumID(practicumID, page, limi
 func (s *practicumModuleService) GetModuleByIDs(ids []int) ([]model.PracticumModule, error) {
 	return s.repo.GetModuleByIDs(ids)
 }
+
+func (s *practicumModuleService) UpdateModule(module *model.PracticumModule) error {
+	module.Title = strings.TrimSpace(module.Title)
+	if module.Title == "" {
+		return ErrModuleTitleInvalid
+	}
+
+	updated, err := s.repo.UpdateModule(module)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrModuleNotFound
+	}
+	return nil
+}
+
+func (s *practicumModuleService) DeleteModule(id int) error {
+	deleted, err := s.repo.DeleteModule(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		inUse, err := s.repo.ModuleInUse(id)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return ErrModuleInUse
+		}
+		return ErrModuleNotFound
+	}
+	return nil
+}
+
+func (s *practicumModuleService) ReorderModules(practicumID int, moduleIDs []int) error {
+	reordered, err := s.repo.ReorderModules(practicumID, moduleIDs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPracticumNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !reordered {
+		return ErrModuleOrderInvalid
+	}
+	return nil
+}
@@ -1,27 +1,66 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/egasa21/si-lab-api-go/internal/dto"
 	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/egasa21/si-lab-api-go/internal/repository"
 )
 
+var (
+	ErrPracticumNotFound      = errors.New("practicum not found")
+	ErrPracticumNameTaken     = errors.New("a practicum with this name already exists")
+	ErrPracticumInvalid       = errors.New("name, code and semester are required and credits must be a positive number")
+	ErrPracticumSortInvalid   = errors.New("unknown sort field or order")
+	ErrPracticumFilterInvalid = errors.New("term_id and credits filters must be positive numbers and min_credits can't exceed max_credits")
+)
+
 type PracticumService interface {
 	CreatePracticum(practicum *model.Practicum) error
 	GetPracticumByID(id int) (*model.Practicum, error)
 	GetPracticumByIDs(ids []int) ([]model.Practicum, error)
-	GetAllPracticums(page, limit int) ([]model.Practicum, int, error)
-	GetPracticumWithMaterialContents(id int) (*model.PracticumWithMaterial, error)
+	GetAllPracticums(filter model.PracticumFilter, page, limit int) ([]model.Practicum, int, error)
+	GetPracticumWithMaterialContents(id int, publishedOnly bool) (*model.PracticumWithMaterial, error)
+	UpdatePracticum(practicum *model.Practicum) error
+	// PatchPracticum only changes the fields set in the request
+	PatchPracticum(id int, patch dto.PatchPracticumRequest) (*model.Practicum, error)
+	DeletePracticum(id int) error
+	RestorePracticum(id int) (*model.Practicum, error)
+	// ClonePracticum deep-copies a practicum with its modules and contents, and optionally its classes
+	ClonePracticum(id int, req dto.ClonePracticumRequest) (*model.PracticumCloneResult, error)
 }
 
 type practicumService struct {
-	repo repository.PracticumRepository
+	repo     repository.PracticumRepository
+	termRepo repository.AcademicTermRepository
 }
 
-func NewPracticumService(repo repository.PracticumRepository) PracticumService {
-	return &practicumService{repo: repo}
+func NewPracticumService(repo repository.PracticumRepository, termRepo repository.AcademicTermRepository) PracticumService {
+	return &practicumService{repo: repo, termRepo: termRepo}
 }
 
 func (s *practicumService) CreatePracticum(practicum *model.Practicum) error {
+	// Without an explicit term the practicum runs in the current one
+	if practicum.AcademicTermID == nil {
+		term, err := s.termRepo.GetActiveAcademicTerm()
+		if err != nil {
+			return err
+		}
+		if term != nil {
+			practicum.AcademicTermID = &term.ID
+		}
+	} else if err := s.checkTermExists(*practicum.AcademicTermID); err != nil {
+		return err
+	}
+
+	if err := s.checkNameAvailable(practicum.Name, practicum.AcademicTermID, 0); err != nil {
+		return err
+	}
 	return s.repo.CreatePracticum(practicum)
 }
 
@@ -29,14 +68,196 @@ func (s *practicumService) GetPracticumByID(id int) (*model.Practicum, error) {
 	return s.repo.GetPracticumByID(id)
 }
 
-func (s *practicumService) GetAllPracticums(page, limit int) ([]model.Practicum, int, error) {
-	return s.repo.GetAllPracticums(page, limit)
+func (s *practicumService) GetAllPracticums(filter model.PracticumFilter, page, limit int) ([]model.Practicum, int, error) {
+	if filter.SortBy != "" && !slices.Contains(model.PracticumSortFields, filter.SortBy) {
+		return nil, 0, ErrPracticumSortInvalid
+	}
+	if filter.MinCredits > 0 && filter.MaxCredits > 0 && filter.MinCredits > filter.MaxCredits {
+		return nil, 0, ErrPracticumFilterInvalid
+	}
+	filter.Search = strings.TrimSpace(filter.Search)
+	filter.Semester = strings.TrimSpace(filter.Semester)
+
+	termID, withoutTerm, err := resolveAcademicTermID(s.termRepo, filter.AcademicTermID, filter.AllTerms)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter.AcademicTermID = termID
+	filter.WithoutTerm = withoutTerm
+
+	return s.repo.GetAllPracticums(filter, page, limit)
 }
 
 func (s *practicumService) GetPracticumByIDs(ids []int) ([]model.Practicum, error) {
 	return s.repo.GetPracticumByIDs(ids)
 }
 
-func (s *practicumService) GetPracticumWithMaterialContents(id int) (*model.PracticumWithMaterial, error) {
-	return s.repo.GetPracticumWithMaterialContents(id)
+func (s *practicumService) GetPracticumWithMaterialContents(id int, publishedOnly bool) (*model.PracticumWithMaterial, error) {
+	return s.repo.GetPracticumWithMaterialContents(id, publishedOnly)
+}
+
+func (s *practicumService) UpdatePracticum(practicum *model.Practicum) error {
+	if err := validatePracticum(practicum); err != nil {
+		return err
+	}
+	if practicum.AcademicTermID != nil {
+		if err := s.checkTermExists(*practicum.AcademicTermID); err != nil {
+			return err
+		}
+	}
+	if err := s.checkNameAvailable(practicum.Name, practicum.AcademicTermID, practicum.ID); err != nil {
+		return err
+	}
+
+	updated, err := s.repo.UpdatePracticum(practicum)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrPracticumNotFound
+	}
+	return nil
+}
+
+func (s *practicumService) PatchPracticum(id int, patch dto.PatchPracticumRequest) (*model.Practicum, error) {
+	practicum, err := s.repo.GetPracticumByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPracticumNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if patch.Name != nil {
+		practicum.Name = *patch.Name
+	}
+	if patch.Code != nil {
+		practicum.Code = *patch.Code
+	}
+	if patch.Description != nil {
+		practicum.Description = *patch.Description
+	}
+	if patch.Credits != nil {
+		practicum.Credits = *patch.Credits
+	}
+	if patch.Semester != nil {
+		practicum.Semester = *patch.Semester
+	}
+	if patch.AcademicTermID != nil {
+		practicum.AcademicTermID = patch.AcademicTermID
+	}
+
+	if err := s.UpdatePracticum(practicum); err != nil {
+		return nil, err
+	}
+	return practicum, nil
+}
+
+func (s *practicumService) DeletePracticum(id int) error {
+	deleted, err := s.repo.SoftDeletePracticum(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrPracticumNotFound
+	}
+	return nil
+}
+
+func (s *practicumService) RestorePracticum(id int) (*model.Practicum, error) {
+	practicum, err := s.repo.GetDeletedPracticumByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if practicum == nil {
+		return nil, ErrPracticumNotFound
+	}
+
+	// The name may have been reused while the practicum was deleted
+	if err := s.checkNameAvailable(practicum.Name, practicum.AcademicTermID, 0); err != nil {
+		return nil, err
+	}
+
+	restored, err := s.repo.RestorePracticum(id)
+	if err != nil {
+		return nil, err
+	}
+	if !restored {
+		return nil, ErrPracticumNotFound
+	}
+	return s.repo.GetPracticumByID(id)
+}
+
+func (s *practicumService) ClonePracticum(id int, req dto.ClonePracticumRequest) (*model.PracticumCloneResult, error) {
+	source, err := s.repo.GetPracticumByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPracticumNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	target := *source
+	target.ID = 0
+	target.AcademicTermID = req.AcademicTermID
+	if name := strings.TrimSpace(req.Name); name != "" {
+		target.Name = name
+	}
+
+	if target.AcademicTermID == nil {
+		term, err := s.termRepo.GetActiveAcademicTerm()
+		if err != nil {
+			return nil, err
+		}
+		if term != nil {
+			target.AcademicTermID = &term.ID
+		}
+	} else if err := s.checkTermExists(*target.AcademicTermID); err != nil {
+		return nil, err
+	}
+
+	// Cloning within the same term needs a new name
+	if err := s.checkNameAvailable(target.Name, target.AcademicTermID, 0); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ClonePracticum(id, &target, req.IncludeClasses)
+}
+
+// checkNameAvailable fails when another practicum of the term that isn't deleted already uses the name
+func (s *practicumService) checkNameAvailable(name string, academicTermID *int, id int) error {
+	existing, err := s.repo.GetPracticumByName(name, academicTermID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != id {
+		return ErrPracticumNameTaken
+	}
+	return nil
+}
+
+func (s *practicumService) checkTermExists(id int) error {
+	term, err := s.termRepo.GetAcademicTermByID(id)
+	if err != nil {
+		return err
+	}
+	if term == nil {
+		return ErrAcademicTermNotFound
+	}
+	return nil
+}
+
+func validatePracticum(practicum *model.Practicum) error {
+	practicum.Name = strings.TrimSpace(practicum.Name)
+	practicum.Code = strings.TrimSpace(practicum.Code)
+	practicum.Semester = strings.TrimSpace(practicum.Semester)
+	if practicum.Name == "" || practicum.Code == "" || practicum.Semester == "" {
+		return ErrPracticumInvalid
+	}
+
+	credits, err := strconv.Atoi(strings.TrimSpace(practicum.Credits))
+	if err != nil || credits < 1 {
+		return ErrPracticumInvalid
+	}
+	return nil
 }
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/repository"
+)
+
+var ErrStaffRoleRequired = errors.New("only lecturers and laboratory assistants can be assigned to a practicum")
+
+type PracticumStaffService interface {
+	AssignStaff(practicumID, userID int) (*model.PracticumStaff, error)
+	RemoveStaff(practicumID, userID int) error
+	GetStaffByPracticumID(practicumID int) ([]model.PracticumStaff, error)
+	GetPracticumIDsByUserID(userID int) ([]int, error)
+	IsAssigned(userID, practicumID int) (bool, error)
+}
+
+type practicumStaffService struct {
+	repo     repository.PracticumStaffRepository
+	authRepo repository.AuthRepository
+}
+
+func NewPracticumStaffService(repo repository.PracticumStaffRepository, authRepo repository.AuthRepository) PracticumStaffService {
+	return &practicumStaffService{repo: repo, authRepo: authRepo}
+}
+
+func (s *practicumStaffService) AssignStaff(practicumID, userID int) (*model.PracticumStaff, error) {
+	// An unknown user has no roles and is refused the same way
+	roles, err := s.authRepo.GetRolesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	eligible := false
+	for _, role := range roles {
+		if role.Name == model.RoleLecturer || role.Name == model.RoleLaboratoryAssistant {
+			eligible = true
+		}
+	}
+	if !eligible {
+		return nil, ErrStaffRoleRequired
+	}
+
+	staff := &model.PracticumStaff{
+		PracticumID: practicumID,
+		UserID:      userID,
+	}
+	if err := s.repo.AssignStaff(staff); err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+func (s *practicumStaffService) RemoveStaff(practicumID, userID int) error {
+	return s.repo.RemoveStaff(practicumID, userID)
+}
+
+func (s *practicumStaffService) GetStaffByPracticumID(practicumID int) ([]model.PracticumStaff, error) {
+	return s.repo.GetStaffByPracticumID(practicumID)
+}
+
+func (s *practicumStaffService) GetPracticumIDsByUserID(userID int) ([]int, error) {
+	return s.repo.GetPracticumIDsByUserID(userID)
+}
+
+func (s *practicumStaffService) IsAssigned(userID, practicumID int) (bool, error) {
+	return s.repo.IsAssigned(userID, practicumID)
+}
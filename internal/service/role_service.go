@@ -0,0 +1,121 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/repository"
+)
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+	ErrUserNotFound = errors.New("user not found")
+	ErrLastAdmin    = errors.New("cannot revoke the admin role from the last admin")
+)
+
+type RoleService interface {
+	GetAllRoles() ([]model.RoleModel, error)
+	GetUserRoles(userID int) ([]model.RoleModel, error)
+	GrantRole(userID int, roleName string) ([]model.RoleModel, error)
+	RevokeRole(userID int, roleName string) ([]model.RoleModel, error)
+}
+
+type roleService struct {
+	repo repository.AuthRepository
+}
+
+func NewRoleService(repo repository.AuthRepository) RoleService {
+	return &roleService{repo: repo}
+}
+
+func (s *roleService) GetAllRoles() ([]model.RoleModel, error) {
+	return s.repo.GetAllRoles()
+}
+
+func (s *roleService) GetUserRoles(userID int) ([]model.RoleModel, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.Roles, nil
+}
+
+// GrantRole adds the role to the user, granting a role the user already has is a no-op
+func (s *roleService) GrantRole(userID int, roleName string) ([]model.RoleModel, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+
+	role, err := s.getRole(roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddRoleToUser(userID, role.ID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetRolesByUserID(userID)
+}
+
+// RevokeRole removes the role from the user. The last admin can't lose the admin role,
+// otherwise nobody could manage roles anymore.
+func (s *roleService) RevokeRole(userID int, roleName string) ([]model.RoleModel, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.getRole(roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	if role.Name == model.RoleAdmin && hasRole(user.Roles, model.RoleAdmin) {
+		admins, err := s.repo.CountUsersWithRole(role.ID)
+		if err != nil {
+			return nil, err
+		}
+		if admins <= 1 {
+			return nil, ErrLastAdmin
+		}
+	}
+
+	if err := s.repo.RemoveRoleFromUser(userID, role.ID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetRolesByUserID(userID)
+}
+
+func (s *roleService) getUser(userID int) (*model.User, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *roleService) getRole(name string) (*model.RoleModel, error) {
+	role, err := s.repo.GetRoleByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func hasRole(roles []model.RoleModel, name model.Role) bool {
+	for _, role := range roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/repository"
+	"github.com/egasa21/si-lab-api-go/pkg/auth"
+	"github.com/egasa21/si-lab-api-go/pkg/oidc"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrSSOStateInvalid   = errors.New("invalid or expired sign-in request")
+	ErrSSOEmailMissing   = errors.New("identity provider did not return an email address")
+	ErrSSOEmailUnclaimed = errors.New("an account with this email already exists, sign in with your password first")
+)
+
+// SSOAuthRequestTTL is how long a sign-in started by LoginURL can be finished
+const SSOAuthRequestTTL = 10 * time.Minute
+
+type SSOService interface {
+	// LoginURL also returns the state, the caller has to bind it to the browser and check it on the callback
+	LoginURL(ctx context.Context) (loginURL, state string, err error)
+	Callback(ctx context.Context, code, state string) (*auth.TokenDetails, error)
+}
+
+type ssoService struct {
+	provider       *oidc.Provider
+	issuer         string
+	studentIDClaim string
+	authService    AuthService
+	authRepo       repository.AuthRepository
+	studentRepo    repository.StudentRepository
+	identityRepo   repository.UserIdentityRepository
+}
+
+// NewSSOService signs users in through an OIDC provider. studentIDClaim names the ID token
+// claim holding the student_id_number used to link the account to a student.
+func NewSSOService(provider *oidc.Provider, issuer, studentIDClaim string, authService AuthService, authRepo repository.AuthRepository, studentRepo repository.StudentRepository, identityRepo repository.UserIdentityRepository) SSOService {
+	return &ssoService{
+		provider:       provider,
+		issuer:         strings.TrimRight(issuer, "/"),
+		studentIDClaim: studentIDClaim,
+		authService:    authService,
+		authRepo:       authRepo,
+		studentRepo:    studentRepo,
+		identityRepo:   identityRepo,
+	}
+}
+
+// LoginURL starts an authorization code flow, the PKCE verifier and nonce stay on the server
+func (s *ssoService) LoginURL(ctx context.Context) (string, string, error) {
+	state, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	request := &model.OIDCAuthRequest{
+		State:        state,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(SSOAuthRequestTTL),
+	}
+	if err := s.identityRepo.CreateAuthRequest(request); err != nil {
+		return "", "", err
+	}
+
+	loginURL, err := s.provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallengeS256(codeVerifier))
+	if err != nil {
+		return "", "", err
+	}
+	return loginURL, state, nil
+}
+
+// Callback finishes the flow and issues our own tokens for the user behind the ID token
+func (s *ssoService) Callback(ctx context.Context, code, state string) (*auth.TokenDetails, error) {
+	request, err := s.identityRepo.ConsumeAuthRequest(state)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil || time.Now().After(request.ExpiresAt) {
+		return nil, ErrSSOStateInvalid
+	}
+
+	token, err := s.provider.Exchange(ctx, code, request.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := s.provider.VerifyIDToken(ctx, token.IDToken, request.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.resolveUser(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.authService.StartSession(user.IDUser)
+}
+
+// resolveUser finds the user linked to the external account, linking or provisioning one on first sign-in
+func (s *ssoService) resolveUser(claims jwt.MapClaims) (*model.User, error) {
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, oidc.ErrInvalidIDToken
+	}
+
+	identity, err := s.identityRepo.GetUserIdentity(s.issuer, subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		user, err := s.authRepo.GetUserByID(identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		s.linkStudent(user, claims)
+		return user, nil
+	}
+
+	email := strings.ToLower(strings.TrimSpace(stringClaim(claims, "email")))
+	if email == "" {
+		return nil, ErrSSOEmailMissing
+	}
+	emailVerified, _ := claims["email_verified"].(bool)
+
+	user, err := s.authRepo.GetUserByEmail(email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if user != nil && !emailVerified {
+		// Without a verified email anyone at the provider could take over the local account
+		return nil, ErrSSOEmailUnclaimed
+	}
+	if user == nil {
+		if user, err = s.provisionUser(email, emailVerified); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.identityRepo.CreateUserIdentity(&model.UserIdentity{UserID: user.IDUser, Issuer: s.issuer, Subject: subject}); err != nil {
+		return nil, err
+	}
+
+	s.linkStudent(user, claims)
+	return user, nil
+}
+
+// provisionUser creates a student account without a password, one can be set through forgot-password
+func (s *ssoService) provisionUser(email string, emailVerified bool) (*model.User, error) {
+	role, err := s.authRepo.GetRoleByName(string(model.RoleStudent))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	user := &model.User{Email: email}
+	if err := s.authRepo.CreateUser(user, []int{role.ID}, nil); err != nil {
+		return nil, err
+	}
+
+	if emailVerified {
+		if _, err := s.authRepo.MarkEmailVerified(user.IDUser, email); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info().Int("user_id", user.IDUser).Msg("Provisioned user from single sign-on")
+	return s.authRepo.GetUserByID(user.IDUser)
+}
+
+// linkStudent links the user to the student matching the configured claim. It's best effort,
+// a missing claim or student doesn't block the sign-in.
+func (s *ssoService) linkStudent(user *model.User, claims jwt.MapClaims) {
+	if user.IDStudent != 0 || s.studentIDClaim == "" {
+		return
+	}
+
+	studentIDNumber := stringClaim(claims, s.studentIDClaim)
+	if studentIDNumber == "" {
+		return
+	}
+
+	student, err := s.studentRepo.GetStudentByStudentID(studentIDNumber)
+	if err != nil || student == nil {
+		log.Warn().Err(err).Int("user_id", user.IDUser).Msg("No student found for single sign-on claim")
+		return
+	}
+
+	linked, err := s.authRepo.LinkStudent(user.IDUser, student.ID)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", user.IDUser).Msg("Failed to link student")
+		return
+	}
+	if !linked {
+		log.Warn().Int("user_id", user.IDUser).Int("student_id", student.ID).Msg("Student is already linked to another user")
+		return
+	}
+	user.IDStudent = student.ID
+}
+
+// stringClaim reads a claim that providers may send either as a string or as a number
+func stringClaim(claims jwt.MapClaims, name string) string {
+	switch v := claims[name].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
@@ -1,28 +1,57 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
+
 	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/egasa21/si-lab-api-go/internal/repository"
 )
 
+var (
+	ErrClassNotFound             = errors.New("class not found")
+	ErrEnrollmentNotFound        = errors.New("enrollment not found")
+	ErrNotRegisteredForPracticum = errors.New("the student has to be registered for the practicum of the class first")
+)
+
 type StudentClassEnrollmentService interface {
 	EnrollStudent(classID, studentID int) error
 	GetEnrollmentsByStudentID(studentID int) ([]model.StudentClassEnrollment, error)
 	GetEnrollmentsByClassID(classID int) ([]model.StudentClassEnrollment, error)
+	GetEnrollmentByID(enrollmentID int) (*model.StudentClassEnrollment, error)
 	UnenrollStudent(enrollmentID int) error
 }
 
 type studentClassEnrollmentService struct {
-	repo repository.StudentClassEnrollmentRepository
+	repo             repository.StudentClassEnrollmentRepository
+	classRepo        repository.PracticumClassRepository
+	registrationRepo repository.StudentRegistrationRepository
 }
 
 // NewStudentClassEnrollmentService creates a new instance of the service
-func NewStudentClassEnrollmentService(repo repository.StudentClassEnrollmentRepository) StudentClassEnrollmentService {
-	return &studentClassEnrollmentService{repo: repo}
+func NewStudentClassEnrollmentService(repo repository.StudentClassEnrollmentRepository, classRepo repository.PracticumClassRepository, registrationRepo repository.StudentRegistrationRepository) StudentClassEnrollmentService {
+	return &studentClassEnrollmentService{repo: repo, classRepo: classRepo, registrationRepo: registrationRepo}
 }
 
-// EnrollStudent enrolls a student in a class
+// EnrollStudent enrolls a student in a class of a practicum the student is registered for,
+// so enrollment goes through the same registration window and study plan checks
 func (s *studentClassEnrollmentService) EnrollStudent(classID, studentID int) error {
+	class, err := s.classRepo.GetClassByID(classID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrClassNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	registered, err := s.registrationRepo.IsStudentRegistered(studentID, class.PracticumID)
+	if err != nil {
+		return err
+	}
+	if !registered {
+		return ErrNotRegisteredForPracticum
+	}
+
 	return s.repo.EnrollStudent(classID, studentID)
 }
 
@@ -36,6 +65,18 @@ func (s *studentClassEnrollmentService) GetEnrollmentsByClassID(classID int) ([]
 	return s.repo.GetEnrollmentsByClassID(classID)
 }
 
+// GetEnrollmentByID retrieves a single class enrollment
+func (s *studentClassEnrollmentService) GetEnrollmentByID(enrollmentID int) (*model.StudentClassEnrollment, error) {
+	enrollment, err := s.repo.GetEnrollmentByID(enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil {
+		return nil, ErrEnrollmentNotFound
+	}
+	return enrollment, nil
+}
+
 // UnenrollStudent removes a student from a class
 func (s *studentClassEnrollmentService) UnenrollStudent(enrollmentID int) error {
 	return s.repo.DeleteEnrollment(enrollmentID)
@@ -77,7 +77,8 @@ func (s *studentDataService) GetStudentPracticumActivity(userID int) ([]dto.Stud
 		return nil, err
 	}
 
-	moduleContents, err := s.practicumModuleContentService.GetContentByIDs(practicumModuleContentIDs)
+	// Students see the titles of the published versions, not of drafts in progress
+	moduleContents, err := s.practicumModuleContentService.GetPublishedContentByIDs(practicumModuleContentIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -1,37 +1,114 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
+	"time"
+
 	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/egasa21/si-lab-api-go/internal/repository"
 )
 
+var (
+	ErrPracticumNotInStudyPlan = errors.New("the practicum is not on an approved study plan of the student")
+	ErrRegistrationNotFound    = errors.New("registration not found")
+)
+
 type StudentRegistrationService interface {
-	RegisterStudent(registration *model.StudentRegistration) error
-	GetRegistrationsByStudentID(studentID int) ([]model.StudentRegistration, error)
+	// RegisterStudent registers the student for every practicum or, when one of them is refused, for none
+	RegisterStudent(studentID int, practicumIDs []int) error
+	// GetRegistrationsByStudentID defaults to the active term when academicTermID is 0
+	GetRegistrationsByStudentID(studentID, academicTermID int, allTerms bool) ([]model.StudentRegistration, error)
 	GetRegistrationsByPracticumID(practicumID int) ([]model.StudentRegistration, error)
+	GetRegistrationByID(id int) (*model.StudentRegistration, error)
 	DeleteRegistration(id int) error
 }
 
 type studentRegistrationService struct {
-	repo repository.StudentRegistrationRepository
+	repo          repository.StudentRegistrationRepository
+	practicumRepo repository.PracticumRepository
+	termRepo      repository.AcademicTermRepository
+	studyPlanRepo repository.StudyPlanRepository
+}
+
+func NewStudentRegistrationService(repo repository.StudentRegistrationRepository, practicumRepo repository.PracticumRepository, termRepo repository.AcademicTermRepository, studyPlanRepo repository.StudyPlanRepository) StudentRegistrationService {
+	return &studentRegistrationService{repo: repo, practicumRepo: practicumRepo, termRepo: termRepo, studyPlanRepo: studyPlanRepo}
 }
 
-func NewStudentRegistrationService(repo repository.StudentRegistrationRepository) StudentRegistrationService {
-	return &studentRegistrationService{repo: repo}
+// RegisterStudent only accepts registrations inside the registration window of the practicum's term,
+// for practicums listed on an approved study plan of the student
+func (s *studentRegistrationService) RegisterStudent(studentID int, practicumIDs []int) error {
+	registrations := make([]model.StudentRegistration, 0, len(practicumIDs))
+	seen := make(map[int]bool, len(practicumIDs))
+	for _, practicumID := range practicumIDs {
+		if seen[practicumID] {
+			continue
+		}
+		seen[practicumID] = true
+		if err := s.checkRegistration(studentID, practicumID); err != nil {
+			return err
+		}
+		registrations = append(registrations, model.StudentRegistration{StudentID: studentID, PracticumID: practicumID})
+	}
+	if len(registrations) == 0 {
+		return nil
+	}
+
+	return s.repo.RegisterStudent(registrations)
 }
 
-func (s *studentRegistrationService) RegisterStudent(registration *model.StudentRegistration) error {
-	return s.repo.RegisterStudent(registration)
+func (s *studentRegistrationService) checkRegistration(studentID, practicumID int) error {
+	practicum, err := s.practicumRepo.GetPracticumByID(practicumID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPracticumNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if practicum.AcademicTermID != nil {
+		term, err := s.termRepo.GetAcademicTermByID(*practicum.AcademicTermID)
+		if err != nil {
+			return err
+		}
+		if term != nil && !term.RegistrationOpen(time.Now()) {
+			return ErrRegistrationClosed
+		}
+	}
+
+	approved, err := s.studyPlanRepo.IsPracticumApproved(studentID, practicumID)
+	if err != nil {
+		return err
+	}
+	if !approved {
+		return ErrPracticumNotInStudyPlan
+	}
+	return nil
 }
 
-func (s *studentRegistrationService) GetRegistrationsByStudentID(studentID int) ([]model.StudentRegistration, error) {
-	return s.repo.GetRegistrationsByStudentID(studentID)
+func (s *studentRegistrationService) GetRegistrationsByStudentID(studentID, academicTermID int, allTerms bool) ([]model.StudentRegistration, error) {
+	termID, withoutTerm, err := resolveAcademicTermID(s.termRepo, academicTermID, allTerms)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetRegistrationsByStudentID(studentID, termID, withoutTerm)
 }
 
 func (s *studentRegistrationService) GetRegistrationsByPracticumID(practicumID int) ([]model.StudentRegistration, error) {
 	return s.repo.GetRegistrationsByPracticumID(practicumID)
 }
 
+func (s *studentRegistrationService) GetRegistrationByID(id int) (*model.StudentRegistration, error) {
+	registration, err := s.repo.GetRegistrationByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if registration == nil {
+		return nil, ErrRegistrationNotFound
+	}
+	return registration, nil
+}
+
 func (s *studentRegistrationService) DeleteRegistration(id int) error {
 	return s.repo.DeleteRegistration(id)
 }
@@ -1,24 +1,32 @@
 package service
 
 import (
+	"errors"
+
 	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/egasa21/si-lab-api-go/internal/repository"
+	"github.com/google/uuid"
 )
 
+var ErrStudentNotFound = errors.New("student not found")
+
 type StudentService interface {
 	GetAllStudents(page, limit int) ([]model.Student, int, error)
 	GetStudentByID(id int) (*model.Student, error)
 	GetStudentByUserID(id int) (*model.Student, error)
 	CreateStudent(student *model.Student) (int, error)
 	GetStudentByStudentID(student_id_number string) (*model.Student, error)
+	// SetStudyPlan attaches a study plan the student uploaded to their own record
+	SetStudyPlan(userID int, attachmentID uuid.UUID) (*model.Student, error)
 }
 
 type studentService struct {
-	repo repository.StudentRepository
+	repo           repository.StudentRepository
+	attachmentRepo repository.AttachmentRepository
 }
 
-func NewStudentService(repo repository.StudentRepository) StudentService {
-	return &studentService{repo: repo}
+func NewStudentService(repo repository.StudentRepository, attachmentRepo repository.AttachmentRepository) StudentService {
+	return &studentService{repo: repo, attachmentRepo: attachmentRepo}
 }
 
 func (s *studentService) GetAllStudents(page, limit int) ([]model.Student, int, error) {
@@ -40,3 +48,30 @@ func (s *studentService) GetStudentByUserID(id int) (*model.Student, error) {
 func (s *studentService) CreateStudent(student *model.Student) (int, error) {
 	return s.repo.CreateStudent(student)
 }
+
+func (s *studentService) SetStudyPlan(userID int, attachmentID uuid.UUID) (*model.Student, error) {
+	student, err := s.repo.GetStudentByUserID(userID)
+	if err != nil || student == nil {
+		return nil, ErrStudentNotFound
+	}
+
+	attachment, err := s.attachmentRepo.GetAttachmentByID(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil || attachment.Purpose != model.AttachmentStudyPlan ||
+		attachment.UploadedBy == nil || *attachment.UploadedBy != userID {
+		return nil, ErrStudyPlanAttachmentInvalid
+	}
+
+	updated, err := s.repo.SetStudyPlanAttachment(student.ID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrStudentNotFound
+	}
+
+	student.StudyPlanAttachmentID = &attachmentID
+	return student, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/egasa21/si-lab-api-go/internal/model"
+	"github.com/egasa21/si-lab-api-go/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrStudyPlanNotFound           = errors.New("study plan not found")
+	ErrStudyPlanAttachmentInvalid  = errors.New("the attachment has to be a study plan you uploaded")
+	ErrStudyPlanPracticumsRequired = errors.New("the study plan has to list at least one practicum")
+	ErrStudyPlanNotPending         = errors.New("only pending study plans can be approved or rejected")
+	ErrStudyPlanReasonRequired     = errors.New("a reason is required to reject a study plan")
+	ErrStudyPlanStatusFilter       = errors.New("status must be pending, approved or rejected")
+)
+
+type StudyPlanService interface {
+	// SubmitStudyPlan records the study plan a student uploaded with the practicums it lists, for staff to review
+	SubmitStudyPlan(userID int, attachmentID uuid.UUID, practicumIDs []int) (*model.StudyPlan, error)
+	GetStudyPlan(id int) (*model.StudyPlan, error)
+	GetStudyPlansByUserID(userID int) ([]model.StudyPlan, error)
+	// GetStudyPlans lists plans oldest first so the review queue is worked in order
+	GetStudyPlans(status model.StudyPlanStatus, page, limit int) ([]model.StudyPlan, int, error)
+	ApproveStudyPlan(id, reviewerID int) (*model.StudyPlan, error)
+	RejectStudyPlan(id, reviewerID int, reason string) (*model.StudyPlan, error)
+}
+
+type studyPlanService struct {
+	repo           repository.StudyPlanRepository
+	studentRepo    repository.StudentRepository
+	attachmentRepo repository.AttachmentRepository
+	practicumRepo  repository.PracticumRepository
+}
+
+func NewStudyPlanService(repo repository.StudyPlanRepository, studentRepo repository.StudentRepository, attachmentRepo repository.AttachmentRepository, practicumRepo repository.PracticumRepository) StudyPlanService {
+	return &studyPlanService{repo: repo, studentRepo: studentRepo, attachmentRepo: attachmentRepo, practicumRepo: practicumRepo}
+}
+
+func (s *studyPlanService) SubmitStudyPlan(userID int, attachmentID uuid.UUID, practicumIDs []int) (*model.StudyPlan, error) {
+	student, err := s.studentRepo.GetStudentByUserID(userID)
+	if err != nil || student == nil {
+		return nil, ErrStudentNotFound
+	}
+
+	attachment, err := s.attachmentRepo.GetAttachmentByID(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil || attachment.Purpose != model.AttachmentStudyPlan ||
+		attachment.UploadedBy == nil || *attachment.UploadedBy != userID {
+		return nil, ErrStudyPlanAttachmentInvalid
+	}
+
+	ids := uniquePositiveIDs(practicumIDs)
+	if len(ids) == 0 {
+		return nil, ErrStudyPlanPracticumsRequired
+	}
+	practicums, err := s.practicumRepo.GetPracticumByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(practicums) != len(ids) {
+		return nil, ErrPracticumNotFound
+	}
+
+	plan := &model.StudyPlan{StudentID: student.ID, AttachmentID: attachmentID, PracticumIDs: ids}
+	// study_plan_file links to the upload, staff open it from the student record
+	if err := s.repo.CreateStudyPlan(plan, "/v1/attachments/"+attachmentID.String()+"/download"); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *studyPlanService) GetStudyPlan(id int) (*model.StudyPlan, error) {
+	plan, err := s.repo.GetStudyPlanByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, ErrStudyPlanNotFound
+	}
+	return plan, nil
+}
+
+func (s *studyPlanService) GetStudyPlansByUserID(userID int) ([]model.StudyPlan, error) {
+	student, err := s.studentRepo.GetStudentByUserID(userID)
+	if err != nil || student == nil {
+		return nil, ErrStudentNotFound
+	}
+	return s.repo.GetStudyPlansByStudentID(student.ID)
+}
+
+func (s *studyPlanService) GetStudyPlans(status model.StudyPlanStatus, page, limit int) ([]model.StudyPlan, int, error) {
+	switch status {
+	case "", model.StudyPlanPending, model.StudyPlanApproved, model.StudyPlanRejected:
+	default:
+		return nil, 0, ErrStudyPlanStatusFilter
+	}
+	return s.repo.GetStudyPlans(status, page, limit)
+}
+
+func (s *studyPlanService) ApproveStudyPlan(id, reviewerID int) (*model.StudyPlan, error) {
+	return s.review(id, model.StudyPlanApproved, reviewerID, nil)
+}
+
+func (s *studyPlanService) RejectStudyPlan(id, reviewerID int, reason string) (*model.StudyPlan, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrStudyPlanReasonRequired
+	}
+	return s.review(id, model.StudyPlanRejected, reviewerID, &reason)
+}
+
+func (s *studyPlanService) review(id int, status model.StudyPlanStatus, reviewerID int, reason *string) (*model.StudyPlan, error) {
+	reviewed, err := s.repo.ReviewStudyPlan(id, status, reviewerID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.GetStudyPlan(id)
+	if err != nil {
+		return nil, err
+	}
+	if !reviewed {
+		return nil, ErrStudyPlanNotPending
+	}
+	return plan, nil
+}
+
+// uniquePositiveIDs drops duplicates and non-positive IDs, keeping the order
+func uniquePositiveIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if id > 0 && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
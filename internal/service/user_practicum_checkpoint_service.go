@@ -1,13 +1,19 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
+
 	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/egasa21/si-lab-api-go/internal/repository"
 	"github.com/rs/zerolog/log"
 )
 
+var ErrCheckpointPositionInvalid = errors.New("the module and content of a checkpoint have to belong to its practicum")
+
 type UserPracticumCheckpointService interface {
 	CreateCheckpoint(checkpoint *model.UserPracticumCheckpoint) error
+	GetCheckpointByID(id int) (*model.UserPracticumCheckpoint, error)
 	GetCheckpointByUserAndPracticum(userID, practicumID int) (*model.UserPracticumCheckpoint, error)
 	GetCheckpointByUser(userID int) ([]model.UserPracticumCheckpoint, error)
 	UpdateCheckpoint(checkpoint *model.UserPracticumCheckpoint) error
@@ -15,16 +21,56 @@ type UserPracticumCheckpointService interface {
 }
 
 type userPracticumCheckpointService struct {
-	repo repository.UserPracticumCheckpointRepository
+	repo             repository.UserPracticumCheckpointRepository
+	studentRepo      repository.StudentRepository
+	registrationRepo repository.StudentRegistrationRepository
+	moduleRepo       repository.PracticumModuleRepository
+	contentRepo      repository.PracticumModuleContentRepository
 }
 
-func NewUserPracticumCheckpointService(repo repository.UserPracticumCheckpointRepository) UserPracticumCheckpointService {
-	return &userPracticumCheckpointService{repo: repo}
+func NewUserPracticumCheckpointService(repo repository.UserPracticumCheckpointRepository, studentRepo repository.StudentRepository, registrationRepo repository.StudentRegistrationRepository, moduleRepo repository.PracticumModuleRepository, contentRepo repository.PracticumModuleContentRepository) UserPracticumCheckpointService {
+	return &userPracticumCheckpointService{
+		repo:             repo,
+		studentRepo:      studentRepo,
+		registrationRepo: registrationRepo,
+		moduleRepo:       moduleRepo,
+		contentRepo:      contentRepo,
+	}
+}
+
+// checkCheckpoint makes sure the user is registered for the practicum and the module and
+// content are in it
+func (s *userPracticumCheckpointService) checkCheckpoint(checkpoint *model.UserPracticumCheckpoint) error {
+	if err := checkRegistered(s.studentRepo, s.registrationRepo, checkpoint.UserID, checkpoint.PracticumID); err != nil {
+		return err
+	}
+
+	module, err := s.moduleRepo.GetModuleByID(checkpoint.ModuleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCheckpointPositionInvalid
+	}
+	if err != nil {
+		return err
+	}
+	content, err := s.contentRepo.GetContentByID(checkpoint.ContentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCheckpointPositionInvalid
+	}
+	if err != nil {
+		return err
+	}
+	if module.PracticumID != checkpoint.PracticumID || content.IDModule != module.ID {
+		return ErrCheckpointPositionInvalid
+	}
+	return nil
 }
 
 // CreateCheckpoint creates a new user practicum checkpoint
 func (s *userPracticumCheckpointService) CreateCheckpoint(checkpoint *model.UserPracticumCheckpoint) error {
-	// You could add validation here, for example, checking if the user or practicum exists.
+	if err := s.checkCheckpoint(checkpoint); err != nil {
+		return err
+	}
+
 	err := s.repo.CreateCheckpoint(checkpoint)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create user practicum checkpoint")
@@ -33,6 +79,16 @@ func (s *userPracticumCheckpointService) CreateCheckpoint(checkpoint *model.User
 	return nil
 }
 
+// GetCheckpointByID retrieves a checkpoint by its ID
+func (s *userPracticumCheckpointService) GetCheckpointByID(id int) (*model.UserPracticumCheckpoint, error) {
+	checkpoint, err := s.repo.GetCheckpointByID(id)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get user practicum checkpoint")
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
 // GetCheckpointByUserAndPracticum retrieves a checkpoint by user and practicum ID
 func (s *userPracticumCheckpointService) GetCheckpointByUserAndPracticum(userID, practicumID int) (*model.UserPracticumCheckpoint, error) {
 	checkpoint, err := s.repo.GetCheckpointByUserAndPracticum(userID, practicumID)
@@ -60,7 +116,10 @@ func (s *userPracticumCheckpointService) GetCheckpointByUser(userID int) ([]mode
 
 // UpdateCheckpoint updates an existing user practicum checkpoint
 func (s *userPracticumCheckpointService) UpdateCheckpoint(checkpoint *model.UserPracticumCheckpoint) error {
-	// You could add business logic here, like ensuring the checkpoint exists before updating it.
+	if err := s.checkCheckpoint(checkpoint); err != nil {
+		return err
+	}
+
 	err := s.repo.UpdateCheckpoint(checkpoint)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to update user practicum checkpoint")
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"database/sql"
 	"errors"
 	"time"
 
@@ -8,9 +9,15 @@ import (
 	"github.com/egasa21/si-lab-api-go/internal/repository"
 )
 
+var (
+	ErrProgressNotFound      = errors.New("progress not found")
+	ErrProgressNotRegistered = errors.New("progress is only kept for students registered for the practicum")
+)
+
 type UserPracticumProgressService interface {
 	CreateProgress(progress *model.UserPracticumProgress) error
 	GetProgress(userID, practicumID int) (*model.UserPracticumProgress, error)
+	GetProgressByID(id int) (*model.UserPracticumProgress, error)
 	GetProgressByPracticumIDs(pracIDs []int) ([]model.UserPracticumProgress, error)
 	UpdateProgress(progress *model.UserPracticumProgress) error
 	MarkAsCompleted(userID, practicumID int) error
@@ -18,11 +25,34 @@ type UserPracticumProgressService interface {
 }
 
 type userPracticumProgressService struct {
-	repo repository.UserPracticumProgressRepository
+	repo             repository.UserPracticumProgressRepository
+	studentRepo      repository.StudentRepository
+	registrationRepo repository.StudentRegistrationRepository
+}
+
+func NewUserPracticumProgressService(repo repository.UserPracticumProgressRepository, studentRepo repository.StudentRepository, registrationRepo repository.StudentRegistrationRepository) UserPracticumProgressService {
+	return &userPracticumProgressService{repo: repo, studentRepo: studentRepo, registrationRepo: registrationRepo}
 }
 
-func NewUserPracticumProgressService(repo repository.UserPracticumProgressRepository) UserPracticumProgressService {
-	return &userPracticumProgressService{repo: repo}
+// checkRegistered fails with ErrProgressNotRegistered unless the user is a student registered
+// for the practicum, progress and checkpoints are only written for them
+func checkRegistered(studentRepo repository.StudentRepository, registrationRepo repository.StudentRegistrationRepository, userID, practicumID int) error {
+	student, err := studentRepo.GetStudentByUserID(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProgressNotRegistered
+	}
+	if err != nil {
+		return err
+	}
+
+	registered, err := registrationRepo.IsStudentRegistered(student.ID, practicumID)
+	if err != nil {
+		return err
+	}
+	if !registered {
+		return ErrProgressNotRegistered
+	}
+	return nil
 }
 
 func (s *userPracticumProgressService) CreateProgress(progress *model.UserPracticumProgress) error {
@@ -30,6 +60,9 @@ func (s *userPracticumProgressService) CreateProgress(progress *model.UserPracti
 	if progress.Progress < 0 || progress.Progress > 100 {
 		return errors.New("progress must be between 0 and 100")
 	}
+	if err := checkRegistered(s.studentRepo, s.registrationRepo, progress.UserID, progress.PracticumID); err != nil {
+		return err
+	}
 
 	// If progress is 100, set completion time
 	if progress.Progress == 100 {
@@ -41,7 +74,19 @@ func (s *userPracticumProgressService) CreateProgress(progress *model.UserPracti
 }
 
 func (s *userPracticumProgressService) GetProgress(userID, practicumID int) (*model.UserPracticumProgress, error) {
-	return s.repo.GetProgressByUserAndPracticum(userID, practicumID)
+	progress, err := s.repo.GetProgressByUserAndPracticum(userID, practicumID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProgressNotFound
+	}
+	return progress, err
+}
+
+func (s *userPracticumProgressService) GetProgressByID(id int) (*model.UserPracticumProgress, error) {
+	progress, err := s.repo.GetProgressByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProgressNotFound
+	}
+	return progress, err
 }
 
 func (s *userPracticumProgressService) UpdateProgress(progress *model.UserPracticumProgress) error {
@@ -49,6 +94,9 @@ func (s *userPracticumProgressService) UpdateProgress(progress *model.UserPracti
 	if progress.Progress < 0 || progress.Progress > 100 {
 		return errors.New("progress must be between 0 and 100")
 	}
+	if err := checkRegistered(s.studentRepo, s.registrationRepo, progress.UserID, progress.PracticumID); err != nil {
+		return err
+	}
 
 	// If progress is 100, mark as completed
 	if progress.Progress == 100 {
@@ -62,7 +110,11 @@ func (s *userPracticumProgressService) UpdateProgress(progress *model.UserPracti
 }
 
 func (s *userPracticumProgressService) MarkAsCompleted(userID, practicumID int) error {
-	progress, err := s.repo.GetProgressByUserAndPracticum(userID, practicumID)
+	if err := checkRegistered(s.studentRepo, s.registrationRepo, userID, practicumID); err != nil {
+		return err
+	}
+
+	progress, err := s.GetProgress(userID, practicumID)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// LocalBlobPath is where the API serves local blobs, signed URLs point at PublicURL + LocalBlobPath + key
+const LocalBlobPath = "/v1/blobs/"
+
+var ErrSignatureInvalid = errors.New("the download link is invalid or has expired")
+
+// LocalStore keeps blobs as files under a directory. It signs its own download URLs, which
+// the API checks with Verify before serving the file.
+type LocalStore struct {
+	dir        string
+	publicURL  string
+	signingKey []byte
+}
+
+func NewLocalStore(dir, publicURL, signingKey string) (*LocalStore, error) {
+	if dir == "" {
+		dir = "storage"
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	key := []byte(signingKey)
+	if len(key) == 0 {
+		// Links then stop working on restart, fine for development only
+		log.Warn().Msg("STORAGE_SIGNING_KEY is not set, using a random key for download links")
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &LocalStore{dir: dir, publicURL: strings.TrimRight(publicURL, "/"), signingKey: key}, nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, object Object, body io.Reader) (err error) {
+	target, err := s.path(object.Key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return err
+	}
+
+	// Written next to the target and renamed, so readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		tmp.Close()
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	hash := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(tmp, hash), body); err != nil {
+		return err
+	}
+	if object.SHA256 != "" && hex.EncodeToString(hash.Sum(nil)) != object.SHA256 {
+		return ErrChecksumMismatch
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStore) SignedURL(ctx context.Context, key string, expiry time.Duration, filename string) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("filename", filename)
+	query.Set("signature", s.sign(key, expires, filename))
+
+	return s.publicURL + LocalBlobPath + escapeKey(key) + "?" + query.Encode(), nil
+}
+
+// Verify checks the signature and expiry of a download link made by SignedURL
+func (s *LocalStore) Verify(key, expires, filename, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return ErrSignatureInvalid
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, expires, filename))) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (s *LocalStore) sign(key, expires, filename string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	fmt.Fprintf(mac, "%s\n%s\n%s", key, expires, filename)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path maps a key to a file under the store directory, refusing keys that would leave it
+func (s *LocalStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
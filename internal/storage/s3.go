@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 refuses presigned URLs valid for longer than a week
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// S3Store keeps blobs in a bucket of an S3-compatible service, requests are signed with
+// Signature Version 4
+type S3Store struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	client    *http.Client
+}
+
+func NewS3Store(endpoint, region, bucket, accessKey, secretKey string, pathStyle bool) (*S3Store, error) {
+	if endpoint == "" || bucket == "" || accessKey == "" || secretKey == "" {
+		return nil, errors.New("S3 storage needs an endpoint, a bucket and credentials")
+	}
+	parsed, err := url.Parse(strings.TrimRight(endpoint, "/"))
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3Store{
+		endpoint:  parsed,
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		pathStyle: pathStyle,
+		client:    &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, object Object, body io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(object.Key).String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = object.Size
+	req.Header.Set("Content-Type", object.ContentType)
+
+	payloadHash := object.SHA256
+	if payloadHash == "" {
+		payloadHash = unsignedPayload
+	}
+	s.sign(req, payloadHash, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The service checks the body against x-amz-content-sha256
+	if resp.StatusCode == http.StatusBadRequest && strings.Contains(readErrorBody(resp), "XAmzContentSHA256Mismatch") {
+		return ErrChecksumMismatch
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("S3 put %s: %s", object.Key, resp.Status)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, unsignedPayload, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("S3 get %s: %s", key, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, unsignedPayload, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("S3 delete %s: %s", key, resp.Status)
+	}
+	return nil
+}
+
+// SignedURL presigns a GET, the bucket serves the download without going through the API
+func (s *S3Store) SignedURL(ctx context.Context, key string, expiry time.Duration, filename string) (string, error) {
+	if expiry > maxPresignExpiry {
+		expiry = maxPresignExpiry
+	}
+	return s.presign(key, expiry, filename, time.Now()), nil
+}
+
+func (s *S3Store) presign(key string, expiry time.Duration, filename string, now time.Time) string {
+	now = now.UTC()
+	target := s.objectURL(key)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", sigV4Algorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if filename != "" {
+		query.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+	target.RawQuery = canonicalQuery(query)
+
+	request := canonicalRequest(http.MethodGet, target.EscapedPath(), target.RawQuery, map[string]string{"host": target.Host}, unsignedPayload)
+	signature := sigV4Signature(s.secretKey, s.region, now, request)
+	target.RawQuery += "&X-Amz-Signature=" + signature
+	return target.String()
+}
+
+// objectURL addresses the object with the bucket in the path or in the host name
+func (s *S3Store) objectURL(key string) *url.URL {
+	target := *s.endpoint
+	if s.pathStyle {
+		target.Path = s.endpoint.Path + "/" + s.bucket + "/" + key
+		target.RawPath = s.endpoint.Path + "/" + sigV4Escape(s.bucket) + "/" + escapeS3Key(key)
+	} else {
+		target.Host = s.bucket + "." + s.endpoint.Host
+		target.Path = s.endpoint.Path + "/" + key
+		target.RawPath = s.endpoint.Path + "/" + escapeS3Key(key)
+	}
+	return &target
+}
+
+// escapeS3Key escapes every byte of the key segments but the unreserved ones, the way S3
+// builds the canonical path. url.PathEscape leaves characters like $ and = as they are.
+func escapeS3Key(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = sigV4Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// sign adds the Signature Version 4 Authorization header, signing the host, the content type
+// and the x-amz headers
+func (s *S3Store) sign(req *http.Request, payloadHash string, at time.Time) {
+	at = at.UTC()
+	req.Header.Set("X-Amz-Date", at.Format(sigV4TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	request := canonicalRequest(req.Method, req.URL.EscapedPath(), canonicalQuery(req.URL.Query()), headers, payloadHash)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.accessKey, s.scope(at), signedHeaderNames(headers), sigV4Signature(s.secretKey, s.region, at, request)))
+}
+
+func (s *S3Store) scope(at time.Time) string {
+	return sigV4Scope(at, s.region)
+}
+
+func readErrorBody(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return string(body)
+}
@@ -0,0 +1,68 @@
+// Package storage keeps uploaded files behind the BlobStore interface, on the local disk or
+// in an S3-compatible bucket
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	ErrNotFound         = errors.New("blob not found")
+	ErrChecksumMismatch = errors.New("blob checksum does not match")
+	ErrInvalidKey       = errors.New("invalid blob key")
+)
+
+// Object describes a blob being stored. SHA256 is the hex digest of the body, stores reject
+// the upload when the body doesn't match it.
+type Object struct {
+	Key         string
+	ContentType string
+	Size        int64
+	SHA256      string
+}
+
+// BlobStore stores and serves file contents by key
+type BlobStore interface {
+	Put(ctx context.Context, object Object, body io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns an address that downloads the blob until the expiry passes,
+	// filename is what the browser saves it as
+	SignedURL(ctx context.Context, key string, expiry time.Duration, filename string) (string, error)
+}
+
+// Config selects and configures the store, Driver is "local" or "s3"
+type Config struct {
+	Driver string
+
+	// LocalDir is where the local store keeps files, PublicURL the address of this API its
+	// signed URLs point at and SigningKey the secret they are signed with
+	LocalDir   string
+	PublicURL  string
+	SigningKey string
+
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	// S3PathStyle addresses the bucket in the path instead of the host name, MinIO and most
+	// local stand-ins need it
+	S3PathStyle bool
+}
+
+// New returns the store for the configured driver, the local store when none is set
+func New(cfg Config) (BlobStore, error) {
+	switch cfg.Driver {
+	case "s3":
+		return NewS3Store(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKeyID, cfg.S3SecretAccessKey, cfg.S3PathStyle)
+	case "", "local":
+		return NewLocalStore(cfg.LocalDir, cfg.PublicURL, cfg.SigningKey)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
@@ -1,81 +1,117 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"os"
 	"time"
 
 	"github.com/egasa21/si-lab-api-go/internal/model"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET_KEY"))
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+
+	AccessTokenExpiration  = 10 * time.Minute
+	RefreshTokenExpiration = 7 * 24 * time.Hour
+)
 
 type TokenDetails struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
-	ExpiresIn    int64
+	AccessToken           string `json:"access_token"`
+	RefreshToken          string `json:"refresh_token"`
+	ExpiresIn             int64
+	AccessTokenID         string    `json:"-"`
+	RefreshTokenExpiresAt time.Time `json:"-"`
+}
+
+// Denylist holds the ids (jti) of tokens revoked before their expiry
+type Denylist interface {
+	IsRevoked(jti string) (bool, error)
+}
+
+var denylist Denylist
+
+// SetDenylist makes VerifyToken reject tokens whose jti has been revoked
+func SetDenylist(d Denylist) {
+	denylist = d
 }
 
 var ErrInvalidToken = errors.New("invalid token")
 var ErrTokenExpired = errors.New("token has expired")
+var ErrTokenRevoked = errors.New("token has been revoked")
 
 func GenerateJWT(userID int, roles []model.RoleModel) (*TokenDetails, error) {
+	if keys == nil {
+		return nil, ErrNoSigningKey
+	}
+
 	// Convert roles to string slice
 	roleNames := make([]string, len(roles))
 	for i, role := range roles {
 		roleNames[i] = string(role.Name)
 	}
 
-	// Access token expiration duration
-	accessTokenExpiration := 10 * time.Minute
-	refreshTokenExpiration := 7 * 24 * time.Hour
+	now := time.Now()
+	refreshTokenExpiresAt := now.Add(RefreshTokenExpiration)
+	accessTokenID := uuid.NewString()
 
-	// Access token claims
+	// Access token claims, jti lets a single access token be revoked
 	accessTokenClaims := jwt.MapClaims{
 		"user_id": userID,
 		"roles":   roleNames,
-		"exp":     time.Now().Add(accessTokenExpiration).Unix(),
+		"type":    TokenTypeAccess,
+		"jti":     accessTokenID,
+		"exp":     now.Add(AccessTokenExpiration).Unix(),
 	}
 
-	// Refresh token claims
+	// Refresh token claims, jti keeps every issued refresh token unique
 	refreshTokenClaims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(refreshTokenExpiration).Unix(),
+		"type":    TokenTypeRefresh,
+		"jti":     uuid.NewString(),
+		"exp":     refreshTokenExpiresAt.Unix(),
 	}
 
 	// Generate access token
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessTokenClaims)
-	accessTokenString, err := accessToken.SignedString(jwtSecret)
+	accessTokenString, err := keys.sign(accessTokenClaims)
 	if err != nil {
 		return nil, err
 	}
 
 	// Generate refresh token
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshTokenClaims)
-	refreshTokenString, err := refreshToken.SignedString(jwtSecret)
+	refreshTokenString, err := keys.sign(refreshTokenClaims)
 	if err != nil {
 		return nil, err
 	}
 
 	// Calculate expiresIn for the access token
-	expiresIn := int64(accessTokenExpiration.Seconds())
+	expiresIn := int64(AccessTokenExpiration.Seconds())
 
 	// Return tokens with expiresIn
 	return &TokenDetails{
-		AccessToken:  accessTokenString,
-		RefreshToken: refreshTokenString,
-		ExpiresIn:    expiresIn,
+		AccessToken:           accessTokenString,
+		RefreshToken:          refreshTokenString,
+		ExpiresIn:             expiresIn,
+		AccessTokenID:         accessTokenID,
+		RefreshTokenExpiresAt: refreshTokenExpiresAt,
 	}, nil
 }
 
 func VerifyToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return jwtSecret, nil
-	})
+	if keys == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	// The key is picked by kid, retired keys still verify the tokens they signed
+	token, err := jwt.Parse(tokenString, keys.publicKey, jwt.WithValidMethods([]string{
+		jwt.SigningMethodRS256.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+	}))
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -84,9 +120,50 @@ func VerifyToken(tokenString string) (jwt.MapClaims, error) {
 		return nil, ErrInvalidToken
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
 	}
 
-	return nil, ErrInvalidToken
+	if jti, _ := claims["jti"].(string); jti != "" && denylist != nil {
+		revoked, err := denylist.IsRevoked(jti)
+		if err != nil {
+			// fail closed when the denylist can't be consulted
+			return nil, ErrInvalidToken
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	return claims, nil
+}
+
+// VerifyRefreshToken verifies the token and makes sure it was issued as a refresh token
+func VerifyRefreshToken(tokenString string) (jwt.MapClaims, error) {
+	claims, err := VerifyToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenType, _ := claims["type"].(string); tokenType != TokenTypeRefresh {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// HashToken returns the hex encoded SHA-256 of a token, used to store tokens without keeping them in plain text
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateOpaqueToken returns a random URL-safe token for single-use links
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for signing or verification
+const minRSAKeyBits = 2048
+
+var ErrNoSigningKey = errors.New("no jwt signing key configured")
+
+// signingKey is a key pair whose private half may sign tokens
+type signingKey struct {
+	id         string
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+}
+
+// verificationKey is the public half of a key, kept after rotation until tokens signed with it expire
+type verificationKey struct {
+	id        string
+	method    jwt.SigningMethod
+	publicKey crypto.PublicKey
+}
+
+// KeySet holds the key tokens are signed with and every key they are verified against
+type KeySet struct {
+	signing      *signingKey
+	verification map[string]verificationKey
+}
+
+var keys *KeySet
+
+// SetKeySet makes GenerateJWT and VerifyToken use the given keys
+func SetKeySet(ks *KeySet) {
+	keys = ks
+}
+
+// LoadKeySet reads every <kid>.pem file in dir. Private keys (PKCS#8 or PKCS#1) can sign, public
+// keys (PKIX) only verify, which is how retired keys are kept around during a rotation. The key
+// named signingKeyID signs new tokens, it may be omitted when dir holds a single private key.
+// inlinePEM is an alternative to a file for platforms without a writable disk, it is added
+// under signingKeyID.
+func LoadKeySet(dir, signingKeyID, inlinePEM string) (*KeySet, error) {
+	ks := &KeySet{verification: make(map[string]verificationKey)}
+	private := make(map[string]*signingKey)
+
+	add := func(id string, data []byte) error {
+		if _, exists := ks.verification[id]; exists {
+			return fmt.Errorf("duplicate jwt key id %q", id)
+		}
+		signer, public, err := parseKeyPEM(data)
+		if err != nil {
+			return fmt.Errorf("jwt key %q: %w", id, err)
+		}
+		method, err := signingMethodFor(public)
+		if err != nil {
+			return fmt.Errorf("jwt key %q: %w", id, err)
+		}
+		ks.verification[id] = verificationKey{id: id, method: method, publicKey: public}
+		if signer != nil {
+			private[id] = &signingKey{id: id, method: method, privateKey: signer}
+		}
+		return nil
+	}
+
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read jwt key: %w", err)
+			}
+			if err := add(strings.TrimSuffix(filepath.Base(file), ".pem"), data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if inlinePEM != "" {
+		if signingKeyID == "" {
+			return nil, errors.New("an inline jwt key needs a signing key id")
+		}
+		if err := add(signingKeyID, []byte(inlinePEM)); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case signingKeyID != "":
+		ks.signing = private[signingKeyID]
+		if ks.signing == nil {
+			return nil, fmt.Errorf("%w: no private key with id %q", ErrNoSigningKey, signingKeyID)
+		}
+	case len(private) == 1:
+		for _, key := range private {
+			ks.signing = key
+		}
+	case len(private) > 1:
+		return nil, errors.New("several private jwt keys found, set the signing key id")
+	default:
+		return nil, ErrNoSigningKey
+	}
+
+	return ks, nil
+}
+
+// publicKey returns the verification key for a token header, the algorithm has to match the key
+func (ks *KeySet) publicKey(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok := ks.verification[kid]
+	if !ok || t.Method.Alg() != key.method.Alg() {
+		return nil, ErrInvalidToken
+	}
+	return key.publicKey, nil
+}
+
+func (ks *KeySet) sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.id
+	return token.SignedString(ks.signing.privateKey)
+}
+
+// JSONWebKey is the public part of a key as published in the JWKS document
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns every verification key so other services can check our tokens
+func JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	if keys == nil {
+		return set
+	}
+
+	ids := make([]string, 0, len(keys.verification))
+	for id := range keys.verification {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		key := keys.verification[id]
+		jwk := JSONWebKey{Kid: key.id, Use: "sig", Alg: key.method.Alg()}
+		switch public := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// parseKeyPEM returns the signer for private keys, nil for public keys, and the public key in both cases
+func parseKeyPEM(data []byte) (crypto.Signer, crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, nil, errors.New("unsupported private key")
+		}
+		return signer, signer.Public(), nil
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, key.Public(), nil
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, key, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+func signingMethodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("rsa key must be at least %d bits", minRSAKeyBits)
+		}
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+}